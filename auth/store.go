@@ -30,6 +30,9 @@ type AuthStore interface {
 	// AuthEnable() turns on the authentication feature
 	AuthEnable()
 
+	// IsAuthEnabled returns true if the authentication feature is enabled
+	IsAuthEnabled() bool
+
 	// Recover recovers the state of auth store from the given backend
 	Recover(b backend.Backend)
 }
@@ -51,6 +54,14 @@ func (as *authStore) AuthEnable() {
 	plog.Noticef("Authentication enabled")
 }
 
+func (as *authStore) IsAuthEnabled() bool {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+	_, vs := tx.UnsafeRange(authBucketName, enableFlagKey, nil, 0)
+	return len(vs) == 1 && len(vs[0]) == 1 && vs[0][0] == 1
+}
+
 func (as *authStore) Recover(be backend.Backend) {
 	as.be = be
 	// TODO(mitake): recovery process
@@ -22,11 +22,15 @@ import (
 
 type (
 	AuthEnableResponse pb.AuthEnableResponse
+	AuthStatusResponse pb.AuthStatusResponse
 )
 
 type Auth interface {
 	// AuthEnable enables auth of a etcd cluster.
 	AuthEnable(ctx context.Context) (*AuthEnableResponse, error)
+
+	// AuthStatus reports whether auth of a etcd cluster is enabled.
+	AuthStatus(ctx context.Context) (*AuthStatusResponse, error)
 }
 
 type auth struct {
@@ -40,12 +44,21 @@ func NewAuth(c *Client) Auth {
 	conn := c.ActiveConnection()
 	return &auth{
 		conn:   c.ActiveConnection(),
-		remote: pb.NewAuthClient(conn),
+		remote: c.retryAuthClient(pb.NewAuthClient(conn)),
 		c:      c,
 	}
 }
 
 func (auth *auth) AuthEnable(ctx context.Context) (*AuthEnableResponse, error) {
+	ctx, cancel := auth.c.requestContext(ctx)
+	defer cancel()
 	resp, err := auth.remote.AuthEnable(ctx, &pb.AuthEnableRequest{})
 	return (*AuthEnableResponse)(resp), err
 }
+
+func (auth *auth) AuthStatus(ctx context.Context) (*AuthStatusResponse, error) {
+	ctx, cancel := auth.c.requestContext(ctx)
+	defer cancel()
+	resp, err := auth.remote.AuthStatus(ctx, &pb.AuthStatusRequest{})
+	return (*AuthStatusResponse)(resp), err
+}
@@ -16,22 +16,47 @@ package clientv3
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"net"
-	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 )
 
 var (
-	ErrNoAvailableEndpoints = errors.New("etcdclient: no available endpoints")
+	ErrNoAvailableEndpoints  = errors.New("etcdclient: no available endpoints")
+	ErrOldCluster            = errors.New("etcdclient: old cluster version")
+	ErrInvalidStartRevision  = errors.New("etcdclient: start revision must be positive")
+	ErrInvalidOp             = errors.New("etcdclient: option is not valid for the operation")
+	ErrDuplicateComparison   = errors.New("etcdclient: duplicate comparison on the same key and target")
+	ErrWatchReconnectTimeout = errors.New("etcdclient: watch reconnect timed out")
+	ErrInvalidEtag           = errors.New("etcdclient: etag must be <cluster ID>/<revision>")
+	ErrWatchEtagMatch        = errors.New("etcdclient: revision has not changed since etag")
 )
 
+const (
+	// DefaultDialTimeout is the dial timeout used when Config.DialTimeout
+	// is zero.
+	DefaultDialTimeout = 5 * time.Second
+	// NoDialTimeout makes dialing block until a connection is established.
+	NoDialTimeout = -1
+)
+
+// minSupportedVersion is the oldest etcd version a client configured with
+// RejectOldCluster is willing to talk to.
+var minSupportedVersion = semver.Version{Major: 2, Minor: 3}
+
 // Client provides and manages an etcd v3 client session.
 type Client struct {
 	Cluster
@@ -41,6 +66,9 @@ type Client struct {
 	Auth
 	Maintenance
 
+	// ttlLeases holds the leases created for puts with WithTTL.
+	ttlLeases *ttlLeaseCache
+
 	conn   *grpc.ClientConn
 	cfg    Config
 	creds  *credentials.TransportAuthenticator
@@ -49,23 +77,125 @@ type Client struct {
 
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// goroutineWg tracks goroutines started by goAsync; Close waits on it.
+	goroutineWg sync.WaitGroup
+}
+
+// dialTimeout returns the dial timeout to use, or zero for no timeout.
+func (cfg *Config) dialTimeout() time.Duration {
+	switch {
+	case cfg.DialTimeout == 0:
+		return DefaultDialTimeout
+	case cfg.DialTimeout < 0:
+		return 0
+	}
+	return cfg.DialTimeout
+}
+
+// tlsConfig returns the TLS config with the TLSCACerts as root CAs, or nil
+// if the client does not use TLS.
+func (cfg *Config) tlsConfig() (*tls.Config, error) {
+	if len(cfg.TLSCACerts) == 0 {
+		return cfg.TLS, nil
+	}
+	tc := &tls.Config{}
+	if cfg.TLS != nil {
+		tc = cfg.TLS.Clone()
+	}
+	tc.RootCAs = x509.NewCertPool()
+	for _, f := range cfg.TLSCACerts {
+		pem, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("etcdclient: no certificates found in %s", f)
+		}
+	}
+	return tc, nil
 }
 
 // EndpointDialer is a policy for choosing which endpoint to dial next
 type EndpointDialer func(*Client) (*grpc.ClientConn, error)
 
 type Config struct {
-	// Endpoints is a list of URLs
+	// Endpoints is a list of URLs. An endpoint of the form "unix://<path>"
+	// connects over the unix domain socket at path. An endpoint of the form
+	// "endpoint://priority/<weight>/<url>" is tried before the endpoints of
+	// lower weight; endpoints without a weight have weight 0.
 	Endpoints []string
 
 	// RetryDialer chooses the next endpoint to use
 	RetryDialer EndpointDialer
 
 	// DialTimeout is the timeout for failing to establish a connection.
+	// Zero means DefaultDialTimeout; NoDialTimeout waits forever.
 	DialTimeout time.Duration
 
+	// InitialConnectionTimeout replaces DialTimeout for the first
+	// connection made by New, giving a cluster that is still starting up
+	// a longer grace period than later reconnects. Zero means DialTimeout.
+	InitialConnectionTimeout time.Duration
+
+	// Dialer, if set, opens the connections to the endpoints in place of
+	// net.DialTimeout. The network is "tcp", or "unix" for unix://
+	// endpoints; the context expires with the dial timeout or when the
+	// client is closed. If TLS is configured, the TLS handshake is made on
+	// the returned connection.
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
 	// TLS holds the client secure credentials, if any.
 	TLS *tls.Config
+
+	// TLSCACerts are paths to PEM files of the CAs trusted to sign the
+	// server certificates. If set, they replace the root CAs of TLS and
+	// enable TLS even if TLS is nil.
+	TLSCACerts []string
+
+	// RejectOldCluster makes New fail with ErrOldCluster if any endpoint
+	// runs an etcd version older than the minimum supported by this client.
+	RejectOldCluster bool
+
+	// WatchReconnectTimeout bounds how long a watcher keeps trying to
+	// re-establish a lost watch stream. Once it passes, every watch channel
+	// receives a final canceled response with ErrWatchReconnectTimeout and
+	// is closed. Zero retries without limit.
+	WatchReconnectTimeout time.Duration
+
+	// PerRPCCredentials attach request metadata, such as a bearer token,
+	// to every RPC issued by the client.
+	PerRPCCredentials []credentials.Credentials
+
+	// RetryPolicy retries RPCs that fail with the given codes. The zero
+	// value does not retry.
+	RetryPolicy RetryPolicy
+
+	// DefaultRequestTimeout bounds every request made with a context that
+	// has no deadline. It does not apply to watches and lease keep alives,
+	// which last until canceled. Zero leaves such requests unbounded.
+	DefaultRequestTimeout time.Duration
+}
+
+// RequestContext returns a copy of parent that is canceled after d. A nil
+// parent is taken as context.Background().
+func RequestContext(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, d)
+}
+
+// requestContext bounds ctx by the DefaultRequestTimeout of the client if
+// ctx has no deadline.
+func (c *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.cfg.DefaultRequestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return RequestContext(ctx, c.cfg.DefaultRequestTimeout)
 }
 
 // New creates a new etcdv3 client from a given configuration.
@@ -76,6 +206,11 @@ func New(cfg Config) (*Client, error) {
 	if len(cfg.Endpoints) == 0 {
 		return nil, ErrNoAvailableEndpoints
 	}
+	eps, err := sortEndpointsByPriority(cfg.Endpoints)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Endpoints = eps
 
 	return newClient(&cfg)
 }
@@ -85,11 +220,14 @@ func NewFromURL(url string) (*Client, error) {
 	return New(Config{Endpoints: []string{url}})
 }
 
-// Close shuts down the client's etcd connections.
+// Close shuts down the client's etcd connections. It returns once all
+// goroutines started by the client have exited.
 func (c *Client) Close() error {
 	c.mu.Lock()
 	if c.cancel == nil {
 		c.mu.Unlock()
+		// a concurrent Close may still be stopping the goroutines
+		c.goroutineWg.Wait()
 		return nil
 	}
 	c.cancel()
@@ -97,7 +235,25 @@ func (c *Client) Close() error {
 	c.mu.Unlock()
 	c.Watcher.Close()
 	c.Lease.Close()
-	return c.conn.Close()
+	err := c.conn.Close()
+	c.goroutineWg.Wait()
+	return err
+}
+
+// goAsync runs f in a goroutine tracked by goroutineWg. It does nothing
+// and returns false once the client is closed.
+func (c *Client) goAsync(f func()) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.cancel == nil {
+		return false
+	}
+	c.goroutineWg.Add(1)
+	go func() {
+		defer c.goroutineWg.Done()
+		f()
+	}()
+	return true
 }
 
 // Ctx is a context for "out of band" messages (e.g., for sending
@@ -117,23 +273,46 @@ func (c *Client) Errors() (errs []error) {
 	return errs
 }
 
+// GetAndWatch gets key and watches it for changes made after the get, so
+// no event between the two is missed. The options apply to the get; the
+// watch covers the same key range and starts at the revision after the
+// get's. Options such as WithLimit or WithSort only shape the get.
+func (c *Client) GetAndWatch(ctx context.Context, key string, opts ...OpOption) (*GetResponse, WatchChan, error) {
+	resp, err := c.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	wopts := []OpOption{WithRev(resp.Header.Revision + 1)}
+	if op := OpGet(key, opts...); op.end != nil {
+		wopts = append(wopts, WithRange(string(op.end)))
+	}
+	return resp, c.Watch(ctx, key, wopts...), nil
+}
+
 // Dial establishes a connection for a given endpoint using the client's config
 func (c *Client) Dial(endpoint string) (*grpc.ClientConn, error) {
 	opts := []grpc.DialOption{
 		grpc.WithBlock(),
-		grpc.WithTimeout(c.cfg.DialTimeout),
+	}
+	if t := c.cfg.dialTimeout(); t > 0 {
+		opts = append(opts, grpc.WithTimeout(t))
 	}
 	if c.creds != nil {
 		opts = append(opts, grpc.WithTransportCredentials(*c.creds))
 	} else {
 		opts = append(opts, grpc.WithInsecure())
 	}
+	for _, cred := range c.cfg.PerRPCCredentials {
+		opts = append(opts, grpc.WithPerRPCCredentials(cred))
+	}
 
 	proto := "tcp"
-	if url, uerr := url.Parse(endpoint); uerr == nil && url.Scheme == "unix" {
+	if strings.HasPrefix(endpoint, "unix://") {
 		proto = "unix"
-		// strip unix:// prefix so certs work
-		endpoint = url.Host
+		// strip unix:// prefix so certs work; the remainder is the
+		// socket path, e.g. "unix:///var/run/etcd.sock" dials
+		// "/var/run/etcd.sock"
+		endpoint = strings.TrimPrefix(endpoint, "unix://")
 	}
 	f := func(a string, t time.Duration) (net.Conn, error) {
 		select {
@@ -141,7 +320,15 @@ func (c *Client) Dial(endpoint string) (*grpc.ClientConn, error) {
 			return nil, c.ctx.Err()
 		default:
 		}
-		return net.DialTimeout(proto, a, t)
+		if c.cfg.Dialer == nil {
+			return net.DialTimeout(proto, a, t)
+		}
+		dctx, cancel := c.ctx, context.CancelFunc(func() {})
+		if t > 0 {
+			dctx, cancel = context.WithTimeout(c.ctx, t)
+		}
+		defer cancel()
+		return c.cfg.Dialer(dctx, proto, a)
 	}
 	opts = append(opts, grpc.WithDialer(f))
 
@@ -156,14 +343,22 @@ func newClient(cfg *Config) (*Client, error) {
 	if cfg == nil {
 		cfg = &Config{RetryDialer: dialEndpointList}
 	}
+	tlscfg, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
 	var creds *credentials.TransportAuthenticator
-	if cfg.TLS != nil {
-		c := credentials.NewTLS(cfg.TLS)
+	if tlscfg != nil {
+		c := credentials.NewTLS(tlscfg)
 		creds = &c
 	}
 	// use a temporary skeleton client to bootstrap first connection
+	bootCfg := *cfg
+	if cfg.InitialConnectionTimeout != 0 {
+		bootCfg.DialTimeout = cfg.InitialConnectionTimeout
+	}
 	ctx, cancel := context.WithCancel(context.TODO())
-	conn, err := cfg.RetryDialer(&Client{cfg: *cfg, creds: creds, ctx: ctx})
+	conn, err := cfg.RetryDialer(&Client{cfg: bootCfg, creds: creds, ctx: ctx})
 	if err != nil {
 		return nil, err
 	}
@@ -177,13 +372,50 @@ func newClient(cfg *Config) (*Client, error) {
 	client.Cluster = NewCluster(client)
 	client.KV = NewKV(client)
 	client.Lease = NewLease(client)
+	client.ttlLeases = newTTLLeaseCache(client.Lease)
 	client.Watcher = NewWatcher(client)
 	client.Auth = NewAuth(client)
 	client.Maintenance = &maintenance{c: client}
 
+	if cfg.RejectOldCluster {
+		if err := client.checkVersion(); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
 	return client, nil
 }
 
+// checkVersion probes the status of every endpoint and returns ErrOldCluster
+// if any of them runs a version older than minSupportedVersion.
+func (c *Client) checkVersion() error {
+	ctx := c.ctx
+	if t := c.cfg.dialTimeout(); t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	for _, ep := range c.Endpoints() {
+		resp, err := c.Status(ctx, ep)
+		if err != nil {
+			if grpc.Code(err) == codes.Unimplemented {
+				// member predates the Status RPC
+				return ErrOldCluster
+			}
+			return err
+		}
+		v, err := semver.NewVersion(resp.Version)
+		if err != nil {
+			return err
+		}
+		if v.LessThan(minSupportedVersion) {
+			return ErrOldCluster
+		}
+	}
+	return nil
+}
+
 // ActiveConnection returns the current in-use connection
 func (c *Client) ActiveConnection() *grpc.ClientConn {
 	c.mu.RLock()
@@ -236,6 +468,46 @@ func dialEndpointList(c *Client) (*grpc.ClientConn, error) {
 	return nil, err
 }
 
+const priorityEndpointPrefix = "endpoint://priority/"
+
+type priorityEndpoint struct {
+	weight int
+	ep     string
+}
+
+type byPriority []priorityEndpoint
+
+func (p byPriority) Len() int           { return len(p) }
+func (p byPriority) Less(i, j int) bool { return p[i].weight > p[j].weight }
+func (p byPriority) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// sortEndpointsByPriority strips the weight from the endpoints given as
+// "endpoint://priority/<weight>/<url>" and orders all endpoints by
+// decreasing weight, so dialing tries the preferred endpoints first.
+// Endpoints of the same weight keep their order.
+func sortEndpointsByPriority(eps []string) ([]string, error) {
+	peps := make([]priorityEndpoint, len(eps))
+	for i, ep := range eps {
+		peps[i].ep = ep
+		if !strings.HasPrefix(ep, priorityEndpointPrefix) {
+			continue
+		}
+		s := strings.SplitN(strings.TrimPrefix(ep, priorityEndpointPrefix), "/", 2)
+		w, err := strconv.Atoi(s[0])
+		if err != nil || w < 0 || len(s) != 2 || s[1] == "" {
+			return nil, fmt.Errorf("etcdclient: bad priority endpoint %q, expected %s<weight>/<url>", ep, priorityEndpointPrefix)
+		}
+		peps[i] = priorityEndpoint{weight: w, ep: s[1]}
+	}
+	sort.Stable(byPriority(peps))
+
+	sorted := make([]string, len(peps))
+	for i := range peps {
+		sorted[i] = peps[i].ep
+	}
+	return sorted, nil
+}
+
 // isHalted returns true if the given error and context indicate no forward
 // progress can be made, even after reconnecting.
 func isHalted(ctx context.Context, err error) bool {
@@ -15,12 +15,28 @@
 package clientv3
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"reflect"
 	"testing"
 	"time"
 
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestDialTimeout(t *testing.T) {
@@ -55,6 +71,202 @@ func TestDialTimeout(t *testing.T) {
 	}
 }
 
+func TestDialTimeoutDefault(t *testing.T) {
+	donec := make(chan error)
+	go func() {
+		// zero falls back to DefaultDialTimeout instead of blocking
+		c, err := New(Config{Endpoints: []string{"localhost:12345"}})
+		if c != nil || err == nil {
+			t.Errorf("new client should fail")
+		}
+		donec <- err
+	}()
+
+	select {
+	case <-time.After(DefaultDialTimeout + time.Second):
+		t.Errorf("failed to timeout dial on time")
+	case err := <-donec:
+		if err != grpc.ErrClientConnTimeout {
+			t.Errorf("unexpected error %v, want %v", err, grpc.ErrClientConnTimeout)
+		}
+	}
+}
+
+type bearerCreds string
+
+func (b bearerCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+func (b bearerCreds) RequireTransportSecurity() bool { return false }
+
+// mdKVServer records the metadata of each Range request it receives.
+type mdKVServer struct {
+	pb.KVServer
+	mdc chan metadata.MD
+}
+
+func (s *mdKVServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
+	md, _ := metadata.FromContext(ctx)
+	s.mdc <- md
+	return &pb.RangeResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+func TestPerRPCCredentials(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	kvs := &mdKVServer{mdc: make(chan metadata.MD, 1)}
+	pb.RegisterKVServer(srv, kvs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	cli, err := New(Config{
+		Endpoints:         []string{l.Addr().String()},
+		PerRPCCredentials: []credentials.Credentials{bearerCreds("abc")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.Get(context.TODO(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+	md := <-kvs.mdc
+	if w := []string{"Bearer abc"}; !reflect.DeepEqual(md["authorization"], w) {
+		t.Errorf("authorization = %v, want %v", md["authorization"], w)
+	}
+}
+
+func TestOpAttributes(t *testing.T) {
+	op := OpGet("foo", WithAttr("Trace-Id", "abc"))
+	if w := map[string]string{"Trace-Id": "abc"}; !reflect.DeepEqual(op.Attributes(), w) {
+		t.Errorf("attributes = %v, want %v", op.Attributes(), w)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	kvs := &mdKVServer{mdc: make(chan metadata.MD, 1)}
+	pb.RegisterKVServer(srv, kvs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	cli, err := New(Config{Endpoints: []string{l.Addr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// attributes are added to metadata already on the context
+	ctx := metadata.NewContext(context.TODO(), metadata.Pairs("foo", "bar"))
+	if _, err = cli.Do(ctx, op); err != nil {
+		t.Fatal(err)
+	}
+	md := <-kvs.mdc
+	for k, v := range map[string]string{"trace-id": "abc", "foo": "bar"} {
+		if !reflect.DeepEqual(md[k], []string{v}) {
+			t.Errorf("metadata %q = %v, want [%s]", k, md[k], v)
+		}
+	}
+}
+
+// newTestCert creates a certificate for 127.0.0.1 signed by parent, or a
+// self-signed CA certificate if parent is nil.
+func newTestCert(t *testing.T, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{Organization: []string{"etcd"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, interface{}(key)
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		if signer, err = x509.ParseCertificate(parent.Certificate[0]); err != nil {
+			t.Fatal(err)
+		}
+		signerKey = parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writeTestCert(t *testing.T, dir, name string, c tls.Certificate) string {
+	p := path.Join(dir, name)
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Certificate[0]})
+	if err := ioutil.WriteFile(p, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTLSCACerts(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "clientv3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca1, ca2 := newTestCert(t, nil), newTestCert(t, nil)
+	ca1File := writeTestCert(t, dir, "ca1.crt", ca1)
+	ca2File := writeTestCert(t, dir, "ca2.crt", ca2)
+
+	// the server certificate is signed by the second CA only
+	srvCert := newTestCert(t, &ca2)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{srvCert}})
+	srv := grpc.NewServer(grpc.Creds(creds))
+	kvs := &mdKVServer{mdc: make(chan metadata.MD, 1)}
+	pb.RegisterKVServer(srv, kvs)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	cli, err := New(Config{
+		Endpoints:   []string{l.Addr().String()},
+		DialTimeout: time.Second,
+		TLSCACerts:  []string{ca1File, ca2File},
+	})
+	if err != nil {
+		t.Fatalf("failed to dial with both CAs (%v)", err)
+	}
+	defer cli.Close()
+	if _, err = cli.Get(context.TODO(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first CA alone does not verify the server
+	cfg := Config{TLSCACerts: []string{ca1File}}
+	tc, err := cfg.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := tls.Dial("tcp", l.Addr().String(), tc)
+	if err == nil {
+		conn.Close()
+		t.Fatalf("expected certificate verification error")
+	}
+}
+
 func TestIsHalted(t *testing.T) {
 	if !isHalted(nil, fmt.Errorf("etcdserver: some etcdserver error")) {
 		t.Errorf(`error prefixed with "etcdserver: " should be Halted`)
@@ -68,3 +280,60 @@ func TestIsHalted(t *testing.T) {
 		t.Errorf("cancel on context should be Halted")
 	}
 }
+
+func TestSortEndpointsByPriority(t *testing.T) {
+	tests := []struct {
+		eps []string
+
+		weps []string
+		werr bool
+	}{
+		{[]string{"a:1", "b:2"}, []string{"a:1", "b:2"}, false},
+		{
+			[]string{"endpoint://priority/1/http://remote:2379", "endpoint://priority/10/http://local:2379"},
+			[]string{"http://local:2379", "http://remote:2379"},
+			false,
+		},
+		// no weight is weight 0; equal weights keep their order
+		{
+			[]string{"a:1", "endpoint://priority/0/b:2", "endpoint://priority/5/c:3", "endpoint://priority/5/d:4"},
+			[]string{"c:3", "d:4", "a:1", "b:2"},
+			false,
+		},
+		{[]string{"endpoint://priority/x/a:1"}, nil, true},
+		{[]string{"endpoint://priority/-1/a:1"}, nil, true},
+		{[]string{"endpoint://priority/1"}, nil, true},
+		{[]string{"endpoint://priority/1/"}, nil, true},
+	}
+	for i, tt := range tests {
+		eps, err := sortEndpointsByPriority(tt.eps)
+		if (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+			continue
+		}
+		if !reflect.DeepEqual(eps, tt.weps) {
+			t.Errorf("#%d: endpoints = %v, want %v", i, eps, tt.weps)
+		}
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	ctx, cancel := RequestContext(nil, time.Minute)
+	defer cancel()
+	if dl, ok := ctx.Deadline(); !ok || dl.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("deadline = %v (%v), want within a minute", dl, ok)
+	}
+
+	c := &Client{cfg: Config{DefaultRequestTimeout: time.Minute}}
+	// a context with a deadline is kept as is
+	rctx, rcancel := c.requestContext(ctx)
+	rcancel()
+	if rctx != ctx {
+		t.Errorf("context with deadline was replaced")
+	}
+	rctx, rcancel = c.requestContext(context.Background())
+	defer rcancel()
+	if _, ok := rctx.Deadline(); !ok {
+		t.Errorf("context without deadline was not bounded")
+	}
+}
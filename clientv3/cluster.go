@@ -15,6 +15,11 @@
 package clientv3
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
 	"sync"
 
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
@@ -37,16 +42,63 @@ type Cluster interface {
 	// MemberLeader returns the current leader member.
 	MemberLeader(ctx context.Context) (*Member, error)
 
-	// MemberAdd adds a new member into the cluster.
+	// MemberAdd adds a new member into the cluster. Its peer addresses are
+	// checked before the request is sent; see ErrInvalidPeerURL.
 	MemberAdd(ctx context.Context, peerAddrs []string) (*MemberAddResponse, error)
 
 	// MemberRemove removes an existing member from the cluster.
 	MemberRemove(ctx context.Context, id uint64) (*MemberRemoveResponse, error)
 
-	// MemberUpdate updates the peer addresses of the member.
+	// MemberUpdate updates the peer addresses of the member. They are
+	// checked as for MemberAdd.
 	MemberUpdate(ctx context.Context, id uint64, peerAddrs []string) (*MemberUpdateResponse, error)
 }
 
+// ErrInvalidPeerURL is returned by MemberAdd and MemberUpdate, without
+// contacting the cluster, for a peer URL that is not an http or https URL
+// with a host and a non-zero port.
+type ErrInvalidPeerURL struct {
+	URL    string
+	Reason string
+}
+
+func (e *ErrInvalidPeerURL) Error() string {
+	return fmt.Sprintf("etcdclient: invalid peer URL %q (%s)", e.URL, e.Reason)
+}
+
+// validatePeerURLs returns an *ErrInvalidPeerURL for the first unusable
+// peer URL.
+func validatePeerURLs(peerAddrs []string) error {
+	for _, addr := range peerAddrs {
+		if reason := checkPeerURL(addr); reason != "" {
+			return &ErrInvalidPeerURL{URL: addr, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// checkPeerURL returns why addr is not a valid peer URL, or "" if it is.
+func checkPeerURL(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return err.Error()
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "scheme must be http or https"
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	switch {
+	case err != nil:
+		return "missing port"
+	case host == "":
+		return "missing host"
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return "port must be between 1 and 65535"
+	}
+	return ""
+}
+
 type cluster struct {
 	c *Client
 
@@ -62,11 +114,16 @@ func NewCluster(c *Client) Cluster {
 		c: c,
 
 		conn:   conn,
-		remote: pb.NewClusterClient(conn),
+		remote: c.retryClusterClient(pb.NewClusterClient(conn)),
 	}
 }
 
 func (c *cluster) MemberAdd(ctx context.Context, peerAddrs []string) (*MemberAddResponse, error) {
+	ctx, cancel := c.c.requestContext(ctx)
+	defer cancel()
+	if err := validatePeerURLs(peerAddrs); err != nil {
+		return nil, err
+	}
 	r := &pb.MemberAddRequest{PeerURLs: peerAddrs}
 	resp, err := c.getRemote().MemberAdd(ctx, r)
 	if err == nil {
@@ -77,11 +134,13 @@ func (c *cluster) MemberAdd(ctx context.Context, peerAddrs []string) (*MemberAdd
 		return nil, err
 	}
 
-	go c.switchRemote(err)
+	c.c.goAsync(func() { c.switchRemote(err) })
 	return nil, err
 }
 
 func (c *cluster) MemberRemove(ctx context.Context, id uint64) (*MemberRemoveResponse, error) {
+	ctx, cancel := c.c.requestContext(ctx)
+	defer cancel()
 	r := &pb.MemberRemoveRequest{ID: id}
 	resp, err := c.getRemote().MemberRemove(ctx, r)
 	if err == nil {
@@ -92,11 +151,16 @@ func (c *cluster) MemberRemove(ctx context.Context, id uint64) (*MemberRemoveRes
 		return nil, err
 	}
 
-	go c.switchRemote(err)
+	c.c.goAsync(func() { c.switchRemote(err) })
 	return nil, err
 }
 
 func (c *cluster) MemberUpdate(ctx context.Context, id uint64, peerAddrs []string) (*MemberUpdateResponse, error) {
+	ctx, cancel := c.c.requestContext(ctx)
+	defer cancel()
+	if err := validatePeerURLs(peerAddrs); err != nil {
+		return nil, err
+	}
 	// it is safe to retry on update.
 	for {
 		r := &pb.MemberUpdateRequest{ID: id, PeerURLs: peerAddrs}
@@ -117,10 +181,14 @@ func (c *cluster) MemberUpdate(ctx context.Context, id uint64, peerAddrs []strin
 }
 
 func (c *cluster) MemberList(ctx context.Context) (*MemberListResponse, error) {
+	ctx, cancel := c.c.requestContext(ctx)
+	defer cancel()
 	// it is safe to retry on list.
 	for {
 		resp, err := c.getRemote().MemberList(ctx, &pb.MemberListRequest{})
 		if err == nil {
+			// the server lists members in map order
+			sort.Sort(membersByID(resp.Members))
 			return (*MemberListResponse)(resp), nil
 		}
 
@@ -135,6 +203,12 @@ func (c *cluster) MemberList(ctx context.Context) (*MemberListResponse, error) {
 	}
 }
 
+type membersByID []*pb.Member
+
+func (ms membersByID) Len() int           { return len(ms) }
+func (ms membersByID) Less(i, j int) bool { return ms[i].ID < ms[j].ID }
+func (ms membersByID) Swap(i, j int)      { ms[i], ms[j] = ms[j], ms[i] }
+
 func (c *cluster) MemberLeader(ctx context.Context) (*Member, error) {
 	resp, err := c.MemberList(ctx)
 	if err != nil {
@@ -165,6 +239,6 @@ func (c *cluster) switchRemote(prevErr error) error {
 	defer c.mu.Unlock()
 
 	c.conn = newConn
-	c.remote = pb.NewClusterClient(c.conn)
+	c.remote = c.c.retryClusterClient(pb.NewClusterClient(c.conn))
 	return nil
 }
@@ -0,0 +1,54 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestMemberAddInvalidPeerURL(t *testing.T) {
+	// no connection; an invalid URL must fail before any RPC
+	c := NewCluster(&Client{})
+
+	tests := []struct {
+		url    string
+		reason string
+	}{
+		{"http://127.0.0.1", "missing port"},
+		{"unix://127.0.0.1:2380", "scheme must be http or https"},
+		// parsed as scheme "localhost"
+		{"localhost:2380", "scheme must be http or https"},
+		{"http://:2380", "missing host"},
+		{"http://127.0.0.1:0", "port must be between 1 and 65535"},
+		{"https://127.0.0.1:70000", "port must be between 1 and 65535"},
+	}
+	for i, tt := range tests {
+		urls := []string{"http://127.0.0.1:2380", tt.url}
+		_, err := c.MemberAdd(context.TODO(), urls)
+		perr, ok := err.(*ErrInvalidPeerURL)
+		if !ok {
+			t.Errorf("#%d: err = %v, want *ErrInvalidPeerURL", i, err)
+			continue
+		}
+		if perr.URL != tt.url || perr.Reason != tt.reason {
+			t.Errorf("#%d: err = %+v, want URL %q and reason %q", i, perr, tt.url, tt.reason)
+		}
+		if _, err = c.MemberUpdate(context.TODO(), 1, urls); err == nil {
+			t.Errorf("#%d: expected MemberUpdate to fail", i)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package concurrency
 
 import (
 	"errors"
+	"time"
 
 	v3 "github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/storage/storagepb"
@@ -31,6 +32,9 @@ type Election struct {
 	client *v3.Client
 
 	keyPrefix string
+	// session, if set, holds the lease for the leader key; otherwise
+	// the client's shared session is used.
+	session *Session
 
 	leaderKey     string
 	leaderRev     int64
@@ -45,9 +49,12 @@ func NewElection(client *v3.Client, pfx string) *Election {
 // Campaign puts a value as eligible for the election. It blocks until
 // it is elected, an error occurs, or the context is cancelled.
 func (e *Election) Campaign(ctx context.Context, val string) error {
-	s, serr := NewSession(e.client)
-	if serr != nil {
-		return serr
+	s := e.session
+	if s == nil {
+		var serr error
+		if s, serr = NewSession(e.client); serr != nil {
+			return serr
+		}
 	}
 
 	k, rev, err := NewUniqueKV(ctx, e.client, e.keyPrefix, val, v3.WithLease(s.Lease()))
@@ -69,6 +76,37 @@ func (e *Election) Campaign(ctx context.Context, val string) error {
 	return nil
 }
 
+type campaignOp struct {
+	timeout time.Duration
+}
+
+// CampaignOption configures LeaderCampaign.
+type CampaignOption func(*campaignOp)
+
+// WithCampaignTimeout gives up the campaign if it is not won within d.
+func WithCampaignTimeout(d time.Duration) CampaignOption {
+	return func(op *campaignOp) { op.timeout = d }
+}
+
+// LeaderCampaign campaigns on the election prefix with the given session's
+// lease and returns the election only once it has been won.
+func LeaderCampaign(ctx context.Context, s *Session, pfx, val string, opts ...CampaignOption) (*Election, error) {
+	op := &campaignOp{}
+	for _, opt := range opts {
+		opt(op)
+	}
+	if op.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, op.timeout)
+		defer cancel()
+	}
+	e := &Election{client: s.client, keyPrefix: pfx, session: s}
+	if err := e.Campaign(ctx, val); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 // Proclaim lets the leader announce a new value without another election.
 func (e *Election) Proclaim(ctx context.Context, val string) error {
 	if e.leaderSession == nil {
@@ -112,8 +150,9 @@ func (e *Election) Leader() (string, error) {
 }
 
 // Observe returns a channel that observes all leader proposal values as
-// GetResponse values on the current leader key. The channel closes when
-// the context is cancelled or the underlying watcher is otherwise disrupted.
+// GetResponse values on the current leader key. If a leader is elected, its
+// value is the first response. The channel closes when the context is
+// cancelled or the underlying watcher is otherwise disrupted.
 func (e *Election) Observe(ctx context.Context) <-chan v3.GetResponse {
 	retc := make(chan v3.GetResponse)
 	go e.observe(ctx, retc)
@@ -129,6 +168,8 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 		}
 
 		var kv *storagepb.KeyValue
+		// rev is the revision to watch the leader key from
+		var rev int64
 
 		cctx, cancel := context.WithCancel(ctx)
 		if len(resp.Kvs) == 0 {
@@ -146,15 +187,30 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 				for _, ev := range wr.Events {
 					if ev.Type == storagepb.PUT {
 						kv = ev.Kv
+						rev = kv.ModRevision + 1
+						resp.Header = &wr.Header
 						break
 					}
 				}
 			}
 		} else {
+			// the get saw the leader key as of its revision, which may be
+			// past a compaction of the key's last put
 			kv = resp.Kvs[0]
+			rev = resp.Header.Revision + 1
+		}
+
+		// send the current leader instead of relying on the watch to
+		// replay its put, which may have been compacted
+		resp.Kvs = []*storagepb.KeyValue{kv}
+		select {
+		case ch <- *resp:
+		case <-cctx.Done():
+			cancel()
+			return
 		}
 
-		wch := e.client.Watch(cctx, string(kv.Key), v3.WithRev(kv.ModRevision))
+		wch := e.client.Watch(cctx, string(kv.Key), v3.WithRev(rev))
 		keyDeleted := false
 		for !keyDeleted {
 			wr, ok := <-wch
@@ -181,3 +237,7 @@ func (e *Election) observe(ctx context.Context, ch chan<- v3.GetResponse) {
 
 // Key returns the leader key if elected, empty string otherwise.
 func (e *Election) Key() string { return e.leaderKey }
+
+// FencingToken returns the create revision of the leader key if elected,
+// to pass with Key to clientv3.WithFencingToken.
+func (e *Election) FencingToken() int64 { return e.leaderRev }
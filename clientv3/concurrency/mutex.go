@@ -69,6 +69,10 @@ func (m *Mutex) IsOwner() v3.Cmp {
 
 func (m *Mutex) Key() string { return m.myKey }
 
+// FencingToken returns the create revision of the lock key, to pass with
+// Key to clientv3.WithFencingToken.
+func (m *Mutex) FencingToken() int64 { return m.myRev }
+
 type lockerMutex struct{ *Mutex }
 
 func (lm *lockerMutex) Lock() {
@@ -24,7 +24,9 @@ type STM interface {
 	// Get returns the value for a key and inserts the key in the txn's read set.
 	// If Get fails, it aborts the transaction with an error, never returning.
 	Get(key string) string
-	// Put adds a value for a key to the write set.
+	// Put adds a value for a key to the write set. The key is written
+	// without a lease unless opts attach one; WithKeepLease keeps the lease
+	// the key has when the txn commits.
 	Put(key, val string, opts ...v3.OpOption)
 	// Rev returns the revision of a key in the read set.
 	Rev(key string) int64
@@ -115,6 +117,8 @@ func (s *stm) Get(key string) string {
 }
 
 func (s *stm) Put(key, val string, opts ...v3.OpOption) {
+	// default to no lease; an explicit WithLease in opts still applies
+	opts = append([]v3.OpOption{v3.WithIgnoreLease()}, opts...)
 	s.wset[key] = stmPut{val, v3.OpPut(key, val, opts...)}
 }
 
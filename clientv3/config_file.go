@@ -0,0 +1,237 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadConfig reads a client configuration from the YAML file at path.
+//
+// Only a flat subset of YAML is understood: one "key: value" per line, with
+// a list given either as "[a, b]" or as "- item" lines following the key.
+// Blank lines and "#" comments are ignored. Other YAML syntax, such as
+// nested mappings, flow mappings, block scalars, anchors, aliases, tags and
+// document markers, is rejected. The keys are:
+//
+//	endpoints        list of endpoints
+//	dial-timeout     dial timeout, e.g. 5s
+//	cert-file        client certificate file; requires key-file
+//	key-file         client key file; requires cert-file
+//	trusted-ca-file  CA file, or list of CA files, to verify the servers
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseConfigFile(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+
+	cfg := &Config{}
+	var certFile, keyFile string
+	for _, e := range entries {
+		if e.key != "endpoints" && e.key != "trusted-ca-file" && len(e.vals) != 1 {
+			return nil, fmt.Errorf("%s:%d: %q expects a single value", path, e.line, e.key)
+		}
+		switch e.key {
+		case "endpoints":
+			cfg.Endpoints = e.vals
+		case "dial-timeout":
+			if cfg.DialTimeout, err = time.ParseDuration(e.vals[0]); err != nil {
+				return nil, fmt.Errorf("%s:%d: bad dial-timeout (%v)", path, e.line, err)
+			}
+		case "cert-file":
+			certFile = e.vals[0]
+		case "key-file":
+			keyFile = e.vals[0]
+		case "trusted-ca-file":
+			cfg.TLSCACerts = e.vals
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown key %q", path, e.line, e.key)
+		}
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("%s: cert-file and key-file must be given together", path)
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		cfg.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	return cfg, nil
+}
+
+// NewFromConfigFile creates a client from the configuration file at path;
+// see LoadConfig for its format.
+func NewFromConfigFile(path string) (*Client, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("%s: no endpoints", path)
+	}
+	if cfg.DialTimeout < 0 {
+		return nil, fmt.Errorf("%s: dial-timeout must not be negative", path)
+	}
+	c, err := New(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// configEntry is a key of a configuration file with its values.
+type configEntry struct {
+	line int
+	key  string
+	vals []string
+}
+
+// parseConfigFile parses the YAML subset described by LoadConfig. Errors
+// are prefixed by the line number.
+func parseConfigFile(s string) ([]*configEntry, error) {
+	var (
+		entries []*configEntry
+		seen    = make(map[string]bool)
+		// list is the entry that takes the following "- item" lines
+		list *configEntry
+	)
+	for i, l := range strings.Split(s, "\n") {
+		n := i + 1
+		if j := strings.Index(l, " #"); j >= 0 {
+			l = l[:j]
+		}
+		indented := strings.TrimLeft(l, " \t") != l
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if l == "---" || l == "..." {
+			return nil, fmt.Errorf("%d: document markers are not supported", n)
+		}
+
+		if l == "-" || strings.HasPrefix(l, "- ") {
+			if list == nil {
+				return nil, fmt.Errorf("%d: list item without a key", n)
+			}
+			item := strings.TrimSpace(l[1:])
+			if strings.Contains(item, ": ") || strings.HasSuffix(item, ":") {
+				return nil, fmt.Errorf("%d: lists of mappings are not supported", n)
+			}
+			if err := checkConfigScalar(item); err != nil {
+				return nil, fmt.Errorf("%d: %v", n, err)
+			}
+			v, err := unquoteConfigValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", n, err)
+			}
+			list.vals = append(list.vals, v)
+			continue
+		}
+
+		if indented {
+			return nil, fmt.Errorf("%d: nested mappings are not supported", n)
+		}
+		j := strings.Index(l, ":")
+		if j <= 0 {
+			return nil, fmt.Errorf("%d: expected \"key: value\"", n)
+		}
+		e := &configEntry{line: n, key: strings.TrimSpace(l[:j])}
+		if seen[e.key] {
+			return nil, fmt.Errorf("%d: duplicate key %q", n, e.key)
+		}
+		seen[e.key] = true
+		entries = append(entries, e)
+
+		list = nil
+		val := strings.TrimSpace(l[j+1:])
+		switch {
+		case val == "":
+			list = e
+		case strings.HasPrefix(val, "["):
+			if !strings.HasSuffix(val, "]") {
+				return nil, fmt.Errorf("%d: list must end with \"]\" on the same line", n)
+			}
+			for _, item := range strings.Split(val[1:len(val)-1], ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				if err := checkConfigScalar(item); err != nil {
+					return nil, fmt.Errorf("%d: %v", n, err)
+				}
+				v, err := unquoteConfigValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("%d: %v", n, err)
+				}
+				e.vals = append(e.vals, v)
+			}
+		default:
+			if err := checkConfigScalar(val); err != nil {
+				return nil, fmt.Errorf("%d: %v", n, err)
+			}
+			v, err := unquoteConfigValue(val)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", n, err)
+			}
+			e.vals = []string{v}
+		}
+	}
+	return entries, nil
+}
+
+// checkConfigScalar returns an error if v starts a YAML construct other
+// than a plain or quoted scalar.
+func checkConfigScalar(v string) error {
+	if v == "" {
+		return nil
+	}
+	switch v[0] {
+	case '{':
+		return fmt.Errorf("flow mappings are not supported")
+	case '[':
+		return fmt.Errorf("nested lists are not supported")
+	case '|', '>':
+		return fmt.Errorf("block scalars are not supported")
+	case '&', '*', '!':
+		return fmt.Errorf("anchors, aliases and tags are not supported")
+	}
+	return nil
+}
+
+// unquoteConfigValue removes the quotes of a double or single quoted
+// scalar, and returns any other scalar as is.
+func unquoteConfigValue(v string) (string, error) {
+	if len(v) < 2 {
+		return v, nil
+	}
+	switch {
+	case v[0] == '"' && v[len(v)-1] == '"':
+		return strconv.Unquote(v)
+	case v[0] == '\'' && v[len(v)-1] == '\'':
+		return strings.Replace(v[1:len(v)-1], "''", "'", -1), nil
+	}
+	return v, nil
+}
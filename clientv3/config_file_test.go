@@ -0,0 +1,108 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		s string
+
+		weps     []string
+		wtimeout time.Duration
+		wcas     []string
+		werr     string
+	}{
+		{
+			s: `# client config
+endpoints:
+- 127.0.0.1:2379
+- "127.0.0.1:22379" # second member
+dial-timeout: 3s
+trusted-ca-file: ca.crt
+`,
+			weps:     []string{"127.0.0.1:2379", "127.0.0.1:22379"},
+			wtimeout: 3 * time.Second,
+			wcas:     []string{"ca.crt"},
+		},
+		{
+			s:    "endpoints: [a:1, 'b:2']\ntrusted-ca-file: [ca1.crt, ca2.crt]\n",
+			weps: []string{"a:1", "b:2"},
+			wcas: []string{"ca1.crt", "ca2.crt"},
+		},
+		{s: "endpoint: a:1\n", werr: `:1: unknown key "endpoint"`},
+		{s: "endpoints: a:1\nendpoints: b:2\n", werr: `:2: duplicate key "endpoints"`},
+		{s: "- a:1\n", werr: ":1: list item without a key"},
+		{s: "endpoints\n", werr: `:1: expected "key: value"`},
+		{s: "dial-timeout: [1s, 2s]\n", werr: `:1: "dial-timeout" expects a single value`},
+		{s: "dial-timeout: soon\n", werr: ":1: bad dial-timeout"},
+		{s: "cert-file: client.crt\n", werr: "cert-file and key-file must be given together"},
+
+		// unsupported YAML
+		{s: "tls:\n  cert-file: client.crt\n", werr: ":2: nested mappings are not supported"},
+		{s: "endpoints:\n- url: a:1\n", werr: ":2: lists of mappings are not supported"},
+		{s: "endpoints:\n- [a:1]\n", werr: ":2: nested lists are not supported"},
+		{s: "endpoints: [a:1, [b:2]]\n", werr: ":1: nested lists are not supported"},
+		{s: "endpoints: [a:1,\n b:2]\n", werr: `:1: list must end with "]" on the same line`},
+		{s: "endpoints: {a: 1}\n", werr: ":1: flow mappings are not supported"},
+		{s: "trusted-ca-file: |\n  ca.crt\n", werr: ":1: block scalars are not supported"},
+		{s: "trusted-ca-file: >-\n", werr: ":1: block scalars are not supported"},
+		{s: "endpoints: &eps [a:1]\n", werr: ":1: anchors, aliases and tags are not supported"},
+		{s: "endpoints:\n- *ep\n", werr: ":2: anchors, aliases and tags are not supported"},
+		{s: "dial-timeout: !!str 5s\n", werr: ":1: anchors, aliases and tags are not supported"},
+		{s: "---\nendpoints: [a:1]\n", werr: ":1: document markers are not supported"},
+	}
+
+	f, err := ioutil.TempFile("", "clientv3-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	for i, tt := range tests {
+		if err = ioutil.WriteFile(f.Name(), []byte(tt.s), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig(f.Name())
+		if tt.werr != "" {
+			// errors start with the path of the file
+			if err == nil || !strings.HasPrefix(err.Error(), f.Name()) || !strings.Contains(err.Error(), tt.werr) {
+				t.Errorf("#%d: err = %v, want %q", i, err, tt.werr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error (%v)", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(cfg.Endpoints, tt.weps) {
+			t.Errorf("#%d: endpoints = %v, want %v", i, cfg.Endpoints, tt.weps)
+		}
+		if cfg.DialTimeout != tt.wtimeout {
+			t.Errorf("#%d: dial timeout = %v, want %v", i, cfg.DialTimeout, tt.wtimeout)
+		}
+		if !reflect.DeepEqual(cfg.TLSCACerts, tt.wcas) {
+			t.Errorf("#%d: trusted CA files = %v, want %v", i, cfg.TLSCACerts, tt.wcas)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/integration"
+	"github.com/coreos/etcd/pkg/testutil"
+	"golang.org/x/net/context"
+)
+
+// TestClientCloseGoroutines ensures no client goroutines are left running
+// once Close returns, including ones started to switch endpoints.
+func TestClientCloseGoroutines(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	// only count goroutines of the client under test
+	for i := range clus.Members {
+		clus.Client(i).Close()
+	}
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{clus.Client(0).Endpoints()[0]},
+		DialTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+	lresp, err := cli.Create(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to create lease (%v)", err)
+	}
+	if _, err = cli.KeepAlive(ctx, clientv3.LeaseID(lresp.ID)); err != nil {
+		t.Fatalf("failed to keepalive lease (%v)", err)
+	}
+	cli.Watch(ctx, "foo")
+
+	// a failed write switches endpoints in the background
+	clus.Members[0].Stop(t)
+	if _, err = cli.Put(ctx, "foo", "bar"); err == nil {
+		t.Fatalf("expected error on stopped member")
+	}
+
+	testutil.WaitForGoroutines(t, cli, 5*time.Second)
+	if gs := clientGoroutines(); gs != "" {
+		t.Errorf("client goroutines left after close:\n%s", gs)
+	}
+
+	if err = clus.Members[0].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestClientCloseLeakedGoroutines ensures closing a client stops a lease
+// keepalive and a watch that were left running on a lessor or watcher
+// never closed.
+func TestClientCloseLeakedGoroutines(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	for i := range clus.Members {
+		clus.Client(i).Close()
+	}
+
+	tests := []func(*clientv3.Client) error{
+		func(cli *clientv3.Client) error {
+			lapi := clientv3.NewLease(cli)
+			resp, err := lapi.Create(context.TODO(), 10)
+			if err != nil {
+				return err
+			}
+			_, err = lapi.KeepAlive(context.TODO(), clientv3.LeaseID(resp.ID))
+			return err
+		},
+		func(cli *clientv3.Client) error {
+			wch := clientv3.NewWatcher(cli).Watch(context.TODO(), "foo", clientv3.WithCreatedNotify())
+			if wresp := <-wch; !wresp.Created {
+				return fmt.Errorf("watch not created (%v)", wresp.Err())
+			}
+			return nil
+		},
+	}
+	for i, leak := range tests {
+		cli, err := clientv3.New(clientv3.Config{
+			Endpoints:   clus.Client(0).Endpoints(),
+			DialTimeout: time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = leak(cli); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if gs := clientGoroutines(); gs == "" {
+			t.Fatalf("#%d: expected client goroutines before close", i)
+		}
+
+		testutil.WaitForGoroutines(t, cli, 5*time.Second)
+		if gs := clientGoroutines(); gs != "" {
+			t.Errorf("#%d: client goroutines left after close:\n%s", i, gs)
+		}
+	}
+}
+
+// clientGoroutines returns the stacks of the goroutines running clientv3
+// code, after giving exiting ones a moment to finish.
+func clientGoroutines() string { return goroutinesWith("github.com/coreos/etcd/clientv3.") }
+
+// goroutinesWith returns the stacks of the goroutines with substr in their
+// stack, after giving exiting ones a moment to finish.
+func goroutinesWith(substr string) string {
+	var gs []string
+	for i := 0; i < 10; i++ {
+		buf := make([]byte, 2<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		gs = gs[:0]
+		for _, g := range strings.Split(string(buf), "\n\n") {
+			if strings.Contains(g, substr) {
+				gs = append(gs, g)
+			}
+		}
+		if len(gs) == 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return strings.Join(gs, "\n\n")
+}
@@ -43,6 +43,38 @@ func TestMemberList(t *testing.T) {
 	}
 }
 
+// TestMemberListSorted ensures members are listed in the same order, sorted
+// by ID, on every call.
+func TestMemberListSorted(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	capi := clientv3.NewCluster(clus.RandClient())
+
+	var wids []uint64
+	for i := 0; i < 10; i++ {
+		resp, err := capi.MemberList(context.Background())
+		if err != nil {
+			t.Fatalf("failed to list member %v", err)
+		}
+		var ids []uint64
+		for j, m := range resp.Members {
+			if j > 0 && m.ID <= ids[j-1] {
+				t.Fatalf("#%d: members not sorted by ID: %v", i, resp.Members)
+			}
+			ids = append(ids, m.ID)
+		}
+		if wids == nil {
+			wids = ids
+		}
+		if !reflect.DeepEqual(ids, wids) {
+			t.Fatalf("#%d: member IDs = %v, want %v", i, ids, wids)
+		}
+	}
+}
+
 func TestMemberAdd(t *testing.T) {
 	defer testutil.AfterTest(t)
 
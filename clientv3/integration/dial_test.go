@@ -0,0 +1,330 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/integration"
+	"github.com/coreos/etcd/pkg/testutil"
+	"github.com/coreos/etcd/pkg/transport"
+	"golang.org/x/net/context"
+)
+
+// TestDialUnixSocketPath ensures a client can connect through a
+// "unix://<absolute path>" endpoint.
+func TestDialUnixSocketPath(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	sock := strings.TrimPrefix(clus.Client(0).Endpoints()[0], "unix://")
+	path, err := filepath.Abs(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{"unix://" + path},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to dial %q (%v)", path, err)
+	}
+	defer cli.Close()
+
+	if _, err := clientv3.NewKV(cli).Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatalf("couldn't put key (%v)", err)
+	}
+}
+
+// TestDialInitialConnectionTimeout ensures New waits for a member that is
+// slow to start for InitialConnectionTimeout instead of DialTimeout.
+func TestDialInitialConnectionTimeout(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	eps := clus.Client(0).Endpoints()
+	clus.Members[0].Stop(t)
+
+	cfg := clientv3.Config{Endpoints: eps, DialTimeout: time.Second}
+	if cli, err := clientv3.New(cfg); err == nil {
+		cli.Close()
+		t.Fatalf("expected dial to a stopped member to time out")
+	}
+
+	// the member comes back after DialTimeout but within the grace period
+	donec := make(chan error, 1)
+	go func() {
+		time.Sleep(2 * time.Second)
+		donec <- clus.Members[0].Restart(t)
+	}()
+	cfg.InitialConnectionTimeout = 10 * time.Second
+	cli, err := clientv3.New(cfg)
+	if rerr := <-donec; rerr != nil {
+		t.Fatal(rerr)
+	}
+	if err != nil {
+		t.Fatalf("failed to dial slow-starting member (%v)", err)
+	}
+	defer cli.Close()
+	clus.Members[0].WaitOK(t)
+
+	if _, err := clientv3.NewKV(cli).Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatalf("couldn't put key (%v)", err)
+	}
+}
+
+// TestDialFromConfigFile ensures a client created from a config file with
+// endpoints and TLS files connects to a TLS cluster.
+func TestDialFromConfigFile(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	dir, err := ioutil.TempDir("", "clientv3-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tlsInfo := newTestTLSInfo(t, dir)
+	// clients of NewClusterV3 dial before the TLS members are up; launch
+	// the members without clients instead
+	clus := integration.NewClusterByConfig(t, &integration.ClusterConfig{Size: 3, ClientTLS: &tlsInfo, UseV3: true, UseGRPC: true})
+	clus.Launch(t)
+	defer clus.Terminate(t)
+
+	cfgFile := filepath.Join(dir, "client.yaml")
+	s := fmt.Sprintf("endpoints:\n- %s\ndial-timeout: 5s\ncert-file: %s\nkey-file: %s\ntrusted-ca-file: %s\n",
+		clus.Members[0].GRPCAddr(), tlsInfo.CertFile, tlsInfo.KeyFile, tlsInfo.TrustedCAFile)
+	if err = ioutil.WriteFile(cfgFile, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cli, err := clientv3.NewFromConfigFile(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err := cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatalf("couldn't put key (%v)", err)
+	}
+
+	// errors name the config file
+	if err = ioutil.WriteFile(cfgFile, []byte("dial-timeout: 5s\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clientv3.NewFromConfigFile(cfgFile); err == nil || !strings.Contains(err.Error(), cfgFile) {
+		t.Fatalf("expected error naming %s, got %v", cfgFile, err)
+	}
+}
+
+// newTestTLSInfo writes a CA and a certificate it signs for localhost,
+// usable by both servers and clients, to dir.
+func newTestTLSInfo(t *testing.T, dir string) transport.TLSInfo {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"etcd-ca"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{Organization: []string{"etcd"}, CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caTmpl, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := transport.TLSInfo{
+		KeyFile:        filepath.Join(dir, "server.key"),
+		CertFile:       filepath.Join(dir, "server.crt"),
+		TrustedCAFile:  filepath.Join(dir, "ca.crt"),
+		ClientCertAuth: true,
+	}
+	for p, b := range map[string]*pem.Block{
+		info.TrustedCAFile: {Type: "CERTIFICATE", Bytes: caDER},
+		info.CertFile:      {Type: "CERTIFICATE", Bytes: der},
+		info.KeyFile:       {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err = ioutil.WriteFile(p, pem.EncodeToMemory(b), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+// TestDialPriorityEndpoints ensures the client prefers the endpoint of the
+// highest priority and fails over to a lower one once it is down.
+func TestDialPriorityEndpoints(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	// no cluster clients, since member 0 is stopped
+	clus := integration.NewClusterByConfig(t, &integration.ClusterConfig{Size: 3, UseV3: true, UseGRPC: true})
+	clus.Launch(t)
+	defer clus.Terminate(t)
+
+	cfg := clientv3.Config{
+		Endpoints: []string{
+			"endpoint://priority/1/" + clus.Members[1].GRPCAddr(),
+			"endpoint://priority/10/" + clus.Members[0].GRPCAddr(),
+		},
+		DialTimeout: time.Second,
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// member IDs of the high and low priority endpoints
+	var ids []uint64
+	for _, m := range clus.Members[:2] {
+		resp, serr := cli.Status(context.TODO(), m.GRPCAddr())
+		if serr != nil {
+			t.Fatal(serr)
+		}
+		ids = append(ids, resp.Header.MemberId)
+	}
+
+	resp, err := cli.Put(context.TODO(), "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.MemberId != ids[0] {
+		t.Fatalf("put served by %x, want high priority member %x", resp.Header.MemberId, ids[0])
+	}
+
+	clus.Members[0].Stop(t)
+	<-clus.Members[0].StopNotify()
+
+	// the first get fails on the stopped member and reconnects
+	var gresp *clientv3.GetResponse
+	for i := 0; i < 5; i++ {
+		if gresp, err = cli.Get(context.TODO(), "foo"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gresp.Header.MemberId != ids[1] {
+		t.Fatalf("get served by %x, want low priority member %x", gresp.Header.MemberId, ids[1])
+	}
+}
+
+// TestDialCustomDialer ensures Config.Dialer opens every connection of the
+// client.
+func TestDialCustomDialer(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	var (
+		mu    sync.Mutex
+		dials = make(map[string]int)
+	)
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// the members listen on unix sockets
+		if network != "unix" {
+			return nil, fmt.Errorf("unexpected network %q", network)
+		}
+		mu.Lock()
+		dials[addr]++
+		mu.Unlock()
+		var d net.Dialer
+		if dl, ok := ctx.Deadline(); ok {
+			d.Deadline = dl
+		}
+		return d.Dial(network, addr)
+	}
+
+	var eps []string
+	for _, m := range clus.Members {
+		eps = append(eps, m.GRPCAddr())
+	}
+	cli, err := clientv3.New(clientv3.Config{Endpoints: eps, DialTimeout: 5 * time.Second, Dialer: dialer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	// Status dials the remaining endpoints
+	for _, ep := range eps[1:] {
+		if _, err = cli.Status(context.TODO(), ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, n := range dials {
+		total += n
+	}
+	if len(dials) != len(eps) || total != len(eps) {
+		t.Fatalf("dialed %v, want one connection to each of %v", dials, eps)
+	}
+}
@@ -16,7 +16,9 @@ package integration
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -73,6 +75,284 @@ func TestKVPut(t *testing.T) {
 	}
 }
 
+func TestKVPutIgnoreValue(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	lapi := clientv3.NewLease(clus.RandClient())
+	defer lapi.Close()
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	if _, err := kv.Put(ctx, "missing", "", clientv3.WithIgnoreValue()); err != rpctypes.ErrKeyNotFound {
+		t.Fatalf("err = %v, want %v", err, rpctypes.ErrKeyNotFound)
+	}
+	if _, err := kv.Get(ctx, "foo", clientv3.WithIgnoreValue()); err != clientv3.ErrInvalidOp {
+		t.Fatalf("err = %v, want %v", err, clientv3.ErrInvalidOp)
+	}
+
+	val := strings.Repeat("a", 100*1024)
+	if _, err := kv.Put(ctx, "foo", val); err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+	lresp, err := lapi.Create(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to create lease %v", err)
+	}
+	if _, err = kv.Put(ctx, "foo", "", clientv3.WithIgnoreValue(), clientv3.WithLease(clientv3.LeaseID(lresp.ID))); err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+
+	resp, err := kv.Get(ctx, "foo")
+	if err != nil {
+		t.Fatalf("couldn't get key (%v)", err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(resp.Kvs))
+	}
+	if string(resp.Kvs[0].Value) != val {
+		t.Errorf("value changed by put with ignore value")
+	}
+	if resp.Kvs[0].Lease != lresp.ID {
+		t.Errorf("lease = %d, want %d", resp.Kvs[0].Lease, lresp.ID)
+	}
+}
+
+func TestKVPutKeepLease(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	lapi := clientv3.NewLease(clus.RandClient())
+	defer lapi.Close()
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	if _, err := kv.Put(ctx, "missing", "bar", clientv3.WithKeepLease()); err != rpctypes.ErrKeyNotFound {
+		t.Fatalf("err = %v, want %v", err, rpctypes.ErrKeyNotFound)
+	}
+
+	lresp, err := lapi.Create(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to create lease %v", err)
+	}
+	if _, err = kv.Put(ctx, "foo", "bar", clientv3.WithKeepLease(), clientv3.WithLease(clientv3.LeaseID(lresp.ID))); err != clientv3.ErrInvalidOp {
+		t.Fatalf("err = %v, want %v", err, clientv3.ErrInvalidOp)
+	}
+	if _, err = kv.Put(ctx, "foo", "bar", clientv3.WithLease(clientv3.LeaseID(lresp.ID))); err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+	if _, err = kv.Put(ctx, "foo", "baz", clientv3.WithKeepLease()); err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+	// a txn put keeps the lease as well
+	_, err = kv.Txn(ctx).Then(clientv3.OpPut("foo", "qux", clientv3.WithKeepLease())).Commit()
+	if err != nil {
+		t.Fatalf("couldn't put %q in txn (%v)", "foo", err)
+	}
+
+	resp, err := kv.Get(ctx, "foo")
+	if err != nil {
+		t.Fatalf("couldn't get key (%v)", err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(resp.Kvs))
+	}
+	if string(resp.Kvs[0].Value) != "qux" {
+		t.Errorf("value = %q, want %q", resp.Kvs[0].Value, "qux")
+	}
+	if resp.Kvs[0].Lease != lresp.ID {
+		t.Errorf("lease = %d, want %d", resp.Kvs[0].Lease, lresp.ID)
+	}
+}
+
+func TestKVPutWithResponse(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	resp, err := kv.PutWithResponse(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+	if resp.PrevKv != nil {
+		t.Fatalf("prev kv = %+v, want nil for a new key", resp.PrevKv)
+	}
+
+	resp, err = kv.PutWithResponse(ctx, "foo", "baz")
+	if err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+	if resp.PrevKv == nil || string(resp.PrevKv.Value) != "bar" {
+		t.Fatalf("prev kv = %+v, want value %q", resp.PrevKv, "bar")
+	}
+
+	// a plain put does not return the previous key-value pair
+	if resp, err = kv.Put(ctx, "foo", "qux"); err != nil {
+		t.Fatalf("couldn't put %q (%v)", "foo", err)
+	}
+	if resp.PrevKv != nil {
+		t.Errorf("prev kv = %+v, want nil without WithPrevKV", resp.PrevKv)
+	}
+	if _, err = kv.Get(ctx, "foo", clientv3.WithPrevKV()); err != clientv3.ErrInvalidOp {
+		t.Errorf("err = %v, want %v", err, clientv3.ErrInvalidOp)
+	}
+}
+
+func TestKVCAS(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	// an empty expected value requires a missing key
+	if ok, _, err := kv.CAS(ctx, "foo", "", "bar"); err != nil || !ok {
+		t.Fatalf("CAS on missing key = %v, %v; want success", ok, err)
+	}
+	ok, v, err := kv.CAS(ctx, "foo", "", "baz")
+	if err != nil || ok || v != "bar" {
+		t.Fatalf("CAS on existing key = %v, %q, %v; want failure with %q", ok, v, err, "bar")
+	}
+	ok, v, err = kv.CAS(ctx, "foo", "qux", "baz")
+	if err != nil || ok || v != "bar" {
+		t.Fatalf("CAS with wrong value = %v, %q, %v; want failure with %q", ok, v, err, "bar")
+	}
+	if ok, _, err = kv.CAS(ctx, "foo", "bar", "baz"); err != nil || !ok {
+		t.Fatalf("CAS with current value = %v, %v; want success", ok, err)
+	}
+
+	// two clients racing from the same value; exactly one may win each round
+	kvs := []clientv3.KV{clientv3.NewKV(clus.Client(0)), clientv3.NewKV(clus.Client(1))}
+	cur := "baz"
+	for i := 0; i < 10; i++ {
+		donec := make(chan bool, len(kvs))
+		for j := range kvs {
+			go func(j int) {
+				ok, _, err := kvs[j].CAS(ctx, "foo", cur, fmt.Sprintf("v%d-%d", i, j))
+				if err != nil {
+					t.Errorf("#%d: CAS error (%v)", i, err)
+				}
+				donec <- ok
+			}(j)
+		}
+		wins := 0
+		for range kvs {
+			if <-donec {
+				wins++
+			}
+		}
+		if wins != 1 {
+			t.Fatalf("#%d: %d CAS succeeded, want 1", i, wins)
+		}
+		resp, err := kv.Get(ctx, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cur = string(resp.Kvs[0].Value)
+	}
+}
+
+func TestKVPutWithTTL(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+
+	keys := []string{"foo1", "foo2", "foo3"}
+	var leaseID int64
+	for _, k := range keys {
+		if _, err := cli.Put(ctx, k, "bar", clientv3.WithTTL(5*time.Second)); err != nil {
+			t.Fatalf("couldn't put %q (%v)", k, err)
+		}
+		resp, err := cli.Get(ctx, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Kvs) != 1 || resp.Kvs[0].Lease == 0 {
+			t.Fatalf("kvs = %+v, want %q with a lease", resp.Kvs, k)
+		}
+		// puts with the same TTL share the lease
+		if leaseID != 0 && resp.Kvs[0].Lease != leaseID {
+			t.Errorf("lease = %x, want shared lease %x", resp.Kvs[0].Lease, leaseID)
+		}
+		leaseID = resp.Kvs[0].Lease
+	}
+
+	if _, err := cli.Get(ctx, "foo", clientv3.WithTTL(time.Second)); err != clientv3.ErrInvalidOp {
+		t.Errorf("err = %v, want %v", err, clientv3.ErrInvalidOp)
+	}
+
+	// the shared lease outlives the TTL by its reuse window and margin
+	time.Sleep(8 * time.Second)
+
+	resp, err := cli.Get(ctx, "foo", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 0 {
+		t.Fatalf("kvs = %+v, want the keys expired", resp.Kvs)
+	}
+}
+
+// TestKVPutWithTTLLifetime ensures a key put with WithTTL on a shared lease,
+// in a put or a txn, lives for at least its TTL.
+func TestKVPutWithTTLLifetime(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx := context.TODO()
+	ttl := 2 * time.Second
+
+	if _, err := cli.Put(ctx, "foo1", "bar", clientv3.WithTTL(ttl)); err != nil {
+		t.Fatal(err)
+	}
+	// late in the life of the shared lease
+	time.Sleep(800 * time.Millisecond)
+	if _, err := cli.Put(ctx, "foo2", "bar", clientv3.WithTTL(ttl)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Txn(ctx).Then(clientv3.OpPut("foo3", "bar", clientv3.WithTTL(ttl))).Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(ttl - 200*time.Millisecond)
+	resp, err := cli.Get(ctx, "foo", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]int64)
+	for _, kv := range resp.Kvs {
+		keys[string(kv.Key)] = kv.Lease
+	}
+	for _, k := range []string{"foo2", "foo3"} {
+		lease, ok := keys[k]
+		if !ok {
+			t.Fatalf("%q expired before its TTL passed", k)
+		}
+		if lease == 0 {
+			t.Errorf("%q has no lease", k)
+		}
+	}
+}
+
 func TestKVRange(t *testing.T) {
 	defer testutil.AfterTest(t)
 
@@ -219,6 +499,192 @@ func TestKVRange(t *testing.T) {
 	}
 }
 
+// TestKVRangeLimit ensures the More field of a range response reflects
+// the requested limit.
+func TestKVRangeLimit(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := kv.Put(ctx, key, ""); err != nil {
+			t.Fatalf("couldn't put %q (%v)", key, err)
+		}
+	}
+
+	tests := []struct {
+		opts []clientv3.OpOption
+
+		wkeys int
+		wmore bool
+	}{
+		{nil, 3, false},
+		{[]clientv3.OpOption{clientv3.WithLimit(2)}, 2, true},
+		{[]clientv3.OpOption{clientv3.WithLimit(3)}, 3, false},
+		{[]clientv3.OpOption{clientv3.WithLimit(2), clientv3.WithNoLimit()}, 3, false},
+	}
+
+	for i, tt := range tests {
+		opts := append([]clientv3.OpOption{clientv3.WithFromKey()}, tt.opts...)
+		resp, err := kv.Get(ctx, "a", opts...)
+		if err != nil {
+			t.Fatalf("#%d: couldn't range (%v)", i, err)
+		}
+		if len(resp.Kvs) != tt.wkeys {
+			t.Errorf("#%d: len(kvs) = %d, want %d", i, len(resp.Kvs), tt.wkeys)
+		}
+		if resp.More != tt.wmore {
+			t.Errorf("#%d: more = %v, want %v", i, resp.More, tt.wmore)
+		}
+	}
+}
+
+func TestKVRangePrefixClosed(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	// "b" is the range end of prefix "a"; "\xff\xff" has no range end
+	for _, key := range []string{"a", "a\xff", "b", "b\x00", "\xff", "\xff\xff"} {
+		if _, err := kv.Put(ctx, key, ""); err != nil {
+			t.Fatalf("couldn't put %q (%v)", key, err)
+		}
+	}
+
+	tests := []struct {
+		key string
+		opt clientv3.OpOption
+
+		wkeys []string
+	}{
+		{"a", clientv3.WithPrefix(), []string{"a", "a\xff"}},
+		{"a", clientv3.WithPrefixClosed(), []string{"a", "a\xff", "b"}},
+		{"\xff", clientv3.WithPrefix(), []string{"\xff", "\xff\xff"}},
+		{"\xff", clientv3.WithPrefixClosed(), []string{"\xff", "\xff\xff"}},
+	}
+	for i, tt := range tests {
+		resp, err := kv.Get(ctx, tt.key, tt.opt)
+		if err != nil {
+			t.Fatalf("#%d: couldn't range (%v)", i, err)
+		}
+		var keys []string
+		for _, kv := range resp.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		if !reflect.DeepEqual(keys, tt.wkeys) {
+			t.Errorf("#%d: keys = %q, want %q", i, keys, tt.wkeys)
+		}
+	}
+}
+
+func TestKVScan(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	// write 1000 keys in txns of 100 puts
+	numKeys, pageSize := 1000, int64(100)
+	for i := 0; i < numKeys; i += 100 {
+		var ops []clientv3.Op
+		for j := i; j < i+100; j++ {
+			ops = append(ops, clientv3.OpPut(fmt.Sprintf("foo/%04d", j), "bar"))
+		}
+		if _, err := kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+			t.Fatalf("couldn't put keys (%v)", err)
+		}
+	}
+	if _, err := kv.Put(ctx, "fop", "bar"); err != nil {
+		t.Fatalf("couldn't put key (%v)", err)
+	}
+
+	s := clientv3.NewScanner(kv, "foo/", pageSize)
+	n := 0
+	for s.Next(ctx) {
+		if n == 1 {
+			// keys deleted after the first page are still scanned
+			if _, err := kv.Delete(ctx, "foo/0500"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if key, wkey := string(s.KeyValue().Key), fmt.Sprintf("foo/%04d", n); key != wkey {
+			t.Fatalf("#%d: key = %q, want %q", n, key, wkey)
+		}
+		n++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("scan failed (%v)", err)
+	}
+	if n != numKeys {
+		t.Fatalf("scanned %d keys, want %d", n, numKeys)
+	}
+
+	if s = clientv3.NewScanner(kv, "nokey", pageSize); s.Next(ctx) {
+		t.Fatalf("unexpected key %q", s.KeyValue().Key)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("scan failed (%v)", err)
+	}
+}
+
+func TestKVGetFirstCreate(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	// created out of key order; "pfx/c" is the oldest
+	for _, key := range []string{"pfx/c", "pfx/a", "pfx/b"} {
+		if _, err := kv.Put(ctx, key, ""); err != nil {
+			t.Fatalf("couldn't put %q (%v)", key, err)
+		}
+	}
+	// updating a key must not change its create revision
+	if _, err := kv.Put(ctx, "pfx/c", "updated"); err != nil {
+		t.Fatalf("couldn't put %q (%v)", "pfx/c", err)
+	}
+
+	tests := []struct {
+		del  string
+		wkey string
+	}{
+		{"", "pfx/c"},
+		{"pfx/c", "pfx/a"},
+		{"pfx/a", "pfx/b"},
+	}
+	for i, tt := range tests {
+		if tt.del != "" {
+			if _, err := kv.Delete(ctx, tt.del); err != nil {
+				t.Fatalf("#%d: couldn't delete %q (%v)", i, tt.del, err)
+			}
+		}
+		resp, err := kv.Get(ctx, "pfx/", clientv3.WithFirstCreate()...)
+		if err != nil {
+			t.Fatalf("#%d: couldn't get key (%v)", i, err)
+		}
+		if len(resp.Kvs) != 1 {
+			t.Fatalf("#%d: expected 1 key, got %d", i, len(resp.Kvs))
+		}
+		if string(resp.Kvs[0].Key) != tt.wkey {
+			t.Errorf("#%d: key = %q, want %q", i, resp.Kvs[0].Key, tt.wkey)
+		}
+	}
+}
+
 func TestKVDeleteRange(t *testing.T) {
 	defer testutil.AfterTest(t)
 
@@ -291,6 +757,194 @@ func TestKVDeleteRange(t *testing.T) {
 	}
 }
 
+func TestKVDeleteRangeKeys(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	tests := []struct {
+		start, end string
+
+		wdeleted int64
+		wkeys    []string
+	}{
+		// only "c"
+		{"c", "", 1, []string{"a", "b", "c/abc", "d"}},
+		// [a, c)
+		{"a", "c", 2, []string{"c", "c/abc", "d"}},
+		// >= c
+		{"c", "\x00", 3, []string{"a", "b"}},
+		// *
+		{"\x00", "\x00", 5, []string{}},
+	}
+
+	for i, tt := range tests {
+		keySet := []string{"a", "b", "c", "c/abc", "d"}
+		for j, key := range keySet {
+			if _, err := kv.Put(ctx, key, ""); err != nil {
+				t.Fatalf("#%d: couldn't put %q (%v)", j, key, err)
+			}
+		}
+
+		dresp, err := kv.DeleteRange(ctx, tt.start, tt.end)
+		if err != nil {
+			t.Fatalf("#%d: couldn't delete range (%v)", i, err)
+		}
+		if dresp.Deleted != tt.wdeleted {
+			t.Errorf("#%d: dresp.Deleted got %d, expected %d", i, dresp.Deleted, tt.wdeleted)
+		}
+
+		resp, err := kv.Get(ctx, "a", clientv3.WithFromKey())
+		if err != nil {
+			t.Fatalf("#%d: couldn't get keys (%v)", i, err)
+		}
+		keys := []string{}
+		for _, kv := range resp.Kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		if !reflect.DeepEqual(tt.wkeys, keys) {
+			t.Errorf("#%d: resp.Kvs got %v, expected %v", i, keys, tt.wkeys)
+		}
+
+		// clear the keyspace for the next case
+		if _, err = kv.DeleteRange(ctx, "\x00", "\x00"); err != nil {
+			t.Fatalf("#%d: couldn't clear keys (%v)", i, err)
+		}
+	}
+}
+
+func TestKVGetRange(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	for i := 0; i < 100; i++ {
+		if _, err := kv.Put(ctx, fmt.Sprintf("key%02d", i), ""); err != nil {
+			t.Fatalf("couldn't put key%02d (%v)", i, err)
+		}
+	}
+
+	tests := []struct {
+		start, end string
+		opts       []clientv3.OpOption
+
+		wfirst, wcount int
+	}{
+		// only "key10"
+		{"key10", "", nil, 10, 1},
+		// missing key
+		{"key", "", nil, 0, 0},
+		// [key10, key20)
+		{"key10", "key20", nil, 10, 10},
+		// >= key90
+		{"key90", "\x00", nil, 90, 10},
+		// *
+		{"\x00", "\x00", nil, 0, 100},
+		// the range replaces the prefix
+		{"key1", "key15", []clientv3.OpOption{clientv3.WithPrefix()}, 10, 5},
+		// [key50, key60) limited to 3
+		{"key50", "key60", []clientv3.OpOption{clientv3.WithLimit(3)}, 50, 3},
+	}
+
+	for i, tt := range tests {
+		kvs, err := kv.GetRange(ctx, tt.start, tt.end, tt.opts...)
+		if err != nil {
+			t.Fatalf("#%d: couldn't get range (%v)", i, err)
+		}
+		keys := []string{}
+		for _, kv := range kvs {
+			keys = append(keys, string(kv.Key))
+		}
+		wkeys := []string{}
+		for j := tt.wfirst; j < tt.wfirst+tt.wcount; j++ {
+			wkeys = append(wkeys, fmt.Sprintf("key%02d", j))
+		}
+		if !reflect.DeepEqual(keys, wkeys) {
+			t.Errorf("#%d: keys = %v, want %v", i, keys, wkeys)
+		}
+	}
+}
+
+func TestKVGetCountOnly(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	for _, key := range []string{"a", "b", "c", "c/abc", "d"} {
+		if _, err := kv.Put(ctx, key, "v"); err != nil {
+			t.Fatalf("couldn't put %q (%v)", key, err)
+		}
+	}
+
+	resp, err := kv.Get(ctx, "c", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 2 || len(resp.Kvs) != 0 {
+		t.Fatalf("got count %d with %d keys, want count 2 without keys", resp.Count, len(resp.Kvs))
+	}
+}
+
+func TestKVGetMaxResponseSize(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.RandClient())
+	ctx := context.TODO()
+
+	val := strings.Repeat("v", 1024)
+	for i := 0; i < 10; i++ {
+		if _, err := kv.Put(ctx, fmt.Sprintf("foo%d", i), val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := kv.Get(ctx, "foo", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := 0
+	for _, kv := range resp.Kvs[:3] {
+		size += kv.Size()
+	}
+
+	tests := []struct {
+		n int
+
+		wkeys int
+		wmore bool
+	}{
+		{size, 3, true},
+		{size + 1, 3, true},
+		{size - 1, 2, true},
+		{1, 0, true},
+		{1 << 20, 10, false},
+	}
+	for i, tt := range tests {
+		resp, err := kv.Get(ctx, "foo", clientv3.WithPrefix(), clientv3.WithMaxResponseSize(tt.n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Kvs) != tt.wkeys || resp.More != tt.wmore {
+			t.Errorf("#%d: got %d keys with more %v, want %d keys with more %v", i, len(resp.Kvs), resp.More, tt.wkeys, tt.wmore)
+		}
+	}
+}
+
 func TestKVDelete(t *testing.T) {
 	defer testutil.AfterTest(t)
 
@@ -454,6 +1108,44 @@ func TestKVPutFailGetRetry(t *testing.T) {
 	}
 }
 
+// TestKVDefaultRequestTimeout ensures a request without a deadline fails
+// once the DefaultRequestTimeout of the client passes.
+func TestKVDefaultRequestTimeout(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	// no cluster clients, since members 1 and 2 are stopped
+	clus := integration.NewClusterByConfig(t, &integration.ClusterConfig{Size: 3, UseV3: true, UseGRPC: true})
+	clus.Launch(t)
+	defer clus.Terminate(t)
+
+	cfg := clientv3.Config{
+		Endpoints:             []string{clus.Members[0].GRPCAddr()},
+		DefaultRequestTimeout: 500 * time.Millisecond,
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if _, err = cli.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	// without quorum, the put waits until its context expires
+	clus.Members[1].Stop(t)
+	clus.Members[2].Stop(t)
+
+	start := time.Now()
+	_, err = cli.Put(context.TODO(), "foo", "baz")
+	if err == nil {
+		t.Fatalf("put without quorum succeeded, want timeout")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Fatalf("put took %v, want about %v", d, cfg.DefaultRequestTimeout)
+	}
+}
+
 // TestKVGetCancel tests that a context cancel on a Get terminates as expected.
 func TestKVGetCancel(t *testing.T) {
 	defer testutil.AfterTest(t)
@@ -476,3 +1168,89 @@ func TestKVGetCancel(t *testing.T) {
 		t.Fatalf("cancel on get broke client connection")
 	}
 }
+
+// TestKVFencingToken simulates a split brain where an old lock holder keeps
+// writing after it lost its lock key, and ensures its writes are rejected by
+// its fencing token while the writes of unrelated clients do not fence it.
+func TestKVFencingToken(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	ctx := context.TODO()
+	oldCli, newCli := clus.Client(0), clus.Client(1)
+
+	lresp, err := clientv3.NewLease(oldCli).Create(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presp, err := oldCli.Put(ctx, "lock/old", "", clientv3.WithLease(clientv3.LeaseID(lresp.ID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken := presp.Header.Revision
+	if _, err = oldCli.Put(ctx, "foo", "old", clientv3.WithFencingToken("lock/old", oldToken)); err != nil {
+		t.Fatalf("holder put error (%v)", err)
+	}
+
+	// other clients creating leases and writing do not fence the holder
+	if _, err = clientv3.NewLease(newCli).Create(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newCli.Put(ctx, "other", "v", clientv3.WithTTL(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = oldCli.Put(ctx, "foo", "old", clientv3.WithFencingToken("lock/old", oldToken)); err != nil {
+		t.Fatalf("holder put after unrelated writes error (%v)", err)
+	}
+
+	// the old holder stalls and loses its lease; a new holder takes over
+	if _, err = clientv3.NewLease(oldCli).Revoke(ctx, clientv3.LeaseID(lresp.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if presp, err = newCli.Put(ctx, "lock/new", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newCli.Put(ctx, "foo", "new", clientv3.WithFencingToken("lock/new", presp.Header.Revision)); err != nil {
+		t.Fatalf("new holder put error (%v)", err)
+	}
+
+	// the old holder wakes up and still thinks it holds the lock
+	if _, err = oldCli.Put(ctx, "foo", "stale", clientv3.WithFencingToken("lock/old", oldToken)); err != rpctypes.ErrStaleFencingToken {
+		t.Fatalf("stale put err = %v, want %v", err, rpctypes.ErrStaleFencingToken)
+	}
+	if _, err = oldCli.Delete(ctx, "foo", clientv3.WithFencingToken("lock/old", oldToken)); err != rpctypes.ErrStaleFencingToken {
+		t.Fatalf("stale delete err = %v, want %v", err, rpctypes.ErrStaleFencingToken)
+	}
+	// a key put again at the same name has another create revision
+	if _, err = newCli.Put(ctx, "lock/old", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = oldCli.Put(ctx, "foo", "stale", clientv3.WithFencingToken("lock/old", oldToken)); err != rpctypes.ErrStaleFencingToken {
+		t.Fatalf("stale put on recreated key err = %v, want %v", err, rpctypes.ErrStaleFencingToken)
+	}
+
+	resp, err := oldCli.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "new" {
+		t.Fatalf("expected foo=new, got %+v", resp.Kvs)
+	}
+
+	// writes without a token are not fenced
+	if _, err = oldCli.Put(ctx, "bar", "v"); err != nil {
+		t.Fatal(err)
+	}
+	badOpts := []clientv3.OpOption{
+		clientv3.WithFencingToken("lock/new", 0),
+		clientv3.WithAttr(rpctypes.MetadataFencingTokenKey, "1"),
+		clientv3.WithFencingToken("", 1),
+	}
+	for i, opt := range badOpts {
+		if _, err = oldCli.Put(ctx, "bar", "v", opt); err != rpctypes.ErrBadFencingToken {
+			t.Fatalf("#%d: bad token err = %v, want %v", i, err, rpctypes.ErrBadFencingToken)
+		}
+	}
+}
@@ -47,6 +47,30 @@ func TestLeaseCreate(t *testing.T) {
 	}
 }
 
+func TestLeaseCreateWithPreferredID(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	lapi := clientv3.NewLease(clus.RandClient())
+	defer lapi.Close()
+
+	id := clientv3.LeaseID(12345)
+	resp, err := lapi.CreateWithOptions(context.Background(), 10, clientv3.WithPreferredID(id))
+	if err != nil {
+		t.Fatalf("failed to create lease %v", err)
+	}
+	if clientv3.LeaseID(resp.ID) != id {
+		t.Fatalf("lease id = %d, want %d", resp.ID, id)
+	}
+
+	_, err = lapi.CreateWithOptions(context.Background(), 10, clientv3.WithPreferredID(id))
+	if err != rpctypes.ErrLeaseExist {
+		t.Fatalf("err = %v, want %v", err, rpctypes.ErrLeaseExist)
+	}
+}
+
 func TestLeaseRevoke(t *testing.T) {
 	defer testutil.AfterTest(t)
 
@@ -92,6 +116,14 @@ func TestLeaseKeepAliveOnce(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to keepalive lease %v", err)
 	}
+
+	if _, err = lapi.Revoke(context.Background(), clientv3.LeaseID(resp.ID)); err != nil {
+		t.Fatalf("failed to revoke lease %v", err)
+	}
+	_, err = lapi.KeepAliveOnce(context.Background(), clientv3.LeaseID(resp.ID))
+	if err != clientv3.ErrLeaseNotFound {
+		t.Errorf("err = %v, want %v", err, clientv3.ErrLeaseNotFound)
+	}
 }
 
 func TestLeaseKeepAlive(t *testing.T) {
@@ -129,6 +161,64 @@ func TestLeaseKeepAlive(t *testing.T) {
 	}
 }
 
+// TestLeaseKeepAliveSaturated ensures keepalives of many leases whose
+// channels are full do not block the keepalive loop.
+func TestLeaseKeepAliveSaturated(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	const (
+		leases  = 1000
+		bufSize = 2
+	)
+	lapi := clientv3.NewLease(clus.RandClient(), clientv3.WithKeepAliveBufferSize(bufSize))
+	defer lapi.Close()
+
+	rcs := make([]<-chan *clientv3.LeaseKeepAliveResponse, leases)
+	for i := range rcs {
+		resp, err := lapi.Create(context.Background(), 10)
+		if err != nil {
+			t.Fatalf("failed to create lease %v", err)
+		}
+		if rcs[i], err = lapi.KeepAlive(context.Background(), clientv3.LeaseID(resp.ID)); err != nil {
+			t.Fatalf("failed to keepalive lease %v", err)
+		}
+		if cap(rcs[i]) != bufSize {
+			t.Fatalf("keepalive buffer = %d, want %d", cap(rcs[i]), bufSize)
+		}
+	}
+
+	// nobody reads, so the keepalives keep being resent until all the
+	// buffers are full
+	timeout := time.After(30 * time.Second)
+	for i := range rcs {
+		for len(rcs[i]) < bufSize {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-timeout:
+				t.Fatalf("lease %d: buffered %d responses, want %d", i, len(rcs[i]), bufSize)
+			}
+		}
+	}
+
+	// the loop still delivers once a channel is drained
+	for _, rc := range []<-chan *clientv3.LeaseKeepAliveResponse{rcs[0], rcs[leases-1]} {
+		for len(rc) > 0 {
+			<-rc
+		}
+		select {
+		case _, ok := <-rc:
+			if !ok {
+				t.Fatalf("chan is closed, want not closed")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("keepalive loop blocked")
+		}
+	}
+}
+
 // TODO: add a client that can connect to all the members of cluster via unix sock.
 // TODO: test handle more complicated failures.
 func TestLeaseKeepAliveHandleFailure(t *testing.T) {
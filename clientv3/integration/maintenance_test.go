@@ -0,0 +1,75 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/integration"
+	"github.com/coreos/etcd/pkg/testutil"
+	"github.com/coreos/etcd/version"
+	"golang.org/x/net/context"
+)
+
+func TestMaintenanceStatus(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	c := clus.Client(0)
+	resp, err := c.Status(context.TODO(), c.Endpoints()[0])
+	if err != nil {
+		t.Fatalf("failed to get status (%v)", err)
+	}
+	if resp.Version != version.Version {
+		t.Errorf("version = %q, want %q", resp.Version, version.Version)
+	}
+	if resp.Leader == 0 {
+		t.Errorf("expected a leader in status")
+	}
+	if resp.DbSize == 0 {
+		t.Errorf("expected non-zero db size")
+	}
+}
+
+func TestRejectOldCluster(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	tests := []struct {
+		version string
+		werr    error
+	}{
+		{"", nil},
+		{"2.2.0", clientv3.ErrOldCluster},
+	}
+	for i, tt := range tests {
+		clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3, ServerVersion: tt.version})
+		cli, err := clientv3.New(clientv3.Config{
+			Endpoints:        clus.Client(0).Endpoints(),
+			DialTimeout:      5 * time.Second,
+			RejectOldCluster: true,
+		})
+		if err == nil {
+			cli.Close()
+		}
+		if err != tt.werr {
+			t.Errorf("#%d: err = %v, want %v", i, err, tt.werr)
+		}
+		clus.Terminate(t)
+	}
+}
@@ -69,3 +69,51 @@ func TestMirrorSync(t *testing.T) {
 		t.Fatal("failed to receive update in one second")
 	}
 }
+
+func TestMirrorDiff(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	src := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer src.Terminate(t)
+	dst := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer dst.Terminate(t)
+
+	for _, kv := range [][]string{{"a", "1"}, {"b", "2"}, {"c", "3"}, {"e", "5"}, {"z", "0"}} {
+		if _, err := src.Client(0).Put(context.TODO(), kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, kv := range [][]string{{"b", "2"}, {"c", "x"}, {"d", "4"}, {"z", "0"}} {
+		if _, err := dst.Client(0).Put(context.TODO(), kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type diff struct {
+		typ mirror.DiffType
+		key string
+	}
+	wdiffs := []diff{
+		{mirror.OnlyInSource, "a"},
+		{mirror.ValueMismatch, "c"},
+		{mirror.OnlyInDest, "d"},
+		{mirror.OnlyInSource, "e"},
+	}
+
+	// a page size of one reads every key with a separate request
+	differ := mirror.NewDiffer(src.Client(0), dst.Client(0), "", 1, 2)
+	evch, errch := differ.Diff(context.TODO())
+	var diffs []diff
+	for ev := range evch {
+		diffs = append(diffs, diff{ev.Type, string(ev.Key)})
+		if ev.Type == mirror.ValueMismatch && (string(ev.Source.Value) != "3" || string(ev.Dest.Value) != "x") {
+			t.Errorf("mismatch values = %q, %q, want %q, %q", ev.Source.Value, ev.Dest.Value, "3", "x")
+		}
+	}
+	for err := range errch {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(diffs, wdiffs) {
+		t.Errorf("diffs = %v, want %v", diffs, wdiffs)
+	}
+}
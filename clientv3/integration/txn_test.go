@@ -15,10 +15,12 @@
 package integration
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	"github.com/coreos/etcd/integration"
 	"github.com/coreos/etcd/pkg/testutil"
 	"golang.org/x/net/context"
@@ -137,3 +139,87 @@ func TestTxnSuccess(t *testing.T) {
 		t.Fatalf("unexpected Get response %v", resp)
 	}
 }
+
+// TestTxnTotalSize ensures TotalSize predicts a txn too large for the
+// server before it is committed.
+func TestTxnTotalSize(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kv := clientv3.NewKV(clus.Client(0))
+	ctx := context.TODO()
+
+	// the server accepts requests of up to 1.5 MiB
+	const limit = 1.5 * 1024 * 1024
+	val := string(make([]byte, 512*1024))
+
+	op := clientv3.OpPut("foo", val)
+	if op.Size() < len(val) || op.Size() > len(val)+64 {
+		t.Fatalf("op size = %d, want about %d", op.Size(), len(val))
+	}
+	small := kv.Txn(ctx).Then(op)
+	if small.TotalSize() >= limit {
+		t.Fatalf("small txn size = %d, want < %d", small.TotalSize(), int(limit))
+	}
+	if _, err := small.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 MiB of values
+	var ops []clientv3.Op
+	for i := 0; i < 4; i++ {
+		ops = append(ops, clientv3.OpPut(fmt.Sprintf("foo%d", i), val))
+	}
+	large := kv.Txn(ctx).Then(ops...)
+	if large.TotalSize() < 2*1024*1024 {
+		t.Fatalf("large txn size = %d, want >= %d", large.TotalSize(), 2*1024*1024)
+	}
+	if _, err := large.Commit(); err != rpctypes.ErrRequestTooLarge {
+		t.Fatalf("err = %v, want %v", err, rpctypes.ErrRequestTooLarge)
+	}
+}
+
+// TestTxnCommitIdempotent ensures a txn resubmitted with the same id, as a
+// client would after a timeout, returns the first result without reapplying.
+func TestTxnCommitIdempotent(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	ctx := context.TODO()
+	commit := func(c *clientv3.Client, id string) *clientv3.TxnResponse {
+		resp, err := clientv3.NewKV(c).Txn(ctx).Then(clientv3.OpPut("foo", "bar")).CommitIdempotent(id)
+		if err != nil {
+			t.Fatalf("txn %q failed (%v)", id, err)
+		}
+		return resp
+	}
+
+	resp := commit(clus.Client(0), "txn1")
+	// resubmit through another member
+	rresp := commit(clus.Client(1), "txn1")
+	if rresp.Header.Revision != resp.Header.Revision {
+		t.Errorf("revision = %d, want %d", rresp.Header.Revision, resp.Header.Revision)
+	}
+
+	kv := clientv3.NewKV(clus.Client(2))
+	gresp, err := kv.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gresp.Kvs) != 1 || gresp.Kvs[0].Version != 1 {
+		t.Fatalf("expected foo applied once, got %+v", gresp.Kvs)
+	}
+
+	// a different id is applied again
+	commit(clus.Client(0), "txn2")
+	if gresp, err = kv.Get(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if gresp.Kvs[0].Version != 2 {
+		t.Fatalf("version = %d, want 2", gresp.Kvs[0].Version)
+	}
+}
@@ -16,8 +16,10 @@ package integration
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -173,6 +175,59 @@ func testWatchRange(t *testing.T, wctx *watchctx) {
 	putAndWatch(t, wctx, "bar", "bar")
 }
 
+// TestWatchMulti ensures the events of several keys arrive on one channel.
+func TestWatchMulti(t *testing.T) {
+	runWatchTest(t, testWatchMulti)
+}
+
+func testWatchMulti(t *testing.T, wctx *watchctx) {
+	keys := []string{"a", "c", "e", "g", "i"}
+	ctx, cancel := context.WithCancel(context.Background())
+	wctx.ch = wctx.w.WatchMulti(ctx, keys...)
+
+	// a key between the watched ones must not be seen
+	if _, err := wctx.kv.Put(context.TODO(), "b", "b"); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range rand.Perm(len(keys)) {
+		if _, err := wctx.kv.Put(context.TODO(), keys[i], keys[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for len(got) < len(keys) {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out with events on %v", got)
+		case wresp, ok := <-wctx.ch:
+			if !ok || wresp.Err() != nil {
+				t.Fatalf("unexpected watch close (%v)", wresp.Err())
+			}
+			for _, ev := range wresp.Events {
+				got = append(got, string(ev.Kv.Key))
+			}
+		}
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, keys) {
+		t.Fatalf("got events on %v, want %v", got, keys)
+	}
+
+	// one canceled response for all the watches
+	cancel()
+	wresp, ok := <-wctx.ch
+	checkCanceled(t, wresp, ok, clientv3.CancelReasonContextCanceled)
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("took too long to close")
+	case wresp, ok = <-wctx.ch:
+		if ok {
+			t.Fatalf("expected watcher channel to close, got %+v", wresp)
+		}
+	}
+}
+
 // TestWatchReconnRequest tests the send failure path when requesting a watcher.
 func TestWatchReconnRequest(t *testing.T) {
 	runWatchTest(t, testWatchReconnRequest)
@@ -242,8 +297,63 @@ func testWatchReconnRunning(t *testing.T, wctx *watchctx) {
 	putAndWatch(t, wctx, "a", "b")
 }
 
-// TestWatchCancelImmediate ensures a closed channel is returned
-// if the context is cancelled.
+// TestWatchReconnNoDuplicates tests a resumed watcher does not deliver the
+// events of the revision it resumes from again.
+func TestWatchReconnNoDuplicates(t *testing.T) {
+	runWatchTest(t, testWatchReconnNoDuplicates)
+}
+
+func testWatchReconnNoDuplicates(t *testing.T, wctx *watchctx) {
+	if wctx.ch = wctx.w.Watch(context.TODO(), "a", clientv3.WithPrefix()); wctx.ch == nil {
+		t.Fatalf("expected non-nil channel")
+	}
+
+	type keyRev struct {
+		key string
+		rev int64
+	}
+	seen := make(map[keyRev]bool)
+	for i := 0; i < 6; i++ {
+		// two events at the same revision
+		k1, k2 := fmt.Sprintf("a/%d/x", i), fmt.Sprintf("a/%d/y", i)
+		_, err := wctx.kv.Txn(context.TODO()).Then(clientv3.OpPut(k1, "v"), clientv3.OpPut(k2, "v")).Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < 2; {
+			select {
+			case wr, ok := <-wctx.ch:
+				if !ok {
+					t.Fatalf("unexpected watch close")
+				}
+				for _, ev := range wr.Events {
+					kr := keyRev{string(ev.Kv.Key), ev.Kv.ModRevision}
+					if seen[kr] {
+						t.Fatalf("duplicate event %+v", kr)
+					}
+					seen[kr] = true
+					n++
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("#%d: watch timed out", i)
+			}
+		}
+		if i%2 == 0 {
+			// take down watcher connection; the watch resumes from the
+			// revision of the events just received
+			wctx.wclient.ActiveConnection().Close()
+		}
+	}
+
+	// the last resume must not replay the final revision either
+	if _, err := wctx.kv.Put(context.TODO(), "b", "v"); err != nil {
+		t.Fatal(err)
+	}
+	putAndWatch(t, wctx, "a/end", "end")
+}
+
+// TestWatchCancelImmediate ensures a canceled response and a closed channel
+// are returned if the context is cancelled.
 func TestWatchCancelImmediate(t *testing.T) {
 	runWatchTest(t, testWatchCancelImmediate)
 }
@@ -252,13 +362,30 @@ func testWatchCancelImmediate(t *testing.T, wctx *watchctx) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 	wch := wctx.w.Watch(ctx, "a")
-	select {
-	case wresp, ok := <-wch:
-		if ok {
-			t.Fatalf("read wch got %v; expected closed channel", wresp)
+	for i := 0; i < 2; i++ {
+		select {
+		case wresp, ok := <-wch:
+			if i == 0 {
+				checkCanceled(t, wresp, ok, clientv3.CancelReasonContextCanceled)
+			} else if ok {
+				t.Fatalf("read wch got %v; expected closed channel", wresp)
+			}
+		default:
+			t.Fatalf("closed watcher channel should not block")
 		}
-	default:
-		t.Fatalf("closed watcher channel should not block")
+	}
+}
+
+// checkCanceled ensures wresp is a final canceled response with reason.
+func checkCanceled(t *testing.T, wresp clientv3.WatchResponse, ok bool, reason clientv3.WatchCancelReason) {
+	if !ok {
+		t.Fatalf("expected canceled response, got closed channel")
+	}
+	if !wresp.Canceled || wresp.Err() == nil {
+		t.Fatalf("expected canceled response with an error, got %+v", wresp)
+	}
+	if wresp.CancelReason != reason {
+		t.Fatalf("cancel reason = %v, want %v", wresp.CancelReason, reason)
 	}
 }
 
@@ -276,6 +403,12 @@ func testWatchCancelInit(t *testing.T, wctx *watchctx) {
 	select {
 	case <-time.After(time.Second):
 		t.Fatalf("took too long to cancel")
+	case wresp, ok := <-wctx.ch:
+		checkCanceled(t, wresp, ok, clientv3.CancelReasonContextCanceled)
+	}
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("took too long to close")
 	case _, ok := <-wctx.ch:
 		if ok {
 			t.Fatalf("expected watcher channel to close")
@@ -283,6 +416,72 @@ func testWatchCancelInit(t *testing.T, wctx *watchctx) {
 	}
 }
 
+// TestWatchCancelUnread ensures canceling a watch whose subscriber does not
+// read the channel does not keep the watch goroutines waiting on it.
+func TestWatchCancelUnread(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.TODO())
+	// neither channel is read
+	cli.Watch(ctx, "foo")
+	cli.WatchMulti(ctx, "foo", "bar")
+	rctx, rcancel := context.WithCancel(context.TODO())
+	rch := cli.Watch(rctx, "foo")
+	for i := 0; i < 3; i++ {
+		if _, err := cli.Put(context.TODO(), "foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the unread watches get the events along with rch
+	for n := 0; n < 3; {
+		wresp := <-rch
+		if wresp.Err() != nil {
+			t.Fatal(wresp.Err())
+		}
+		n += len(wresp.Events)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	rcancel()
+	for _, substr := range []string{").serveStream(", ").WatchMulti."} {
+		if gs := goroutinesWith(substr); gs != "" {
+			t.Errorf("goroutines still waiting on the subscriber:\n%s", gs)
+		}
+	}
+}
+
+// TestWatchDeadlineExceeded ensures a watch whose context deadline passes
+// is given an error that tells the deadline.
+func TestWatchDeadlineExceeded(t *testing.T) {
+	runWatchTest(t, testWatchDeadlineExceeded)
+}
+
+func testWatchDeadlineExceeded(t *testing.T, wctx *watchctx) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	if wctx.ch = wctx.w.Watch(ctx, "a"); wctx.ch == nil {
+		t.Fatalf("expected non-nil watcher channel")
+	}
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatalf("took too long to time out")
+	case wresp, ok := <-wctx.ch:
+		checkCanceled(t, wresp, ok, clientv3.CancelReasonDeadlineExceeded)
+		if !strings.Contains(wresp.Err().Error(), deadline.String()) {
+			t.Fatalf("error %q does not give the deadline %v", wresp.Err(), deadline)
+		}
+	}
+	if _, ok := <-wctx.ch; ok {
+		t.Fatalf("expected watcher channel to close")
+	}
+}
+
 // TestWatchCancelRunning tests watcher closes correctly after events.
 func TestWatchCancelRunning(t *testing.T) {
 	runWatchTest(t, testWatchCancelRunning)
@@ -301,11 +500,15 @@ func testWatchCancelRunning(t *testing.T, wctx *watchctx) {
 	case <-time.After(time.Second):
 		t.Fatalf("took too long to cancel")
 	case v, ok := <-wctx.ch:
-		if !ok {
-			// closed before getting put; OK
-			break
+		if ok && !v.Canceled {
+			// got the PUT; should be canceled next
+			select {
+			case <-time.After(time.Second):
+				t.Fatalf("took too long to cancel")
+			case v, ok = <-wctx.ch:
+			}
 		}
-		// got the PUT; should close next
+		checkCanceled(t, v, ok, clientv3.CancelReasonContextCanceled)
 		select {
 		case <-time.After(time.Second):
 			t.Fatalf("took too long to close")
@@ -317,6 +520,42 @@ func testWatchCancelRunning(t *testing.T, wctx *watchctx) {
 	}
 }
 
+// TestWatchServerClosed ensures a watcher that cannot reach its member
+// again is canceled with CancelReasonServerClosed.
+func TestWatchServerClosed(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterByConfig(t, &integration.ClusterConfig{Size: 3, UseV3: true, UseGRPC: true})
+	clus.Launch(t)
+	defer clus.Terminate(t)
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:             []string{clus.Members[0].GRPCAddr()},
+		DialTimeout:           time.Second,
+		WatchReconnectTimeout: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	wch := cli.Watch(context.Background(), "a", clientv3.WithCreatedNotify())
+	if wresp := <-wch; !wresp.Created {
+		t.Fatalf("expected created notification, got %+v", wresp)
+	}
+	clus.Members[0].Stop(t)
+
+	select {
+	case <-time.After(10 * time.Second):
+		t.Fatalf("took too long to cancel")
+	case wresp, ok := <-wch:
+		checkCanceled(t, wresp, ok, clientv3.CancelReasonServerClosed)
+	}
+	if err = clus.Members[0].Restart(t); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func putAndWatch(t *testing.T, wctx *watchctx, key, val string) {
 	if _, err := wctx.kv.Put(context.TODO(), key, val); err != nil {
 		t.Fatal(err)
@@ -366,6 +605,9 @@ func TestWatchCompactRevision(t *testing.T) {
 	if wresp.Err() != rpctypes.ErrCompacted {
 		t.Fatalf("wresp.Err() expected ErrCompacteed, but got %v", wresp.Err())
 	}
+	if wresp.CancelReason != clientv3.CancelReasonCompacted {
+		t.Fatalf("cancel reason = %v, want %v", wresp.CancelReason, clientv3.CancelReasonCompacted)
+	}
 
 	// ensure the channel is closed
 	if wresp, ok = <-wch; ok {
@@ -428,3 +670,387 @@ func testWatchWithProgressNotify(t *testing.T, watchOnPut bool) {
 		t.Fatalf("watch response expected in %v, but timed out", pi)
 	}
 }
+
+// TestWatchWithFragmentSize ensures a watch response split into fragments
+// by the server is reassembled before being delivered.
+func TestWatchWithFragmentSize(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	wc := clientv3.NewWatcher(clus.RandClient())
+	defer wc.Close()
+
+	fragmentSize := 1024
+	rch := wc.Watch(context.Background(), "foo", clientv3.WithPrefix(), clientv3.WithFragmentSize(fragmentSize))
+
+	// a single txn produces one response whose events exceed the fragment size
+	numKeys := 5
+	val := strings.Repeat("a", fragmentSize)
+	ops := []clientv3.Op{}
+	for i := 0; i < numKeys; i++ {
+		ops = append(ops, clientv3.OpPut(fmt.Sprintf("foo%d", i), val))
+	}
+	kvc := clientv3.NewKV(clus.RandClient())
+	if _, err := kvc.Txn(context.TODO()).Then(ops...).Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case resp := <-rch:
+		if len(resp.Events) != numKeys {
+			t.Fatalf("expected %d events, got %d", numKeys, len(resp.Events))
+		}
+		for i, ev := range resp.Events {
+			if string(ev.Kv.Key) != fmt.Sprintf("foo%d", i) || string(ev.Kv.Value) != val {
+				t.Errorf("#%d: unexpected event %q", i, ev.Kv.Key)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch response expected, but timed out")
+	}
+}
+
+// TestWatchWithFragmentSizeLargeValue ensures a single event larger than the
+// fragment size is split and reassembled.
+func TestWatchWithFragmentSizeLargeValue(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	wc := clientv3.NewWatcher(clus.RandClient())
+	defer wc.Close()
+
+	fragmentSize := 64 * 1024
+	rch := wc.Watch(context.Background(), "foo", clientv3.WithPrefix(), clientv3.WithFragmentSize(fragmentSize))
+
+	val := strings.Repeat("abcdefgh", 128*1024)
+	kvc := clientv3.NewKV(clus.RandClient())
+	if _, err := kvc.Txn(context.TODO()).Then(clientv3.OpPut("foo0", "a"), clientv3.OpPut("foo1", val), clientv3.OpPut("foo2", "b")).Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case resp := <-rch:
+		wvals := []string{"a", val, "b"}
+		if len(resp.Events) != len(wvals) {
+			t.Fatalf("expected %d events, got %d", len(wvals), len(resp.Events))
+		}
+		for i, ev := range resp.Events {
+			if string(ev.Kv.Key) != fmt.Sprintf("foo%d", i) || string(ev.Kv.Value) != wvals[i] {
+				t.Errorf("#%d: unexpected event %q with %d value bytes", i, ev.Kv.Key, len(ev.Kv.Value))
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch response expected, but timed out")
+	}
+}
+
+// TestWatchInvalidStartRevision ensures a watch with a non-positive start
+// revision fails validation without opening a watch.
+func TestWatchInvalidStartRevision(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	if err := clientv3.ValidateWatchOpts(clientv3.WithStartRevision(-1)); err != clientv3.ErrInvalidStartRevision {
+		t.Fatalf("expected %v, got %v", clientv3.ErrInvalidStartRevision, err)
+	}
+	if err := clientv3.ValidateWatchOpts(clientv3.WithStartRevision(1)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	wc := clientv3.NewWatcher(clus.RandClient())
+	defer wc.Close()
+
+	select {
+	case wresp := <-wc.Watch(context.Background(), "foo", clientv3.WithStartRevision(-1)):
+		if !wresp.Canceled || wresp.Err() != clientv3.ErrInvalidStartRevision {
+			t.Fatalf("expected canceled response with %v, got %+v", clientv3.ErrInvalidStartRevision, wresp)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for validation error")
+	}
+}
+
+// TestWatchWithCreatedNotify ensures the first response on a watch opened
+// with WithCreatedNotify is the created notification.
+func TestWatchWithCreatedNotify(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kvc := clientv3.NewKV(clus.RandClient())
+	if _, err := kvc.Put(context.TODO(), "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	wc := clientv3.NewWatcher(clus.RandClient())
+	defer wc.Close()
+
+	rch := wc.Watch(context.Background(), "foo", clientv3.WithCreatedNotify())
+	if _, err := kvc.Put(context.TODO(), "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case resp := <-rch:
+		if !resp.Created {
+			t.Fatalf("resp.Created expected true, got %+v", resp)
+		}
+		if len(resp.Events) != 0 {
+			t.Fatalf("resp.Events expected none, got %+v", resp.Events)
+		}
+		if resp.Header.Revision < 2 {
+			t.Fatalf("resp.Header.Revision expected >= 2, got %d", resp.Header.Revision)
+		}
+		if resp.IsProgressNotify() {
+			t.Fatalf("created notification reported as progress notification")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("created notification expected, but timed out")
+	}
+
+	select {
+	case resp := <-rch:
+		if len(resp.Events) != 1 || string(resp.Events[0].Kv.Value) != "baz" {
+			t.Fatalf("expected put of baz, got %+v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch response expected, but timed out")
+	}
+}
+
+// TestWatchGetAndWatch ensures GetAndWatch sees every write made after its
+// get, even when writes race with it.
+func TestWatchGetAndWatch(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	if _, err := cli.Put(ctx, "foo/0", "v"); err != nil {
+		t.Fatal(err)
+	}
+
+	const writes = 50
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		wkv := clientv3.NewKV(clus.Client(0))
+		for i := 1; i <= writes; i++ {
+			if _, err := wkv.Put(ctx, fmt.Sprintf("foo/%d", i), "v"); err != nil {
+				t.Errorf("put error (%v)", err)
+				return
+			}
+		}
+	}()
+
+	resp, wch, err := cli.GetAndWatch(ctx, "foo/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, kv := range resp.Kvs {
+		seen[string(kv.Key)] = true
+	}
+	for len(seen) < writes+1 {
+		select {
+		case wresp := <-wch:
+			if err := wresp.Err(); err != nil {
+				t.Fatal(err)
+			}
+			for _, ev := range wresp.Events {
+				k := string(ev.Kv.Key)
+				if seen[k] {
+					t.Fatalf("key %q seen twice", k)
+				}
+				if ev.Kv.ModRevision <= resp.Header.Revision {
+					t.Fatalf("event at revision %d, before get revision %d", ev.Kv.ModRevision, resp.Header.Revision)
+				}
+				seen[k] = true
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("saw %d of %d keys", len(seen), writes+1)
+		}
+	}
+	<-donec
+}
+
+// TestWatchGetAndWatchWithLimit ensures get-only options such as WithLimit
+// shape the get but not the watch of GetAndWatch.
+func TestWatchGetAndWatchWithLimit(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	for _, k := range []string{"foo/a", "foo/b"} {
+		if _, err := cli.Put(ctx, k, "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, wch, err := cli.GetAndWatch(ctx, "foo/", clientv3.WithPrefix(), clientv3.WithLimit(1),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithSerializable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "foo/b" {
+		t.Fatalf("got %+v, want only foo/b", resp.Kvs)
+	}
+
+	// the watch covers the whole prefix
+	if _, err = cli.Put(ctx, "foo/c", "v"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case wresp := <-wch:
+		if err := wresp.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Key) != "foo/c" {
+			t.Fatalf("got %+v, want the put of foo/c", wresp.Events)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch response expected, but timed out")
+	}
+}
+
+// TestWatchEtag ensures a watch with an etag is skipped while the revision is
+// unchanged and delivers the events after the etag once it advances.
+func TestWatchEtag(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	presp, err := cli.Put(ctx, "foo", "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := clientv3.Etag(presp.Header)
+
+	wch := cli.Watch(ctx, "foo", clientv3.WithEtag(etag))
+	select {
+	case wresp, ok := <-wch:
+		if !ok {
+			t.Fatalf("expected canceled response before close")
+		}
+		if !wresp.Canceled || len(wresp.Events) != 0 {
+			t.Fatalf("expected canceled response without events, got %+v", wresp)
+		}
+		if wresp.Err() != clientv3.ErrWatchEtagMatch {
+			t.Fatalf("err = %v, want %v", wresp.Err(), clientv3.ErrWatchEtagMatch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for etag match")
+	}
+	if _, ok := <-wch; ok {
+		t.Fatalf("expected closed channel")
+	}
+
+	// the put happens before the watch opens; starting after the etag
+	// revision still delivers it
+	if _, err = cli.Put(ctx, "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	wch = cli.Watch(ctx, "foo", clientv3.WithEtag(etag))
+	select {
+	case wresp := <-wch:
+		if err := wresp.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Value) != "baz" {
+			t.Fatalf("expected put of baz, got %+v", wresp.Events)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for watch event")
+	}
+
+	if wresp := <-cli.Watch(ctx, "foo", clientv3.WithEtag("bad")); wresp.Err() != clientv3.ErrInvalidEtag {
+		t.Fatalf("err = %v, want %v", wresp.Err(), clientv3.ErrInvalidEtag)
+	}
+}
+
+// TestWatchEventBufferSize ensures a subscriber falling behind a bounded
+// watch gets an overflow notification in place of the dropped events.
+func TestWatchEventBufferSize(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	wch := cli.Watch(ctx, "foo", clientv3.WithEventBufferSize(5))
+	// nothing reads wch while writing
+	var rev int64
+	for i := 0; i < 100; i++ {
+		resp, err := cli.Put(ctx, "foo", fmt.Sprintf("bar%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rev = resp.Header.Revision
+	}
+
+	events, overflowed := 0, false
+	for {
+		var wresp clientv3.WatchResponse
+		select {
+		case wresp = <-wch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for revision %d", rev)
+		}
+		if wresp.Err() != nil {
+			t.Fatal(wresp.Err())
+		}
+		if wresp.Overflowed {
+			if !wresp.IsProgressNotify() {
+				t.Fatalf("overflow response %+v is not a progress notification", wresp)
+			}
+			overflowed = true
+		}
+		events += len(wresp.Events)
+		if wresp.Header.Revision >= rev {
+			break
+		}
+	}
+	if !overflowed {
+		t.Fatalf("expected an overflow notification")
+	}
+	if events >= 100 {
+		t.Fatalf("got %d events, expected some to be dropped", events)
+	}
+
+	// the subscriber resumes once it keeps up
+	if _, err := cli.Put(ctx, "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case wresp := <-wch:
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Value) != "baz" {
+			t.Fatalf("expected event for baz, got %+v", wresp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for event after overflow")
+	}
+}
@@ -17,7 +17,9 @@ package clientv3
 import (
 	"sync"
 
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/storage/storagepb"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 )
@@ -34,8 +36,21 @@ type KV interface {
 	// Note that key,value can be plain bytes array and string is
 	// an immutable representation of that bytes array.
 	// To get a string of bytes, do string([]byte(0x10, 0x20)).
+	// When passed WithIgnoreValue(), Put keeps the current value and only
+	// updates the lease. When passed WithKeepLease(), Put keeps the current
+	// lease and only updates the value. When passed WithPrevKV(), the
+	// response carries the key-value pair before the put.
 	Put(ctx context.Context, key, val string, opts ...OpOption) (*PutResponse, error)
 
+	// PutWithResponse is Put with WithPrevKV() always set, so the response
+	// carries the key-value pair before the put, if the key existed.
+	PutWithResponse(ctx context.Context, key, val string, opts ...OpOption) (*PutResponse, error)
+
+	// CAS sets key to newValue if its current value is expectedValue. An
+	// empty expectedValue requires that the key does not exist. If the swap
+	// fails, CAS returns the current value, or "" if the key does not exist.
+	CAS(ctx context.Context, key, expectedValue, newValue string) (success bool, actualValue string, err error)
+
 	// Get retrieves keys.
 	// By default, Get will return the value for "key", if any.
 	// When passed WithRange(end), Get will return the keys in the range [key, end).
@@ -43,12 +58,24 @@ type KV interface {
 	// When passed WithRev(rev) with rev > 0, Get retrieves keys at the given revision;
 	// if the required revision is compacted, the request will fail with ErrCompacted .
 	// When passed WithLimit(limit), the number of returned keys is bounded by limit.
+	// When passed WithNoLimit(), all matching keys are returned.
 	// When passed WithSort(), the keys will be sorted.
 	Get(ctx context.Context, key string, opts ...OpOption) (*GetResponse, error)
 
+	// GetRange returns the key-value pairs in [start, end). An empty end
+	// returns only start; end "\x00" returns every key greater than or
+	// equal to start. It accepts the options of Get; any range given by
+	// them is replaced by [start, end).
+	GetRange(ctx context.Context, start, end string, opts ...OpOption) ([]*storagepb.KeyValue, error)
+
 	// Delete deletes a key, or optionally using WithRange(end), [key, end).
 	Delete(ctx context.Context, key string, opts ...OpOption) (*DeleteResponse, error)
 
+	// DeleteRange deletes the keys in [startKey, endKey). An empty endKey
+	// deletes only startKey; endKey "\x00" deletes every key greater than
+	// or equal to startKey. The response reports the number of deleted keys.
+	DeleteRange(ctx context.Context, startKey, endKey string) (*DeleteResponse, error)
+
 	// Compact compacts etcd KV history before the given rev.
 	Compact(ctx context.Context, rev int64) error
 
@@ -84,7 +111,7 @@ type kv struct {
 
 func NewKV(c *Client) KV {
 	conn := c.ActiveConnection()
-	remote := pb.NewKVClient(conn)
+	remote := c.retryKVClient(pb.NewKVClient(conn))
 
 	return &kv{
 		conn:   c.ActiveConnection(),
@@ -99,6 +126,29 @@ func (kv *kv) Put(ctx context.Context, key, val string, opts ...OpOption) (*PutR
 	return r.put, err
 }
 
+func (kv *kv) PutWithResponse(ctx context.Context, key, val string, opts ...OpOption) (*PutResponse, error) {
+	return kv.Put(ctx, key, val, append([]OpOption{WithPrevKV()}, opts...)...)
+}
+
+func (kv *kv) CAS(ctx context.Context, key, expectedValue, newValue string) (bool, string, error) {
+	cmp := Compare(Value(key), "=", expectedValue)
+	if expectedValue == "" {
+		cmp = Compare(Version(key), "=", 0)
+	}
+	resp, err := kv.Txn(ctx).If(cmp).Then(OpPut(key, newValue)).Else(OpGet(key)).Commit()
+	if err != nil {
+		return false, "", err
+	}
+	if resp.Succeeded {
+		return true, "", nil
+	}
+	kvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(kvs) == 0 {
+		return false, "", nil
+	}
+	return false, string(kvs[0].Value), nil
+}
+
 func (kv *kv) Get(ctx context.Context, key string, opts ...OpOption) (*GetResponse, error) {
 	r, err := kv.Do(ctx, OpGet(key, opts...))
 	return r.get, err
@@ -109,7 +159,26 @@ func (kv *kv) Delete(ctx context.Context, key string, opts ...OpOption) (*Delete
 	return r.del, err
 }
 
+func (kv *kv) GetRange(ctx context.Context, start, end string, opts ...OpOption) ([]*storagepb.KeyValue, error) {
+	// applied last, so it overrides WithRange, WithPrefix and WithFromKey
+	opts = append(append([]OpOption{}, opts...), WithRange(end))
+	resp, err := kv.Get(ctx, start, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Kvs, nil
+}
+
+func (kv *kv) DeleteRange(ctx context.Context, startKey, endKey string) (*DeleteResponse, error) {
+	if endKey == "" {
+		return kv.Delete(ctx, startKey)
+	}
+	return kv.Delete(ctx, startKey, WithRange(endKey))
+}
+
 func (kv *kv) Compact(ctx context.Context, rev int64) error {
+	ctx, cancel := kv.c.requestContext(ctx)
+	defer cancel()
 	r := &pb.CompactionRequest{Revision: rev}
 	_, err := kv.getRemote().Compact(ctx, r)
 	if err == nil {
@@ -120,7 +189,7 @@ func (kv *kv) Compact(ctx context.Context, rev int64) error {
 		return err
 	}
 
-	go kv.switchRemote(err)
+	kv.c.goAsync(func() { kv.switchRemote(err) })
 	return err
 }
 
@@ -132,29 +201,38 @@ func (kv *kv) Txn(ctx context.Context) Txn {
 }
 
 func (kv *kv) Do(ctx context.Context, op Op) (OpResponse, error) {
+	if err := op.validate(); err != nil {
+		return OpResponse{}, err
+	}
+	ctx, cancel := kv.c.requestContext(ctx)
+	defer cancel()
+	ttl := op.ttl
+	op, err := kv.withTTLLease(ctx, op)
+	if err != nil {
+		return OpResponse{}, err
+	}
+	ctx = op.withAttrs(ctx)
 	for {
 		var err error
 		switch op.t {
 		// TODO: handle other ops
 		case tRange:
 			var resp *pb.RangeResponse
-			r := &pb.RangeRequest{Key: op.key, RangeEnd: op.end, Limit: op.limit, Revision: op.rev, Serializable: op.serializable}
-			if op.sort != nil {
-				r.SortOrder = pb.RangeRequest_SortOrder(op.sort.Order)
-				r.SortTarget = pb.RangeRequest_SortTarget(op.sort.Target)
-			}
-
-			resp, err = kv.getRemote().Range(ctx, r)
+			resp, err = kv.getRemote().Range(ctx, op.toRangeRequest())
 			if err == nil {
 				return OpResponse{get: (*GetResponse)(resp)}, nil
 			}
 		case tPut:
 			var resp *pb.PutResponse
-			r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID)}
+			r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), IgnoreValue: op.ignoreValue, IgnoreLease: op.keepLease, PrevKv: op.prevKV}
 			resp, err = kv.getRemote().Put(ctx, r)
 			if err == nil {
 				return OpResponse{put: (*PutResponse)(resp)}, nil
 			}
+			if err == rpctypes.ErrLeaseNotFound && ttl > 0 {
+				// the shared lease was revoked; the next put creates another
+				kv.c.ttlLeases.forget(ttl, op.leaseID)
+			}
 		case tDeleteRange:
 			var resp *pb.DeleteRangeResponse
 			r := &pb.DeleteRangeRequest{Key: op.key, RangeEnd: op.end}
@@ -172,7 +250,7 @@ func (kv *kv) Do(ctx context.Context, op Op) (OpResponse, error) {
 
 		// do not retry on modifications
 		if op.isWrite() {
-			go kv.switchRemote(err)
+			kv.c.goAsync(func() { kv.switchRemote(err) })
 			return OpResponse{}, err
 		}
 
@@ -182,6 +260,20 @@ func (kv *kv) Do(ctx context.Context, op Op) (OpResponse, error) {
 	}
 }
 
+// withTTLLease replaces the TTL of a put given WithTTL by a lease with
+// that TTL.
+func (kv *kv) withTTLLease(ctx context.Context, op Op) (Op, error) {
+	if op.ttl <= 0 {
+		return op, nil
+	}
+	id, err := kv.c.ttlLeases.lease(ctx, op.ttl)
+	if err != nil {
+		return op, err
+	}
+	op.leaseID, op.ttl = id, 0
+	return op, nil
+}
+
 func (kv *kv) switchRemote(prevErr error) error {
 	newConn, err := kv.c.retryConnection(kv.conn, prevErr)
 	if err != nil {
@@ -192,7 +284,7 @@ func (kv *kv) switchRemote(prevErr error) error {
 	defer kv.mu.Unlock()
 
 	kv.conn = newConn
-	kv.remote = pb.NewKVClient(kv.conn)
+	kv.remote = kv.c.retryKVClient(pb.NewKVClient(kv.conn))
 	return nil
 }
 
@@ -18,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -31,16 +32,25 @@ type (
 )
 
 const (
-	// a small buffer to store unsent lease responses.
-	leaseResponseChSize = 16
+	// DefaultKeepAliveBufferSize is the default size of the buffer of a
+	// KeepAlive channel, a small buffer to store unsent lease responses.
+	DefaultKeepAliveBufferSize = 16
 	// NoLease is a lease ID for the absence of a lease.
 	NoLease LeaseID = 0
 )
 
+// ErrLeaseNotFound is returned for a lease ID that does not exist, for
+// example because it expired or was revoked.
+var ErrLeaseNotFound = rpctypes.ErrLeaseNotFound
+
 type Lease interface {
 	// Create creates a new lease.
 	Create(ctx context.Context, ttl int64) (*LeaseCreateResponse, error)
 
+	// CreateWithOptions creates a new lease with the given options. By
+	// default the server chooses the lease ID, as with Create.
+	CreateWithOptions(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseCreateResponse, error)
+
 	// Revoke revokes the given lease.
 	Revoke(ctx context.Context, id LeaseID) (*LeaseRevokeResponse, error)
 
@@ -48,7 +58,8 @@ type Lease interface {
 	KeepAlive(ctx context.Context, id LeaseID) (<-chan *LeaseKeepAliveResponse, error)
 
 	// KeepAliveOnce renews the lease once. In most of the cases, Keepalive
-	// should be used instead of KeepAliveOnce.
+	// should be used instead of KeepAliveOnce. It fails with
+	// ErrLeaseNotFound if the lease does not exist.
 	KeepAliveOnce(ctx context.Context, id LeaseID) (*LeaseKeepAliveResponse, error)
 
 	// Close releases all resources Lease keeps for efficient communication
@@ -56,6 +67,27 @@ type Lease interface {
 	Close() error
 }
 
+// LeaseOption configures a lease created by CreateWithOptions.
+type LeaseOption func(*pb.LeaseCreateRequest)
+
+// WithPreferredID creates the lease with the given ID instead of one chosen
+// by the server. If a lease with the ID already exists, the create fails
+// with rpctypes.ErrLeaseExist.
+func WithPreferredID(id LeaseID) LeaseOption {
+	return func(r *pb.LeaseCreateRequest) { r.ID = int64(id) }
+}
+
+// LessorOption configures a Lease created by NewLease.
+type LessorOption func(*lessor)
+
+// WithKeepAliveBufferSize sets the buffer size of the channels returned by
+// KeepAlive. A response that finds the buffer full is dropped and the lease
+// is renewed again shortly after, so a larger buffer helps consumers that
+// handle many leases or read their responses in bursts.
+func WithKeepAliveBufferSize(n int) LessorOption {
+	return func(l *lessor) { l.keepAliveBufferSize = n }
+}
+
 type lessor struct {
 	c *Client
 
@@ -74,6 +106,9 @@ type lessor struct {
 	stopCancel context.CancelFunc
 
 	keepAlives map[LeaseID]*keepAlive
+
+	// keepAliveBufferSize is the buffer size of KeepAlive channels.
+	keepAliveBufferSize int
 }
 
 // keepAlive multiplexes a keepalive for a lease over multiple channels
@@ -86,30 +121,52 @@ type keepAlive struct {
 	donec chan struct{}
 }
 
-func NewLease(c *Client) Lease {
+func NewLease(c *Client, opts ...LessorOption) Lease {
 	l := &lessor{
 		c:    c,
 		conn: c.ActiveConnection(),
 
 		donec:      make(chan struct{}),
 		keepAlives: make(map[LeaseID]*keepAlive),
+
+		keepAliveBufferSize: DefaultKeepAliveBufferSize,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.keepAliveBufferSize < 0 {
+		l.keepAliveBufferSize = 0
 	}
 
-	l.remote = pb.NewLeaseClient(l.conn)
-	l.stopCtx, l.stopCancel = context.WithCancel(context.Background())
+	l.remote = l.c.retryLeaseClient(pb.NewLeaseClient(l.conn))
+	// keepalives stop with the client too, so its Close does not wait on
+	// a lessor that was never closed
+	l.stopCtx, l.stopCancel = context.WithCancel(c.Ctx())
 
-	go l.recvKeepAliveLoop()
+	if !c.goAsync(l.recvKeepAliveLoop) {
+		l.stopCancel()
+		close(l.donec)
+	}
 
 	return l
 }
 
 func (l *lessor) Create(ctx context.Context, ttl int64) (*LeaseCreateResponse, error) {
+	return l.CreateWithOptions(ctx, ttl)
+}
+
+func (l *lessor) CreateWithOptions(ctx context.Context, ttl int64, opts ...LeaseOption) (*LeaseCreateResponse, error) {
+	ctx, rcancel := l.c.requestContext(ctx)
+	defer rcancel()
 	cctx, cancel := context.WithCancel(ctx)
 	done := cancelWhenStop(cancel, l.stopCtx.Done())
 	defer close(done)
 
 	for {
 		r := &pb.LeaseCreateRequest{TTL: ttl}
+		for _, opt := range opts {
+			opt(r)
+		}
 		resp, err := l.getRemote().LeaseCreate(cctx, r)
 		if err == nil {
 			return (*LeaseCreateResponse)(resp), nil
@@ -124,6 +181,8 @@ func (l *lessor) Create(ctx context.Context, ttl int64) (*LeaseCreateResponse, e
 }
 
 func (l *lessor) Revoke(ctx context.Context, id LeaseID) (*LeaseRevokeResponse, error) {
+	ctx, rcancel := l.c.requestContext(ctx)
+	defer rcancel()
 	cctx, cancel := context.WithCancel(ctx)
 	done := cancelWhenStop(cancel, l.stopCtx.Done())
 	defer close(done)
@@ -146,7 +205,7 @@ func (l *lessor) Revoke(ctx context.Context, id LeaseID) (*LeaseRevokeResponse,
 }
 
 func (l *lessor) KeepAlive(ctx context.Context, id LeaseID) (<-chan *LeaseKeepAliveResponse, error) {
-	ch := make(chan *LeaseKeepAliveResponse, leaseResponseChSize)
+	ch := make(chan *LeaseKeepAliveResponse, l.keepAliveBufferSize)
 
 	l.mu.Lock()
 	ka, ok := l.keepAlives[id]
@@ -166,12 +225,14 @@ func (l *lessor) KeepAlive(ctx context.Context, id LeaseID) (<-chan *LeaseKeepAl
 	}
 	l.mu.Unlock()
 
-	go l.keepAliveCtxCloser(id, ctx, ka.donec)
+	l.c.goAsync(func() { l.keepAliveCtxCloser(id, ctx, ka.donec) })
 
 	return ch, nil
 }
 
 func (l *lessor) KeepAliveOnce(ctx context.Context, id LeaseID) (*LeaseKeepAliveResponse, error) {
+	ctx, rcancel := l.c.requestContext(ctx)
+	defer rcancel()
 	cctx, cancel := context.WithCancel(ctx)
 	done := cancelWhenStop(cancel, l.stopCtx.Done())
 	defer close(done)
@@ -181,6 +242,9 @@ func (l *lessor) KeepAliveOnce(ctx context.Context, id LeaseID) (*LeaseKeepAlive
 		if err == nil {
 			return resp, err
 		}
+		if isHalted(cctx, err) {
+			return nil, err
+		}
 
 		nerr := l.switchRemoteAndStream(err)
 		if nerr != nil {
@@ -277,7 +341,7 @@ func (l *lessor) resetRecv() (pb.Lease_LeaseKeepAliveClient, error) {
 		return nil, err
 	}
 	stream := l.getKeepAliveStream()
-	go l.sendKeepAliveLoop(stream)
+	l.c.goAsync(func() { l.sendKeepAliveLoop(stream) })
 	return stream, nil
 }
 
@@ -378,7 +442,7 @@ func (l *lessor) switchRemoteAndStream(prevErr error) error {
 		l.conn = newConn
 	}
 
-	l.remote = pb.NewLeaseClient(l.conn)
+	l.remote = l.c.retryLeaseClient(pb.NewLeaseClient(l.conn))
 	l.mu.Unlock()
 
 	serr := l.newStream()
@@ -431,3 +495,78 @@ func cancelWhenStop(cancel context.CancelFunc, stopc <-chan struct{}) chan<- str
 
 	return done
 }
+
+// ttlLeaseMargin is how much more than the TTL a lease created for WithTTL
+// must have left to be given to a new put, so the put arrives while its key
+// still has the whole TTL to live.
+const ttlLeaseMargin = time.Second
+
+// ttlLeaseCache shares the leases created for puts with WithTTL between
+// puts with the same TTL.
+type ttlLeaseCache struct {
+	lessor Lease
+
+	mu     sync.Mutex
+	leases map[int64]ttlLease // keyed by TTL in seconds
+}
+
+type ttlLease struct {
+	id LeaseID
+	// reuseUntil is the time the lease stops being given to new puts.
+	reuseUntil time.Time
+}
+
+func newTTLLeaseCache(l Lease) *ttlLeaseCache {
+	return &ttlLeaseCache{lessor: l, leases: make(map[int64]ttlLease)}
+}
+
+// lease returns a lease that expires no earlier than the given TTL from now,
+// creating it if no cached lease has enough time left. A lease is created
+// with a TTL longer by its reuse window, a tenth of the TTL but at least a
+// second, and given to new puts while the whole TTL is left, so a key lives
+// for at least the TTL and at most the TTL plus the window and margin.
+func (c *ttlLeaseCache) lease(ctx context.Context, ttl time.Duration) (LeaseID, error) {
+	secs := ttlSeconds(ttl)
+
+	c.mu.Lock()
+	l, ok := c.leases[secs]
+	c.mu.Unlock()
+	if ok && time.Now().Before(l.reuseUntil) {
+		return l.id, nil
+	}
+
+	window := secs / 10
+	if window < 1 {
+		window = 1
+	}
+	// the lease expires no earlier than its TTL after it was requested
+	start := time.Now()
+	resp, err := c.lessor.Create(ctx, secs+window+int64(ttlLeaseMargin/time.Second))
+	if err != nil {
+		return NoLease, err
+	}
+	l = ttlLease{
+		id:         LeaseID(resp.ID),
+		reuseUntil: start.Add(time.Duration(resp.TTL-secs)*time.Second - ttlLeaseMargin),
+	}
+	c.mu.Lock()
+	c.leases[secs] = l
+	c.mu.Unlock()
+	return l.id, nil
+}
+
+// forget drops the cached lease for the TTL if it is still id.
+func (c *ttlLeaseCache) forget(ttl time.Duration, id LeaseID) {
+	secs := ttlSeconds(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.leases[secs]; ok && l.id == id {
+		delete(c.leases, secs)
+	}
+}
+
+// ttlSeconds rounds ttl up to whole seconds, the granularity of lease TTLs.
+func ttlSeconds(ttl time.Duration) int64 {
+	return int64((ttl + time.Second - 1) / time.Second)
+}
@@ -21,6 +21,8 @@ import (
 
 type (
 	DefragmentResponse pb.DefragmentResponse
+	StatusResponse     pb.StatusResponse
+	HashKVResponse     pb.HashResponse
 )
 
 type Maintenance interface {
@@ -32,6 +34,15 @@ type Maintenance interface {
 	// To defragment multiple members in the cluster, user need to call defragment multiple
 	// times with different endpoints.
 	Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error)
+
+	// Status gets the status of the etcd member with given endpoint.
+	Status(ctx context.Context, endpoint string) (*StatusResponse, error)
+
+	// HashKV hashes the key-value store of the etcd member with given endpoint
+	// up to the given revision. Revisions at or before the last compaction are
+	// left out. Members that applied the same history report the same hash for
+	// the same revision. If rev is 0, the member's current revision is hashed.
+	HashKV(ctx context.Context, endpoint string, rev int64) (*HashKVResponse, error)
 }
 
 type maintenance struct {
@@ -39,14 +50,53 @@ type maintenance struct {
 }
 
 func (m *maintenance) Defragment(ctx context.Context, endpoint string) (*DefragmentResponse, error) {
+	ctx, cancel := m.c.requestContext(ctx)
+	defer cancel()
 	conn, err := m.c.Dial(endpoint)
 	if err != nil {
 		return nil, err
 	}
-	remote := pb.NewMaintenanceClient(conn)
+	remote := m.c.retryMaintenanceClient(pb.NewMaintenanceClient(conn))
 	resp, err := remote.Defragment(ctx, &pb.DefragmentRequest{})
 	if err != nil {
 		return nil, err
 	}
 	return (*DefragmentResponse)(resp), nil
 }
+
+func (m *maintenance) Status(ctx context.Context, endpoint string) (*StatusResponse, error) {
+	ctx, cancel := m.c.requestContext(ctx)
+	defer cancel()
+	conn, err := m.c.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	remote := m.c.retryMaintenanceClient(pb.NewMaintenanceClient(conn))
+	resp, err := remote.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return (*StatusResponse)(resp), nil
+}
+
+func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*HashKVResponse, error) {
+	ctx, cancel := m.c.requestContext(ctx)
+	defer cancel()
+	conn, err := m.c.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	remote := m.c.retryKVClient(pb.NewKVClient(conn))
+	// a zero request revision hashes the whole backend, so ask for the
+	// current revision with a negative one
+	if rev == 0 {
+		rev = -1
+	}
+	resp, err := remote.Hash(ctx, &pb.HashRequest{Revision: rev})
+	if err != nil {
+		return nil, err
+	}
+	return (*HashKVResponse)(resp), nil
+}
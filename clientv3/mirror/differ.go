@@ -0,0 +1,181 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"bytes"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/storage/storagepb"
+	"golang.org/x/net/context"
+)
+
+// DiffType is the kind of difference found for a key.
+type DiffType int
+
+const (
+	// OnlyInSource is a key that exists only in the source cluster.
+	OnlyInSource DiffType = iota
+	// OnlyInDest is a key that exists only in the destination cluster.
+	OnlyInDest
+	// ValueMismatch is a key that exists in both clusters with different values.
+	ValueMismatch
+)
+
+func (t DiffType) String() string {
+	switch t {
+	case OnlyInSource:
+		return "OnlyInSource"
+	case OnlyInDest:
+		return "OnlyInDest"
+	case ValueMismatch:
+		return "ValueMismatch"
+	}
+	return "Unknown"
+}
+
+// DiffEvent is a key that differs between the source and the destination.
+type DiffEvent struct {
+	Type DiffType
+	Key  []byte
+	// Source and Dest are the key-value pairs of the key in each cluster,
+	// nil if the cluster does not have the key.
+	Source *storagepb.KeyValue
+	Dest   *storagepb.KeyValue
+}
+
+// Differ compares the key-value state of two etcd clusters.
+type Differ interface {
+	// Diff reads both clusters page by page, each at the revision of its
+	// first page, and sends a DiffEvent for every differing key through the
+	// returned chan in key order. Only values are compared; revisions and
+	// leases are local to each cluster.
+	Diff(ctx context.Context) (<-chan DiffEvent, chan error)
+}
+
+// NewDiffer creates a Differ for the keys with the given prefix. An empty
+// prefix compares the entire key-value space. Each cluster is read pageSize
+// keys at a time, with up to concurrency pages fetched ahead of the compare.
+func NewDiffer(src, dst *clientv3.Client, prefix string, pageSize int64, concurrency int) Differ {
+	if pageSize <= 0 {
+		pageSize = batchLimit
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &differ{src: src, dst: dst, prefix: prefix, pageSize: pageSize, concurrency: concurrency}
+}
+
+type differ struct {
+	src, dst    *clientv3.Client
+	prefix      string
+	pageSize    int64
+	concurrency int
+}
+
+func (d *differ) Diff(ctx context.Context) (<-chan DiffEvent, chan error) {
+	evchan := make(chan DiffEvent, d.pageSize)
+	errchan := make(chan error, 1)
+
+	go func() {
+		defer close(evchan)
+		defer close(errchan)
+
+		// stop both scans once the diff returns
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		src, dst := d.scan(ctx, d.src), d.scan(ctx, d.dst)
+		s, err := src.next()
+		if err != nil {
+			errchan <- err
+			return
+		}
+		t, err := dst.next()
+		if err != nil {
+			errchan <- err
+			return
+		}
+		for s != nil || t != nil {
+			var ev *DiffEvent
+			switch {
+			case t == nil || (s != nil && bytes.Compare(s.Key, t.Key) < 0):
+				ev = &DiffEvent{Type: OnlyInSource, Key: s.Key, Source: s}
+				s, err = src.next()
+			case s == nil || bytes.Compare(s.Key, t.Key) > 0:
+				ev = &DiffEvent{Type: OnlyInDest, Key: t.Key, Dest: t}
+				t, err = dst.next()
+			default:
+				if !bytes.Equal(s.Value, t.Value) {
+					ev = &DiffEvent{Type: ValueMismatch, Key: s.Key, Source: s, Dest: t}
+				}
+				if s, err = src.next(); err == nil {
+					t, err = dst.next()
+				}
+			}
+			if ev != nil {
+				select {
+				case evchan <- *ev:
+				case <-ctx.Done():
+					errchan <- ctx.Err()
+					return
+				}
+			}
+			if err != nil {
+				errchan <- err
+				return
+			}
+		}
+	}()
+
+	return evchan, errchan
+}
+
+// kvStream is the ordered keys of one cluster, read ahead by a goroutine.
+type kvStream struct {
+	kvc  <-chan *storagepb.KeyValue
+	errc <-chan error
+}
+
+// next returns the next key-value pair, or nil once all keys were read. A
+// scan that failed returns its error instead of ending early.
+func (ks kvStream) next() (*storagepb.KeyValue, error) {
+	kv, ok := <-ks.kvc
+	if !ok {
+		return nil, <-ks.errc
+	}
+	return kv, nil
+}
+
+// scan reads the keys of the cluster under the prefix in order.
+func (d *differ) scan(ctx context.Context, c *clientv3.Client) kvStream {
+	kvc := make(chan *storagepb.KeyValue, d.pageSize*int64(d.concurrency))
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(kvc)
+		s := clientv3.NewScanner(c, d.prefix, d.pageSize)
+		for s.Next(ctx) {
+			select {
+			case kvc <- s.KeyValue():
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		errc <- s.Err()
+	}()
+	return kvStream{kvc, errc}
+}
@@ -15,7 +15,14 @@
 package clientv3
 
 import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
 )
 
 type opType int
@@ -41,29 +48,81 @@ type Op struct {
 	limit        int64
 	sort         *SortOption
 	serializable bool
+	keysOnly     bool
+	countOnly    bool
+	minModRev    int64
 
 	// for range, watch
 	rev int64
+	// startRev is set by WithStartRevision so that rev is validated.
+	startRev bool
 
 	// progressNotify is for progress updates.
 	progressNotify bool
+	// fragmentSize is for splitting large watch responses.
+	fragmentSize int
+	// createdNotify is for created event.
+	createdNotify bool
+	// etag skips the watch if the revision has not changed since it.
+	etag string
+	// eventBufferSize bounds the watch responses buffered for the subscriber.
+	eventBufferSize int
 
 	// for put
-	val     []byte
-	leaseID LeaseID
+	val         []byte
+	leaseID     LeaseID
+	ignoreValue bool
+	keepLease   bool
+	prevKV      bool
+	// ttl is replaced by a lease with the TTL before the put is sent.
+	ttl time.Duration
+
+	// attrs are sent as gRPC metadata with the op.
+	attrs map[string]string
+}
+
+// Attributes returns the attributes set on the op with WithAttr.
+func (op Op) Attributes() map[string]string { return op.attrs }
+
+// withAttrs adds the op attributes to the outgoing metadata of ctx.
+func (op Op) withAttrs(ctx context.Context) context.Context {
+	if len(op.attrs) == 0 {
+		return ctx
+	}
+	md := metadata.MD{}
+	if omd, ok := metadata.FromContext(ctx); ok {
+		md = omd.Copy()
+	}
+	for k, vs := range metadata.New(op.attrs) {
+		md[k] = append(md[k], vs...)
+	}
+	return metadata.NewContext(ctx, md)
+}
+
+func (op Op) toRangeRequest() *pb.RangeRequest {
+	r := &pb.RangeRequest{
+		Key:            op.key,
+		RangeEnd:       op.end,
+		Limit:          op.limit,
+		Revision:       op.rev,
+		Serializable:   op.serializable,
+		KeysOnly:       op.keysOnly,
+		CountOnly:      op.countOnly,
+		MinModRevision: op.minModRev,
+	}
+	if op.sort != nil {
+		r.SortOrder = pb.RangeRequest_SortOrder(op.sort.Order)
+		r.SortTarget = pb.RangeRequest_SortTarget(op.sort.Target)
+	}
+	return r
 }
 
 func (op Op) toRequestUnion() *pb.RequestUnion {
 	switch op.t {
 	case tRange:
-		r := &pb.RangeRequest{Key: op.key, RangeEnd: op.end, Limit: op.limit, Revision: op.rev, Serializable: op.serializable}
-		if op.sort != nil {
-			r.SortOrder = pb.RangeRequest_SortOrder(op.sort.Order)
-			r.SortTarget = pb.RangeRequest_SortTarget(op.sort.Target)
-		}
-		return &pb.RequestUnion{Request: &pb.RequestUnion_RequestRange{RequestRange: r}}
+		return &pb.RequestUnion{Request: &pb.RequestUnion_RequestRange{RequestRange: op.toRangeRequest()}}
 	case tPut:
-		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID)}
+		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), IgnoreValue: op.ignoreValue, IgnoreLease: op.keepLease, PrevKv: op.prevKV}
 		return &pb.RequestUnion{Request: &pb.RequestUnion_RequestPut{RequestPut: r}}
 	case tDeleteRange:
 		r := &pb.DeleteRangeRequest{Key: op.key, RangeEnd: op.end}
@@ -73,10 +132,32 @@ func (op Op) toRequestUnion() *pb.RequestUnion {
 	}
 }
 
+// Size returns the estimated size in bytes of the op once serialized in a
+// txn, so a txn can be checked against the server's request size limit
+// before it is sent.
+func (op Op) Size() int {
+	return op.toRequestUnion().Size()
+}
+
 func (op Op) isWrite() bool {
 	return op.t != tRange
 }
 
+// validate returns ErrInvalidOp if the op was given an option that does
+// not apply to its type, or conflicting lease options.
+func (op Op) validate() error {
+	if (op.ignoreValue || op.keepLease || op.prevKV || op.ttl > 0) && op.t != tPut {
+		return ErrInvalidOp
+	}
+	if op.keepLease && op.leaseID != NoLease {
+		return ErrInvalidOp
+	}
+	if op.ttl > 0 && (op.keepLease || op.leaseID != NoLease) {
+		return ErrInvalidOp
+	}
+	return nil
+}
+
 func OpGet(key string, opts ...OpOption) Op {
 	ret := Op{t: tRange, key: []byte(key)}
 	ret.applyOpts(opts)
@@ -97,6 +178,12 @@ func OpDelete(key string, opts ...OpOption) Op {
 		panic("unexpected sort in delete")
 	case ret.serializable != false:
 		panic("unexpected serializable in delete")
+	case ret.keysOnly:
+		panic("unexpected keys only in delete")
+	case ret.countOnly:
+		panic("unexpected count only in delete")
+	case ret.minModRev != 0:
+		panic("unexpected min mod revision in delete")
 	}
 	return ret
 }
@@ -115,6 +202,12 @@ func OpPut(key, val string, opts ...OpOption) Op {
 		panic("unexpected sort in put")
 	case ret.serializable != false:
 		panic("unexpected serializable in delete")
+	case ret.keysOnly:
+		panic("unexpected keys only in put")
+	case ret.countOnly:
+		panic("unexpected count only in put")
+	case ret.minModRev != 0:
+		panic("unexpected min mod revision in put")
 	}
 	return ret
 }
@@ -149,13 +242,81 @@ func WithLease(leaseID LeaseID) OpOption {
 	return func(op *Op) { op.leaseID = leaseID }
 }
 
+// WithIgnoreLease clears any lease ID attached to a 'Put' request by earlier
+// options, so the key is written without a lease. To keep the key's current
+// lease instead, use WithKeepLease.
+func WithIgnoreLease() OpOption { return WithLease(NoLease) }
+
+// WithIgnoreValue makes a 'Put' request keep the current value of the key,
+// so only its lease is updated. The key must exist. Other operations given
+// this option fail with ErrInvalidOp.
+func WithIgnoreValue() OpOption {
+	return func(op *Op) { op.ignoreValue = true }
+}
+
+// WithPrevKV makes a 'Put' request return the key-value pair before the
+// put in PutResponse.PrevKv. Other operations given this option fail with
+// ErrInvalidOp.
+func WithPrevKV() OpOption {
+	return func(op *Op) { op.prevKV = true }
+}
+
+// WithKeepLease makes a 'Put' request keep the lease currently attached to
+// the key, so only its value is updated. The key must exist. It cannot be
+// combined with WithLease; other operations given this option fail with
+// ErrInvalidOp.
+func WithKeepLease() OpOption {
+	return func(op *Op) { op.keepLease = true }
+}
+
+// WithTTL attaches a lease with the given TTL, rounded up to a second, to
+// a 'Put' request. The client creates the lease and shares it between puts
+// with the same TTL, so a key expires no earlier than ttl after its put but
+// up to a tenth of ttl, and at least two seconds, later. In a txn the lease
+// is created by Commit. It cannot be combined with WithLease or
+// WithKeepLease; other operations given this option fail with ErrInvalidOp.
+func WithTTL(ttl time.Duration) OpOption {
+	return func(op *Op) { op.ttl = ttl }
+}
+
 // WithLimit limits the number of results to return from 'Get' request.
+// A limit of 0 returns all matching keys.
 func WithLimit(n int64) OpOption { return func(op *Op) { op.limit = n } }
 
+// WithNoLimit explicitly requests all matching keys from 'Get' request.
+// It overrides any limit set by an earlier WithLimit.
+func WithNoLimit() OpOption { return WithLimit(0) }
+
 // WithRev specifies the store revision for 'Get' request.
 // Or the start revision of 'Watch' request.
 func WithRev(rev int64) OpOption { return func(op *Op) { op.rev = rev } }
 
+// WithStartRevision specifies the start revision of 'Watch' request like
+// WithRev, but the revision must be positive; see ValidateWatchOpts.
+func WithStartRevision(rev int64) OpOption {
+	return func(op *Op) {
+		op.rev = rev
+		op.startRev = true
+	}
+}
+
+// ValidateWatchOpts checks the options of a 'Watch' request without
+// sending it. It returns ErrInvalidStartRevision if WithStartRevision
+// is given a revision that is not positive.
+func ValidateWatchOpts(opts ...OpOption) error {
+	op := Op{t: tRange}
+	op.applyOpts(opts)
+	if op.startRev && op.rev <= 0 {
+		return ErrInvalidStartRevision
+	}
+	if op.etag != "" {
+		if _, _, err := parseEtag(op.etag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WithSort specifies the ordering in 'Get' request. It requires
 // 'WithRange' and/or 'WithPrefix' to be specified too.
 // 'target' specifies the target to sort by: key, version, revisions, value.
@@ -190,6 +351,20 @@ func WithPrefix() OpOption {
 	}
 }
 
+// WithPrefixClosed is like WithPrefix, but the range also includes its
+// end key, the first key after the prefix, so it covers [key, end] instead
+// of [key, end). For example, 'Get(foo, WithPrefixClosed())' also returns
+// 'fop' but not 'fop1'. A prefix without an end behaves as with WithPrefix.
+func WithPrefixClosed() OpOption {
+	return func(op *Op) {
+		end := getPrefix(op.key)
+		if !bytes.Equal(end, noPrefixEnd) {
+			end = append(end, 0)
+		}
+		op.end = end
+	}
+}
+
 // WithRange specifies the range of 'Get' or 'Delete' requests.
 // For example, 'Get' requests with 'WithRange(end)' returns
 // the keys in the range [key, end).
@@ -208,6 +383,31 @@ func WithSerializable() OpOption {
 	return func(op *Op) { op.serializable = true }
 }
 
+// WithKeysOnly makes the 'Get' request return only the keys, without
+// their values.
+func WithKeysOnly() OpOption {
+	return func(op *Op) { op.keysOnly = true }
+}
+
+// WithCountOnly makes the 'Get' request return only the number of keys
+// in the range, in the Count field of the response, without the keys.
+func WithCountOnly() OpOption {
+	return func(op *Op) { op.countOnly = true }
+}
+
+// WithMaxResponseSize asks the server to drop the keys of a 'Get' response
+// once their encoded size would exceed n bytes; the response then has More
+// set. The limit is sent as gRPC metadata.
+func WithMaxResponseSize(n int) OpOption {
+	return WithAttr(rpctypes.MetadataMaxResponseSizeKey, strconv.Itoa(n))
+}
+
+// WithMinModRev filters out keys for 'Get' with modification revisions
+// less than the given revision.
+func WithMinModRev(rev int64) OpOption {
+	return func(op *Op) { op.minModRev = rev }
+}
+
 // WithFirstCreate gets the key with the oldest creation revision in the request range.
 func WithFirstCreate() []OpOption { return withTop(SortByCreateRevision, SortAscend) }
 
@@ -238,3 +438,70 @@ func WithProgressNotify() OpOption {
 		op.progressNotify = true
 	}
 }
+
+// WithAttr attaches a key-value pair to the op, e.g. a tracing span id.
+// Attributes are sent as gRPC metadata and do not change what the op does.
+func WithAttr(key, val string) OpOption {
+	return func(op *Op) {
+		if op.attrs == nil {
+			op.attrs = make(map[string]string)
+		}
+		op.attrs[key] = val
+	}
+}
+
+// WithFencingToken makes a 'Put', 'Delete' or txn request fail with
+// rpctypes.ErrStaleFencingToken unless key still exists with create
+// revision token. A lock or election holder passes its own key and the
+// revision it put the key at, e.g. Mutex.Key and Mutex.FencingToken, so
+// its writes are rejected once it lost the key, say on lease expiry, and
+// another client may have taken over. Writes to other keys do not change
+// the token.
+func WithFencingToken(key string, token int64) OpOption {
+	return func(op *Op) {
+		WithAttr(rpctypes.MetadataFencingKeyKey, key)(op)
+		WithAttr(rpctypes.MetadataFencingTokenKey, strconv.FormatInt(token, 10))(op)
+	}
+}
+
+// WithCreatedNotify makes the watch channel first receive a WatchResponse
+// with Created set once the watcher is registered on the server. Its header
+// revision is the revision the watch was created at.
+func WithCreatedNotify() OpOption {
+	return func(op *Op) {
+		op.createdNotify = true
+	}
+}
+
+// WithFragmentSize makes the watch server split watch responses larger than
+// the given number of bytes into several responses, splitting the value of a
+// larger event as well. The client reassembles the fragments, so the
+// subscriber still receives whole WatchResponses.
+func WithFragmentSize(bytes int) OpOption {
+	return func(op *Op) {
+		op.fragmentSize = bytes
+	}
+}
+
+// WithEventBufferSize bounds the watch responses buffered for a slow
+// subscriber. The watch channel buffers n+1 responses and the client queues
+// at most n more. Once the queue is full, further responses are dropped
+// and replaced by a single response with Overflowed set, whose header
+// revision is that of the last dropped response. A size that is not
+// positive buffers without bound, which is the default.
+func WithEventBufferSize(n int) OpOption {
+	return func(op *Op) {
+		op.eventBufferSize = n
+	}
+}
+
+// WithEtag makes 'Watch' first check the cluster's current revision against
+// an etag built by Etag. If the revision has not changed, the watch is not
+// opened and the channel holds a single canceled response with
+// ErrWatchEtagMatch. Otherwise the watch starts after the etag revision
+// unless a revision is given.
+func WithEtag(etag string) OpOption {
+	return func(op *Op) {
+		op.etag = etag
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"time"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy configures how the client retries RPCs that fail with one of
+// the given gRPC codes. Unary RPCs are sent again; streams (watch and lease
+// keep alive) are retried only while they are being opened. Writes are
+// retried too, so a retried put or txn may be applied more than once.
+type RetryPolicy struct {
+	// MaxRetries is how many times a failed RPC is retried. Zero disables
+	// retries.
+	MaxRetries int
+	// BackoffBase is the wait before the first retry. It doubles on each
+	// following retry.
+	BackoffBase time.Duration
+	// BackoffMax bounds the wait between retries. Zero leaves it unbounded.
+	BackoffMax time.Duration
+	// RetryOn lists the codes of the errors to retry.
+	RetryOn []codes.Code
+}
+
+// retry calls f until it succeeds, fails with an error the policy does not
+// retry, or runs out of retries.
+func (p *RetryPolicy) retry(ctx context.Context, f func() error) error {
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil || i >= p.MaxRetries || !p.retryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(p.backoff(i)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	code := grpc.Code(err)
+	for _, c := range p.RetryOn {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before retry i+1.
+func (p *RetryPolicy) backoff(i int) time.Duration {
+	d := p.BackoffBase << uint(i)
+	if p.BackoffMax > 0 && (d > p.BackoffMax || d < p.BackoffBase) {
+		d = p.BackoffMax
+	}
+	return d
+}
+
+// The retry clients hold the client they wrap in a named field rather than
+// embedding it, so a new RPC does not compile until it is retried too.
+
+type retryKVClient struct {
+	kc pb.KVClient
+	p  *RetryPolicy
+}
+
+// retryKVClient wraps kc to retry its RPCs according to the client's
+// RetryPolicy.
+func (c *Client) retryKVClient(kc pb.KVClient) pb.KVClient {
+	if c.cfg.RetryPolicy.MaxRetries == 0 {
+		return kc
+	}
+	return &retryKVClient{kc, &c.cfg.RetryPolicy}
+}
+
+func (rc *retryKVClient) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (resp *pb.RangeResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.kc.Range(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryKVClient) Put(ctx context.Context, in *pb.PutRequest, opts ...grpc.CallOption) (resp *pb.PutResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.kc.Put(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryKVClient) DeleteRange(ctx context.Context, in *pb.DeleteRangeRequest, opts ...grpc.CallOption) (resp *pb.DeleteRangeResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.kc.DeleteRange(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryKVClient) Txn(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallOption) (resp *pb.TxnResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.kc.Txn(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryKVClient) Compact(ctx context.Context, in *pb.CompactionRequest, opts ...grpc.CallOption) (resp *pb.CompactionResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.kc.Compact(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryKVClient) Hash(ctx context.Context, in *pb.HashRequest, opts ...grpc.CallOption) (resp *pb.HashResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.kc.Hash(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+type retryWatchClient struct {
+	wc pb.WatchClient
+	p  *RetryPolicy
+}
+
+// retryWatchClient wraps wc to retry opening watch streams according to
+// the client's RetryPolicy.
+func (c *Client) retryWatchClient(wc pb.WatchClient) pb.WatchClient {
+	if c.cfg.RetryPolicy.MaxRetries == 0 {
+		return wc
+	}
+	return &retryWatchClient{wc, &c.cfg.RetryPolicy}
+}
+
+func (rc *retryWatchClient) Watch(ctx context.Context, opts ...grpc.CallOption) (stream pb.Watch_WatchClient, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		stream, rerr = rc.wc.Watch(ctx, opts...)
+		return rerr
+	})
+	return stream, err
+}
+
+type retryLeaseClient struct {
+	lc pb.LeaseClient
+	p  *RetryPolicy
+}
+
+// retryLeaseClient wraps lc to retry its RPCs, and the opening of keep
+// alive streams, according to the client's RetryPolicy.
+func (c *Client) retryLeaseClient(lc pb.LeaseClient) pb.LeaseClient {
+	if c.cfg.RetryPolicy.MaxRetries == 0 {
+		return lc
+	}
+	return &retryLeaseClient{lc, &c.cfg.RetryPolicy}
+}
+
+func (rc *retryLeaseClient) LeaseCreate(ctx context.Context, in *pb.LeaseCreateRequest, opts ...grpc.CallOption) (resp *pb.LeaseCreateResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.lc.LeaseCreate(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryLeaseClient) LeaseRevoke(ctx context.Context, in *pb.LeaseRevokeRequest, opts ...grpc.CallOption) (resp *pb.LeaseRevokeResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.lc.LeaseRevoke(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryLeaseClient) LeaseKeepAlive(ctx context.Context, opts ...grpc.CallOption) (stream pb.Lease_LeaseKeepAliveClient, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		stream, rerr = rc.lc.LeaseKeepAlive(ctx, opts...)
+		return rerr
+	})
+	return stream, err
+}
+
+type retryClusterClient struct {
+	cc pb.ClusterClient
+	p  *RetryPolicy
+}
+
+// retryClusterClient wraps cc to retry its RPCs according to the client's
+// RetryPolicy.
+func (c *Client) retryClusterClient(cc pb.ClusterClient) pb.ClusterClient {
+	if c.cfg.RetryPolicy.MaxRetries == 0 {
+		return cc
+	}
+	return &retryClusterClient{cc, &c.cfg.RetryPolicy}
+}
+
+func (rc *retryClusterClient) MemberAdd(ctx context.Context, in *pb.MemberAddRequest, opts ...grpc.CallOption) (resp *pb.MemberAddResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.cc.MemberAdd(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryClusterClient) MemberRemove(ctx context.Context, in *pb.MemberRemoveRequest, opts ...grpc.CallOption) (resp *pb.MemberRemoveResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.cc.MemberRemove(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryClusterClient) MemberUpdate(ctx context.Context, in *pb.MemberUpdateRequest, opts ...grpc.CallOption) (resp *pb.MemberUpdateResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.cc.MemberUpdate(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryClusterClient) MemberList(ctx context.Context, in *pb.MemberListRequest, opts ...grpc.CallOption) (resp *pb.MemberListResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.cc.MemberList(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+type retryMaintenanceClient struct {
+	mc pb.MaintenanceClient
+	p  *RetryPolicy
+}
+
+// retryMaintenanceClient wraps mc to retry its RPCs according to the
+// client's RetryPolicy.
+func (c *Client) retryMaintenanceClient(mc pb.MaintenanceClient) pb.MaintenanceClient {
+	if c.cfg.RetryPolicy.MaxRetries == 0 {
+		return mc
+	}
+	return &retryMaintenanceClient{mc, &c.cfg.RetryPolicy}
+}
+
+func (rc *retryMaintenanceClient) Defragment(ctx context.Context, in *pb.DefragmentRequest, opts ...grpc.CallOption) (resp *pb.DefragmentResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.mc.Defragment(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryMaintenanceClient) Status(ctx context.Context, in *pb.StatusRequest, opts ...grpc.CallOption) (resp *pb.StatusResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.mc.Status(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+type retryAuthClient struct {
+	ac pb.AuthClient
+	p  *RetryPolicy
+}
+
+// retryAuthClient wraps ac to retry its RPCs according to the client's
+// RetryPolicy.
+func (c *Client) retryAuthClient(ac pb.AuthClient) pb.AuthClient {
+	if c.cfg.RetryPolicy.MaxRetries == 0 {
+		return ac
+	}
+	return &retryAuthClient{ac, &c.cfg.RetryPolicy}
+}
+
+func (rc *retryAuthClient) AuthEnable(ctx context.Context, in *pb.AuthEnableRequest, opts ...grpc.CallOption) (resp *pb.AuthEnableResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.AuthEnable(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) AuthDisable(ctx context.Context, in *pb.AuthDisableRequest, opts ...grpc.CallOption) (resp *pb.AuthDisableResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.AuthDisable(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) AuthStatus(ctx context.Context, in *pb.AuthStatusRequest, opts ...grpc.CallOption) (resp *pb.AuthStatusResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.AuthStatus(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) Authenticate(ctx context.Context, in *pb.AuthenticateRequest, opts ...grpc.CallOption) (resp *pb.AuthenticateResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.Authenticate(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) UserAdd(ctx context.Context, in *pb.UserAddRequest, opts ...grpc.CallOption) (resp *pb.UserAddResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.UserAdd(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) UserGet(ctx context.Context, in *pb.UserGetRequest, opts ...grpc.CallOption) (resp *pb.UserGetResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.UserGet(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) UserDelete(ctx context.Context, in *pb.UserDeleteRequest, opts ...grpc.CallOption) (resp *pb.UserDeleteResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.UserDelete(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) UserChangePassword(ctx context.Context, in *pb.UserChangePasswordRequest, opts ...grpc.CallOption) (resp *pb.UserChangePasswordResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.UserChangePassword(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) UserGrant(ctx context.Context, in *pb.UserGrantRequest, opts ...grpc.CallOption) (resp *pb.UserGrantResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.UserGrant(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) UserRevoke(ctx context.Context, in *pb.UserRevokeRequest, opts ...grpc.CallOption) (resp *pb.UserRevokeResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.UserRevoke(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) RoleAdd(ctx context.Context, in *pb.RoleAddRequest, opts ...grpc.CallOption) (resp *pb.RoleAddResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.RoleAdd(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) RoleGet(ctx context.Context, in *pb.RoleGetRequest, opts ...grpc.CallOption) (resp *pb.RoleGetResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.RoleGet(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) RoleDelete(ctx context.Context, in *pb.RoleDeleteRequest, opts ...grpc.CallOption) (resp *pb.RoleDeleteResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.RoleDelete(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) RoleGrant(ctx context.Context, in *pb.RoleGrantRequest, opts ...grpc.CallOption) (resp *pb.RoleGrantResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.RoleGrant(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
+
+func (rc *retryAuthClient) RoleRevoke(ctx context.Context, in *pb.RoleRevokeRequest, opts ...grpc.CallOption) (resp *pb.RoleRevokeResponse, err error) {
+	err = rc.p.retry(ctx, func() (rerr error) {
+		resp, rerr = rc.ac.RoleRevoke(ctx, in, opts...)
+		return rerr
+	})
+	return resp, err
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// failingKVClient fails the first fails calls to Range with code.
+type failingKVClient struct {
+	pb.KVClient
+	code  codes.Code
+	fails int
+	calls int
+}
+
+func (kc *failingKVClient) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (*pb.RangeResponse, error) {
+	kc.calls++
+	if kc.calls <= kc.fails {
+		return nil, grpc.Errorf(kc.code, "injected failure")
+	}
+	return &pb.RangeResponse{}, nil
+}
+
+func TestRetryPolicy(t *testing.T) {
+	unavailable := RetryPolicy{MaxRetries: 3, BackoffBase: time.Millisecond, RetryOn: []codes.Code{codes.Unavailable}}
+	tests := []struct {
+		p     RetryPolicy
+		code  codes.Code
+		fails int
+
+		wcalls int
+		werr   bool
+	}{
+		// no policy; no retry
+		{RetryPolicy{}, codes.Unavailable, 2, 1, true},
+		// succeeds on the third call
+		{unavailable, codes.Unavailable, 2, 3, false},
+		// out of retries
+		{unavailable, codes.Unavailable, 5, 4, true},
+		// code not retried
+		{unavailable, codes.Internal, 2, 1, true},
+	}
+	for i, tt := range tests {
+		c := &Client{cfg: Config{RetryPolicy: tt.p}}
+		fkc := &failingKVClient{code: tt.code, fails: tt.fails}
+		_, err := c.retryKVClient(fkc).Range(context.TODO(), &pb.RangeRequest{})
+		if (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+		}
+		if fkc.calls != tt.wcalls {
+			t.Errorf("#%d: calls = %d, want %d", i, fkc.calls, tt.wcalls)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BackoffBase: 10 * time.Millisecond, BackoffMax: 50 * time.Millisecond}
+	wbackoffs := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}
+	for i, w := range wbackoffs {
+		if d := p.backoff(i); d != w {
+			t.Errorf("#%d: backoff = %v, want %v", i, d, w)
+		}
+	}
+	// a shift past the width of Duration must not wrap to a short wait
+	if d := p.backoff(70); d != p.BackoffMax {
+		t.Errorf("backoff = %v, want %v", d, p.BackoffMax)
+	}
+}
+
+func TestRetryPolicyContextCanceled(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 3, BackoffBase: time.Hour, RetryOn: []codes.Code{codes.Unavailable}}
+	c := &Client{cfg: Config{RetryPolicy: p}}
+	fkc := &failingKVClient{code: codes.Unavailable, fails: 10}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := c.retryKVClient(fkc).Range(ctx, &pb.RangeRequest{}); err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"github.com/coreos/etcd/storage/storagepb"
+	"golang.org/x/net/context"
+)
+
+// Scanner iterates over the keys under a prefix, fetching them from etcd
+// one page at a time. All pages are read at the revision of the first page,
+// so keys changed during the scan do not show up twice or go missing.
+//
+//	s := clientv3.NewScanner(kv, "foo", 100)
+//	for s.Next(ctx) {
+//		fmt.Println(s.KeyValue())
+//	}
+//	if err := s.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+type Scanner struct {
+	kv       KV
+	end      string
+	pageSize int64
+
+	// next is the key the next page starts from.
+	next string
+	// rev is the revision of the first page; zero before it is fetched.
+	rev  int64
+	more bool
+
+	kvs []*storagepb.KeyValue
+	cur *storagepb.KeyValue
+	err error
+}
+
+// NewScanner returns a Scanner over the keys of kv with the given prefix
+// that fetches them pageSize keys at a time.
+func NewScanner(kv KV, prefix string, pageSize int64) *Scanner {
+	s := &Scanner{
+		kv:       kv,
+		end:      string(getPrefix([]byte(prefix))),
+		pageSize: pageSize,
+		next:     prefix,
+		more:     true,
+	}
+	if prefix == "" {
+		// scan the whole keyspace
+		s.next = "\x00"
+	}
+	return s
+}
+
+// Next advances the scanner to the next key, fetching a new page if the
+// current one is exhausted. It returns false when the scan ends, either
+// after the last key or on an error reported by Err.
+func (s *Scanner) Next(ctx context.Context) bool {
+	if s.err != nil {
+		return false
+	}
+	if len(s.kvs) == 0 && s.more {
+		s.fetch(ctx)
+	}
+	if len(s.kvs) == 0 {
+		s.cur = nil
+		return false
+	}
+	s.cur, s.kvs = s.kvs[0], s.kvs[1:]
+	return true
+}
+
+// KeyValue returns the key-value pair the scanner is positioned at.
+func (s *Scanner) KeyValue() *storagepb.KeyValue { return s.cur }
+
+// Err returns the first error hit by the scanner, if any.
+func (s *Scanner) Err() error { return s.err }
+
+func (s *Scanner) fetch(ctx context.Context) {
+	resp, err := s.kv.Get(ctx, s.next, WithRange(s.end), WithLimit(s.pageSize), WithRev(s.rev))
+	if err != nil {
+		s.err = err
+		return
+	}
+	if s.rev == 0 {
+		s.rev = resp.Header.Revision
+	}
+	s.kvs, s.more = resp.Kvs, resp.More
+	if len(s.kvs) != 0 {
+		s.next = string(s.kvs[len(s.kvs)-1].Key) + "\x00"
+	}
+}
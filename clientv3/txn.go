@@ -16,9 +16,12 @@ package clientv3
 
 import (
 	"sync"
+	"time"
 
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
 )
 
 //
@@ -47,6 +50,23 @@ type Txn interface {
 	// Commit tries to commit the transaction.
 	Commit() (*TxnResponse, error)
 
+	// CommitIdempotent tries to commit the transaction at most once for id.
+	// Resubmitting a transaction with the same id, e.g. after a timeout,
+	// returns the response of the first commit instead of applying it again,
+	// as long as the server still keeps the id.
+	CommitIdempotent(id string) (*TxnResponse, error)
+
+	// WithAllowDuplicateCompares lets Commit send a txn that has several
+	// comparisons on the same key and target. By default Commit fails
+	// with ErrDuplicateComparison, since such comparisons are usually a
+	// mistake that makes the txn always take the Else branch.
+	WithAllowDuplicateCompares() Txn
+
+	// TotalSize returns the estimated size in bytes of the serialized txn,
+	// the sum of its comparisons and ops. A txn the server rejects with
+	// ErrRequestTooLarge can be caught before calling Commit.
+	TotalSize() int
+
 	// TODO: add a Do for shortcut the txn without any condition?
 }
 
@@ -61,10 +81,18 @@ type txn struct {
 
 	isWrite bool
 
+	allowDupCmps bool
+
 	cmps []*pb.Compare
 
 	sus []*pb.RequestUnion
 	fas []*pb.RequestUnion
+
+	// ttlPuts are the puts given WithTTL; their leases are created by commit
+	ttlPuts []ttlPut
+
+	// err is the first error from validating the ops; Commit returns it.
+	err error
 }
 
 func (txn *txn) If(cs ...Cmp) Txn {
@@ -107,7 +135,7 @@ func (txn *txn) Then(ops ...Op) Txn {
 
 	for _, op := range ops {
 		txn.isWrite = txn.isWrite || op.isWrite()
-		txn.sus = append(txn.sus, op.toRequestUnion())
+		txn.sus = append(txn.sus, txn.toRequestUnion(op))
 	}
 
 	return txn
@@ -125,31 +153,115 @@ func (txn *txn) Else(ops ...Op) Txn {
 
 	for _, op := range ops {
 		txn.isWrite = txn.isWrite || op.isWrite()
-		txn.fas = append(txn.fas, op.toRequestUnion())
+		txn.fas = append(txn.fas, txn.toRequestUnion(op))
 	}
 
 	return txn
 }
 
+func (txn *txn) WithAllowDuplicateCompares() Txn {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	txn.allowDupCmps = true
+	return txn
+}
+
+func (txn *txn) TotalSize() int {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	r := &pb.TxnRequest{Compare: txn.cmps, Success: txn.sus, Failure: txn.fas}
+	return r.Size()
+}
+
+// hasDuplicateCompares returns true if two comparisons share a key and target.
+func hasDuplicateCompares(cmps []*pb.Compare) bool {
+	type cmpKey struct {
+		key    string
+		target pb.Compare_CompareTarget
+	}
+	seen := make(map[cmpKey]struct{}, len(cmps))
+	for _, c := range cmps {
+		k := cmpKey{string(c.Key), c.Target}
+		if _, ok := seen[k]; ok {
+			return true
+		}
+		seen[k] = struct{}{}
+	}
+	return false
+}
+
+type ttlPut struct {
+	r   *pb.PutRequest
+	ttl time.Duration
+}
+
+// toRequestUnion records the first invalid op of the txn and the puts given
+// WithTTL, and returns the request of the op.
+func (txn *txn) toRequestUnion(op Op) *pb.RequestUnion {
+	if err := op.validate(); err != nil && txn.err == nil {
+		txn.err = err
+	}
+	ru := op.toRequestUnion()
+	if op.ttl > 0 {
+		txn.ttlPuts = append(txn.ttlPuts, ttlPut{ru.GetRequestPut(), op.ttl})
+	}
+	return ru
+}
+
 func (txn *txn) Commit() (*TxnResponse, error) {
 	txn.mu.Lock()
 	defer txn.mu.Unlock()
+	ctx, cancel := txn.kv.c.requestContext(txn.ctx)
+	defer cancel()
+	return txn.commit(ctx, false)
+}
+
+func (txn *txn) CommitIdempotent(id string) (*TxnResponse, error) {
+	txn.mu.Lock()
+	defer txn.mu.Unlock()
+	ctx, cancel := txn.kv.c.requestContext(txn.ctx)
+	defer cancel()
+	ctx = metadata.NewContext(ctx, metadata.Pairs(rpctypes.MetadataTxnIDKey, id))
+	return txn.commit(ctx, true)
+}
 
+// commit sends the txn until it succeeds or fails with a non-retryable error.
+// A write txn is only retried on another endpoint if it is idempotent.
+func (txn *txn) commit(ctx context.Context, idempotent bool) (*TxnResponse, error) {
+	if txn.err != nil {
+		return nil, txn.err
+	}
+	if !txn.allowDupCmps && hasDuplicateCompares(txn.cmps) {
+		return nil, ErrDuplicateComparison
+	}
 	kv := txn.kv
+	for _, p := range txn.ttlPuts {
+		id, err := kv.c.ttlLeases.lease(ctx, p.ttl)
+		if err != nil {
+			return nil, err
+		}
+		p.r.Lease = int64(id)
+	}
 
 	for {
 		r := &pb.TxnRequest{Compare: txn.cmps, Success: txn.sus, Failure: txn.fas}
-		resp, err := kv.getRemote().Txn(txn.ctx, r)
+		resp, err := kv.getRemote().Txn(ctx, r)
 		if err == nil {
 			return (*TxnResponse)(resp), nil
 		}
+		if err == rpctypes.ErrLeaseNotFound {
+			// a shared lease was revoked; the next commit creates another
+			for _, p := range txn.ttlPuts {
+				kv.c.ttlLeases.forget(p.ttl, LeaseID(p.r.Lease))
+			}
+		}
 
-		if isHalted(txn.ctx, err) {
+		if isHalted(ctx, err) {
 			return nil, err
 		}
 
-		if txn.isWrite {
-			go kv.switchRemote(err)
+		if txn.isWrite && !idempotent {
+			kv.c.goAsync(func() { kv.switchRemote(err) })
 			return nil, err
 		}
 
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/coreos/etcd/pkg/testutil"
+	"golang.org/x/net/context"
 )
 
 func TestTxnPanics(t *testing.T) {
@@ -103,3 +104,45 @@ func TestTxnPanics(t *testing.T) {
 		}
 	}
 }
+
+func TestTxnDuplicateCompares(t *testing.T) {
+	tests := []struct {
+		cmps []Cmp
+
+		wdup bool
+	}{
+		{
+			[]Cmp{Compare(Value("foo"), "=", "a"), Compare(Value("foo"), "=", "b")},
+			true,
+		},
+		{
+			[]Cmp{Compare(Version("foo"), "=", 1), Compare(Version("foo"), ">", 0)},
+			true,
+		},
+		{
+			[]Cmp{Compare(Value("foo"), "=", "a"), Compare(Version("foo"), "=", 1)},
+			false,
+		},
+		{
+			[]Cmp{Compare(Value("foo"), "=", "a"), Compare(Value("bar"), "=", "a")},
+			false,
+		},
+	}
+	for i, tt := range tests {
+		txn := NewKV(&Client{}).Txn(context.TODO()).If(tt.cmps...).(*txn)
+		if dup := hasDuplicateCompares(txn.cmps); dup != tt.wdup {
+			t.Errorf("#%d: duplicate = %v, want %v", i, dup, tt.wdup)
+		}
+		if tt.wdup {
+			if _, err := txn.Commit(); err != ErrDuplicateComparison {
+				t.Errorf("#%d: err = %v, want %v", i, err, ErrDuplicateComparison)
+			}
+		}
+	}
+
+	// the check can be disabled
+	txn := NewKV(&Client{}).Txn(context.TODO()).If(tests[0].cmps...).WithAllowDuplicateCompares().(*txn)
+	if !txn.allowDupCmps {
+		t.Errorf("allowDupCmps = false, want true")
+	}
+}
@@ -16,7 +16,10 @@ package clientv3
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	v3rpc "github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
@@ -33,8 +36,20 @@ type Watcher interface {
 	// If the watch is slow or the required rev is compacted, the watch request
 	// might be canceled from the server-side and the chan will be closed.
 	// 'opts' can be: 'WithRev' and/or 'WitchPrefix'.
+	// If 'opts' fail ValidateWatchOpts, the chan holds a single canceled
+	// response with the validation error and is closed.
+	// Once ctx is done, the chan sends a final canceled response whose
+	// CancelReason tells whether ctx was canceled or its deadline passed,
+	// and is closed; the response is dropped if it is not received
+	// within a second.
 	Watch(ctx context.Context, key string, opts ...OpOption) WatchChan
 
+	// WatchMulti watches each of the given keys, which are not ranges, on
+	// its own watch of the watcher's stream and merges their responses
+	// onto one channel in arrival order. The first error ends every watch;
+	// that response is sent once before the chan is closed.
+	WatchMulti(ctx context.Context, keys ...string) WatchChan
+
 	// Close closes the watcher and cancels all watch requests.
 	Close() error
 }
@@ -46,14 +61,66 @@ type WatchResponse struct {
 	// CompactRevision is the minimum revision the watcher may receive.
 	CompactRevision int64
 
+	// Created is used to indicate the creation of the watcher.
+	Created bool
+
 	// Canceled is used to indicate watch failure.
 	// If the watch failed and the stream was about to close, before the channel is closed,
 	// the channel sends a final response that has Canceled set to true with a non-nil Err().
 	Canceled bool
+
+	// CancelReason tells why a canceled watch ended; it is zero if the
+	// watch is not canceled or ended for another reason, such as invalid
+	// options.
+	CancelReason WatchCancelReason
+
+	// Overflowed is set on a progress notification sent in place of the
+	// responses dropped since the subscriber fell behind a watch opened
+	// with WithEventBufferSize. The events up to its header revision were
+	// lost.
+	Overflowed bool
+
+	// err is the client-side error that canceled the watch, if any.
+	err error
+}
+
+// WatchCancelReason is the reason a watch was canceled.
+type WatchCancelReason int
+
+const (
+	// CancelReasonContextCanceled means the context of the watch was canceled.
+	CancelReasonContextCanceled WatchCancelReason = iota + 1
+	// CancelReasonDeadlineExceeded means the deadline of the watch context
+	// passed; Err() gives the deadline and how long the watch ran.
+	CancelReasonDeadlineExceeded
+	// CancelReasonCompacted means the revision to watch from was compacted.
+	CancelReasonCompacted
+	// CancelReasonServerClosed means the watcher lost its stream to the
+	// cluster and could not reestablish it.
+	CancelReasonServerClosed
+)
+
+func (r WatchCancelReason) String() string {
+	switch r {
+	case 0:
+		return "none"
+	case CancelReasonContextCanceled:
+		return "context canceled"
+	case CancelReasonDeadlineExceeded:
+		return "deadline exceeded"
+	case CancelReasonCompacted:
+		return "compacted"
+	case CancelReasonServerClosed:
+		return "server closed"
+	}
+	return fmt.Sprintf("WatchCancelReason(%d)", int(r))
 }
 
 // Err is the error value if this WatchResponse holds an error.
 func (wr *WatchResponse) Err() error {
+	if wr.err != nil {
+		return wr.err
+	}
 	if wr.CompactRevision != 0 {
 		return v3rpc.ErrCompacted
 	}
@@ -65,7 +132,7 @@ func (wr *WatchResponse) Err() error {
 
 // IsProgressNotify returns true if the WatchResponse is progress notification.
 func (wr *WatchResponse) IsProgressNotify() bool {
-	return len(wr.Events) == 0 && !wr.Canceled
+	return len(wr.Events) == 0 && !wr.Canceled && !wr.Created
 }
 
 // watcher implements the Watcher interface
@@ -93,6 +160,16 @@ type watcher struct {
 	donec chan struct{}
 	// errc transmits errors from grpc Recv
 	errc chan error
+
+	// reconnectTimeout bounds how long run tries to get a working stream.
+	reconnectTimeout time.Duration
+	// reconnectDeadline is when reconnecting gives up; zero if the
+	// current stream is healthy.
+	reconnectDeadline time.Time
+	// connectedAt is when the current stream was established.
+	connectedAt time.Time
+	// closeErr is sent to all watch channels when run exits on error.
+	closeErr error
 }
 
 // watchRequest is issued by the subscriber to start a new watcher
@@ -103,8 +180,16 @@ type watchRequest struct {
 	rev int64
 	// progressNotify is for progress updates.
 	progressNotify bool
+	// fragmentSize is the size in bytes above which the server splits responses.
+	fragmentSize int
+	// createdNotify is for sending a created response to the subscriber.
+	createdNotify bool
+	// eventBufferSize bounds the responses buffered for the subscriber.
+	eventBufferSize int
 	// retc receives a chan WatchResponse once the watcher is established
 	retc chan chan WatchResponse
+	// start is when Watch was called.
+	start time.Time
 }
 
 // watcherStream represents a registered watcher
@@ -119,18 +204,31 @@ type watcherStream struct {
 
 	// lastRev is revision last successfully sent over outc
 	lastRev int64
+	// lastKey is the key of the last event sent over outc if it was at
+	// lastRev; a resumed stream replays lastRev, so events up to and
+	// including it are dropped
+	lastKey string
 	// resumec indicates the stream must recover at a given revision
 	resumec chan int64
+
+	// fragments buffers the events of a fragmented response until
+	// its last fragment arrives
+	fragments []*storagepb.Event
+	// partialValue is set if the value of the last buffered event
+	// continues in the first event of the next fragment
+	partialValue bool
 }
 
 func NewWatcher(c *Client) Watcher {
-	ctx, cancel := context.WithCancel(context.Background())
+	// the watcher stops with the client too, so its Close does not wait on
+	// a watcher that was never closed
+	ctx, cancel := context.WithCancel(c.Ctx())
 	conn := c.ActiveConnection()
 
 	w := &watcher{
 		c:      c,
 		conn:   conn,
-		remote: pb.NewWatchClient(conn),
+		remote: c.retryWatchClient(pb.NewWatchClient(conn)),
 
 		ctx:     ctx,
 		cancel:  cancel,
@@ -141,23 +239,51 @@ func NewWatcher(c *Client) Watcher {
 		stopc: make(chan struct{}),
 		donec: make(chan struct{}),
 		errc:  make(chan error, 1),
+
+		reconnectTimeout: c.cfg.WatchReconnectTimeout,
+	}
+	if !c.goAsync(w.run) {
+		cancel()
+		w.errc <- nil
+		close(w.donec)
 	}
-	go w.run()
 	return w
 }
 
 // Watch posts a watch request to run() and waits for a new watcher channel
 func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) WatchChan {
+	if err := ValidateWatchOpts(opts...); err != nil {
+		// invalid request; return a closed channel with the error
+		ch := make(chan WatchResponse, 1)
+		ch <- WatchResponse{Canceled: true, err: err}
+		close(ch)
+		return ch
+	}
+
 	ow := opWatch(key, opts...)
 
+	if ow.etag != "" {
+		ch, rev := w.checkEtag(ctx, ow)
+		if ch != nil {
+			return ch
+		}
+		if ow.rev == 0 {
+			ow.rev = rev
+		}
+	}
+
 	retc := make(chan chan WatchResponse, 1)
 	wr := &watchRequest{
-		ctx:            ctx,
-		key:            string(ow.key),
-		end:            string(ow.end),
-		rev:            ow.rev,
-		progressNotify: ow.progressNotify,
-		retc:           retc,
+		ctx:             ctx,
+		key:             string(ow.key),
+		end:             string(ow.end),
+		rev:             ow.rev,
+		progressNotify:  ow.progressNotify,
+		fragmentSize:    ow.fragmentSize,
+		createdNotify:   ow.createdNotify,
+		eventBufferSize: ow.eventBufferSize,
+		retc:            retc,
+		start:           time.Now(),
 	}
 
 	ok := false
@@ -181,11 +307,117 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 	}
 
 	// couldn't create channel; return closed channel
-	ch := make(chan WatchResponse)
+	ch := make(chan WatchResponse, 1)
+	if ctx.Err() != nil {
+		ch <- ctxCanceledResponse(ctx, wr.start)
+	}
 	close(ch)
 	return ch
 }
 
+func (w *watcher) WatchMulti(ctx context.Context, keys ...string) WatchChan {
+	cctx, cancel := context.WithCancel(ctx)
+	// room for the final response if the subscriber is not receiving
+	outc := make(chan WatchResponse, 1)
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+	)
+	forward := func(wch WatchChan) {
+		defer wg.Done()
+		for wr := range wch {
+			if wr.Err() == nil {
+				select {
+				case outc <- wr:
+				case <-cctx.Done():
+				case <-w.donec:
+				}
+				continue
+			}
+			once.Do(func() {
+				defer cancel()
+				select {
+				case outc <- wr:
+					return
+				case <-ctx.Done():
+				case <-w.donec:
+				}
+				// the subscriber may have stopped reading once ctx is done;
+				// only give it the response if there is room
+				select {
+				case outc <- wr:
+				default:
+				}
+			})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		wg.Add(1)
+		go forward(w.Watch(cctx, k))
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(outc)
+	}()
+	return outc
+}
+
+// checkEtag compares the etag of ow with the cluster's current revision. It
+// returns a closed channel if the watch should not be opened; otherwise it
+// returns the revision to start watching from, or zero for the current one.
+func (w *watcher) checkEtag(ctx context.Context, ow Op) (WatchChan, int64) {
+	// the etag was checked by ValidateWatchOpts
+	id, rev, _ := parseEtag(ow.etag)
+
+	// the client's KV follows reconnects itself; w.conn belongs to the
+	// run goroutine
+	resp, err := w.c.KV.Get(ctx, string(ow.key), WithLimit(1), WithKeysOnly())
+	if err != nil {
+		ch := make(chan WatchResponse, 1)
+		ch <- WatchResponse{Canceled: true, err: err}
+		close(ch)
+		return ch, 0
+	}
+	if resp.Header.ClusterId != id {
+		// the etag is from another cluster; its revision means nothing here
+		return nil, 0
+	}
+	if resp.Header.Revision == rev {
+		ch := make(chan WatchResponse, 1)
+		ch <- WatchResponse{Header: *resp.Header, Canceled: true, err: ErrWatchEtagMatch}
+		close(ch)
+		return ch, 0
+	}
+	return nil, rev + 1
+}
+
+// Etag returns the etag for the cluster and revision of a response header,
+// for use with WithEtag.
+func Etag(h *pb.ResponseHeader) string {
+	return fmt.Sprintf("%x/%d", h.ClusterId, h.Revision)
+}
+
+func parseEtag(etag string) (clusterID uint64, rev int64, err error) {
+	parts := strings.Split(etag, "/")
+	if len(parts) != 2 {
+		return 0, 0, ErrInvalidEtag
+	}
+	if clusterID, err = strconv.ParseUint(parts[0], 16, 64); err != nil {
+		return 0, 0, ErrInvalidEtag
+	}
+	if rev, err = strconv.ParseInt(parts[1], 10, 64); err != nil || rev < 0 {
+		return 0, 0, ErrInvalidEtag
+	}
+	return clusterID, rev, nil
+}
+
 func (w *watcher) Close() error {
 	select {
 	case w.stopc <- struct{}{}:
@@ -204,16 +436,20 @@ func (w *watcher) addStream(resp *pb.WatchResponse, pendingReq *watchRequest) {
 		// a cancel at id creation time means the start revision has
 		// been compacted out of the store
 		ret := make(chan WatchResponse, 1)
-		ret <- WatchResponse{
+		wr := WatchResponse{
 			Header:          *resp.Header,
 			CompactRevision: resp.CompactRevision,
 			Canceled:        true}
+		if resp.CompactRevision != 0 {
+			wr.CancelReason = CancelReasonCompacted
+		}
+		ret <- wr
 		close(ret)
 		pendingReq.retc <- ret
 		return
 	}
 
-	ret := make(chan WatchResponse)
+	ret := make(chan WatchResponse, bufferSize(pendingReq.eventBufferSize))
 	if resp.WatchId == -1 {
 		// failed; no channel
 		close(ret)
@@ -236,12 +472,22 @@ func (w *watcher) addStream(resp *pb.WatchResponse, pendingReq *watchRequest) {
 		ws.initReq.rev = resp.Header.Revision
 	}
 
+	if pendingReq.createdNotify {
+		// queued before any events since serveStream has not started
+		ws.recvc <- &WatchResponse{Header: *resp.Header, Created: true}
+	}
+
 	w.mu.Lock()
 	w.streams[ws.id] = ws
 	w.mu.Unlock()
 
-	// send messages to subscriber
-	go w.serveStream(ws)
+	// send messages to subscriber; none once the client is closed
+	if !w.c.goAsync(func() { w.serveStream(ws) }) {
+		w.mu.Lock()
+		delete(w.streams, ws.id)
+		w.mu.Unlock()
+		close(ret)
+	}
 
 	// pass back the subscriber channel for the watcher
 	pendingReq.retc <- ret
@@ -289,6 +535,7 @@ func (w *watcher) run() {
 			failedReq = pendingReq
 		// New events from the watch client
 		case pbresp := <-w.respc:
+			w.reconnectDeadline = time.Time{}
 			switch {
 			case pbresp.Created:
 				// response to pending req, try to add
@@ -318,7 +565,12 @@ func (w *watcher) run() {
 		// watch client failed to recv; spawn another if possible
 		// TODO report watch client errors from errc?
 		case <-w.errc:
+			if w.reconnectTimeout > 0 && (w.reconnectDeadline.IsZero() || time.Since(w.connectedAt) > w.reconnectTimeout) {
+				// the lost stream was healthy; start a new reconnect period
+				w.reconnectDeadline = time.Now().Add(w.reconnectTimeout)
+			}
 			if wc, wcerr = w.newWatchClient(); wcerr != nil {
+				w.closeErr = wcerr
 				w.errc <- wcerr
 				return
 			}
@@ -330,6 +582,15 @@ func (w *watcher) run() {
 		case <-w.stopc:
 			w.errc <- nil
 			return
+		case <-w.ctx.Done():
+			// the client is closed; drop the error of the canceled stream
+			for {
+				select {
+				case w.errc <- nil:
+					return
+				case <-w.errc:
+				}
+			}
 		}
 
 		// send failed; queue for retry
@@ -353,11 +614,29 @@ func (w *watcher) dispatchEvent(pbresp *pb.WatchResponse) bool {
 	defer w.mu.RUnlock()
 	ws, ok := w.streams[pbresp.WatchId]
 	if ok {
+		events := pbresp.Events
+		if ws.partialValue && len(events) > 0 && len(ws.fragments) > 0 {
+			last := ws.fragments[len(ws.fragments)-1]
+			last.Kv.Value = append(last.Kv.Value, events[0].Kv.Value...)
+			events = events[1:]
+		}
+		ws.partialValue = pbresp.PartialValue
+		if pbresp.Fragment {
+			ws.fragments = append(ws.fragments, events...)
+			return true
+		}
+		if len(ws.fragments) > 0 {
+			events = append(ws.fragments, events...)
+			ws.fragments = nil
+		}
 		wr := &WatchResponse{
 			Header:          *pbresp.Header,
-			Events:          pbresp.Events,
+			Events:          events,
 			CompactRevision: pbresp.CompactRevision,
 			Canceled:        pbresp.Canceled}
+		if wr.CompactRevision != 0 {
+			wr.Canceled, wr.CancelReason = true, CancelReasonCompacted
+		}
 		ws.recvc <- wr
 	}
 	return ok
@@ -388,6 +667,7 @@ func (w *watcher) serveStream(ws *watcherStream) {
 	wrs := []*WatchResponse{}
 	resuming := false
 	closing := false
+	ctxDone := false
 	for !closing {
 		curWr := emptyWr
 		outc := ws.outc
@@ -402,14 +682,11 @@ func (w *watcher) serveStream(ws *watcherStream) {
 				closing = true
 				break
 			}
-			var newRev int64
-			if len(wrs[0].Events) > 0 {
-				newRev = wrs[0].Events[len(wrs[0].Events)-1].Kv.ModRevision
-			} else {
-				newRev = wrs[0].Header.Revision
-			}
-			if newRev != ws.lastRev {
-				ws.lastRev = newRev
+			if n := len(wrs[0].Events); n > 0 {
+				lastKv := wrs[0].Events[n-1].Kv
+				ws.lastRev, ws.lastKey = lastKv.ModRevision, string(lastKv.Key)
+			} else if !wrs[0].Created && wrs[0].Header.Revision != 0 && wrs[0].Header.Revision != ws.lastRev {
+				ws.lastRev, ws.lastKey = wrs[0].Header.Revision, ""
 			}
 			wrs[0] = nil
 			wrs = wrs[1:]
@@ -419,22 +696,14 @@ func (w *watcher) serveStream(ws *watcherStream) {
 				return
 			}
 			// resume up to last seen event if disconnected
-			if resuming {
-				resuming = false
-				// trim events already seen
-				for i := 0; i < len(wr.Events); i++ {
-					if wr.Events[i].Kv.ModRevision > ws.lastRev {
-						wr.Events = wr.Events[i:]
-						break
-					}
-				}
+			if resuming && len(wr.Events) > 0 {
 				// only forward new events
-				if wr.Events[0].Kv.ModRevision == ws.lastRev {
+				if wr.Events = ws.trimSeen(wr.Events); len(wr.Events) == 0 {
 					break
 				}
+				resuming = false
 			}
-			// TODO don't keep buffering if subscriber stops reading
-			wrs = append(wrs, wr)
+			wrs = ws.queue(wrs, wr)
 		case resumeRev := <-ws.resumec:
 			if resumeRev != ws.lastRev {
 				panic("unexpected resume revision")
@@ -444,7 +713,22 @@ func (w *watcher) serveStream(ws *watcherStream) {
 		case <-w.donec:
 			closing = true
 		case <-ws.initReq.ctx.Done():
-			closing = true
+			closing, ctxDone = true, true
+		}
+	}
+	switch {
+	case w.isDone() && w.closeErr != nil:
+		select {
+		case ws.outc <- WatchResponse{Canceled: true, CancelReason: CancelReasonServerClosed, err: w.closeErr}:
+		case <-ws.initReq.ctx.Done():
+		case <-w.c.Ctx().Done():
+		}
+	case ctxDone:
+		// the subscriber may have stopped reading once ctx is done; only
+		// give it the response if it is receiving or there is room
+		select {
+		case ws.outc <- ctxCanceledResponse(ws.initReq.ctx, ws.initReq.start):
+		default:
 		}
 	}
 	w.mu.Lock()
@@ -453,18 +737,85 @@ func (w *watcher) serveStream(ws *watcherStream) {
 	// lazily send cancel message if events on missing id
 }
 
+// ctxCanceledResponse returns the final response of a watch started at start
+// whose context is done. A deadline error gives the deadline and how long
+// the watch ran.
+func ctxCanceledResponse(ctx context.Context, start time.Time) WatchResponse {
+	if ctx.Err() != context.DeadlineExceeded {
+		return WatchResponse{Canceled: true, CancelReason: CancelReasonContextCanceled, err: ctx.Err()}
+	}
+	err := ctx.Err()
+	if deadline, ok := ctx.Deadline(); ok {
+		err = fmt.Errorf("%v (deadline %v, watched for %v)", err, deadline, time.Since(start))
+	}
+	return WatchResponse{Canceled: true, CancelReason: CancelReasonDeadlineExceeded, err: err}
+}
+
+// queue appends wr to the responses waiting for the subscriber. Past the
+// event buffer size, wr is dropped and an overflow notification carrying its
+// header takes its place; errors are always queued.
+func (ws *watcherStream) queue(wrs []*WatchResponse, wr *WatchResponse) []*WatchResponse {
+	n := ws.initReq.eventBufferSize
+	if n <= 0 || len(wrs) < n || wr.Err() != nil {
+		return append(wrs, wr)
+	}
+	if last := wrs[len(wrs)-1]; last.Overflowed {
+		last.Header = wr.Header
+		return wrs
+	}
+	return append(wrs, &WatchResponse{Header: wr.Header, Overflowed: true})
+}
+
+// bufferSize returns the capacity of a watch channel for an event buffer
+// size. It has room for one more response, so the final response of a
+// canceled watch need not wait for the subscriber.
+func bufferSize(n int) int {
+	if n < 0 {
+		return 1
+	}
+	return n + 1
+}
+
+// trimSeen drops the events a resumed stream replays that were already
+// sent over outc before the stream was lost.
+func (ws *watcherStream) trimSeen(evs []*storagepb.Event) []*storagepb.Event {
+	for i, ev := range evs {
+		if ev.Kv.ModRevision > ws.lastRev {
+			return evs[i:]
+		}
+		if ev.Kv.ModRevision == ws.lastRev && string(ev.Kv.Key) == ws.lastKey {
+			return evs[i+1:]
+		}
+	}
+	return nil
+}
+
+// isDone returns true once run has exited.
+func (w *watcher) isDone() bool {
+	select {
+	case <-w.donec:
+		return true
+	default:
+		return false
+	}
+}
+
 func (w *watcher) newWatchClient() (pb.Watch_WatchClient, error) {
 	ws, rerr := w.resume()
 	if rerr != nil {
 		return nil, rerr
 	}
-	go w.serveWatchClient(ws)
+	w.connectedAt = time.Now()
+	w.c.goAsync(func() { w.serveWatchClient(ws) })
 	return ws, nil
 }
 
 // resume creates a new WatchClient with all current watchers reestablished
 func (w *watcher) resume() (ws pb.Watch_WatchClient, err error) {
 	for {
+		if !w.reconnectDeadline.IsZero() && time.Now().After(w.reconnectDeadline) {
+			return nil, ErrWatchReconnectTimeout
+		}
 		if ws, err = w.openWatchClient(); err != nil {
 			break
 		} else if err = w.resumeWatchers(ws); err == nil {
@@ -487,7 +838,7 @@ func (w *watcher) openWatchClient() (ws pb.Watch_WatchClient, err error) {
 			return nil, nerr
 		}
 		w.conn = newConn
-		w.remote = pb.NewWatchClient(w.conn)
+		w.remote = w.c.retryWatchClient(pb.NewWatchClient(w.conn))
 	}
 	return ws, nil
 }
@@ -506,6 +857,8 @@ func (w *watcher) resumeWatchers(wc pb.Watch_WatchClient) error {
 		if ws.lastRev != 0 {
 			ws.initReq.rev = ws.lastRev
 		}
+		// fragments from the broken stream will be resent in full
+		ws.fragments, ws.partialValue = nil, false
 		if err := wc.Send(ws.initReq.toPB()); err != nil {
 			return err
 		}
@@ -537,6 +890,7 @@ func (wr *watchRequest) toPB() *pb.WatchRequest {
 		Key:            []byte(wr.key),
 		RangeEnd:       []byte(wr.end),
 		ProgressNotify: wr.progressNotify,
+		FragmentSize:   int64(wr.fragmentSize),
 	}
 	cr := &pb.WatchRequest_CreateRequest{CreateRequest: req}
 	return &pb.WatchRequest{RequestUnion: cr}
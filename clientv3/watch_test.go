@@ -0,0 +1,98 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// flakyWatchServer creates the first watcher and then fails every stream.
+type flakyWatchServer struct {
+	mu      sync.Mutex
+	streams int
+}
+
+func (s *flakyWatchServer) Watch(stream pb.Watch_WatchServer) error {
+	s.mu.Lock()
+	s.streams++
+	n := s.streams
+	s.mu.Unlock()
+	if n > 1 {
+		return grpc.Errorf(codes.Unavailable, "unavailable")
+	}
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	resp := &pb.WatchResponse{Header: &pb.ResponseHeader{Revision: 1}, WatchId: 1, Created: true}
+	if err := stream.Send(resp); err != nil {
+		return err
+	}
+	return grpc.Errorf(codes.Unavailable, "stream lost")
+}
+
+func TestWatchReconnectTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterWatchServer(srv, &flakyWatchServer{})
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	timeout := 500 * time.Millisecond
+	cli, err := New(Config{
+		Endpoints:             []string{l.Addr().String()},
+		WatchReconnectTimeout: timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// the server may break the stream right after the created
+	// notification, so the timeout is only known to start after the watch
+	start := time.Now()
+	wch := cli.Watch(context.TODO(), "foo", WithCreatedNotify())
+	if wr := <-wch; !wr.Created {
+		t.Fatalf("expected created notification, got %+v", wr)
+	}
+
+	select {
+	case wr := <-wch:
+		if wr.Err() != ErrWatchReconnectTimeout {
+			t.Fatalf("err = %v, want %v", wr.Err(), ErrWatchReconnectTimeout)
+		}
+		if wr.CancelReason != CancelReasonServerClosed {
+			t.Fatalf("cancel reason = %v, want %v", wr.CancelReason, CancelReasonServerClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch channel did not fail after reconnect timeout")
+	}
+	if d := time.Since(start); d < timeout {
+		t.Errorf("watch failed after %v, before the %v timeout", d, timeout)
+	}
+	if _, ok := <-wch; ok {
+		t.Fatalf("expected closed watch channel")
+	}
+}
@@ -230,6 +230,10 @@ type etcdProcessClusterConfig struct {
 	isPeerAutoTLS bool
 	initialToken  string
 	isV3          bool
+
+	// basePort is the first port used by the cluster; zero means
+	// etcdProcessBasePort.
+	basePort int
 }
 
 // newEtcdProcessCluster launches a new cluster from etcd processes, returning
@@ -308,12 +312,17 @@ func (cfg *etcdProcessClusterConfig) etcdProcessConfigs() []*etcdProcessConfig {
 		peerScheme = "https"
 	}
 
+	basePort := cfg.basePort
+	if basePort == 0 {
+		basePort = etcdProcessBasePort
+	}
+
 	etcdCfgs := make([]*etcdProcessConfig, cfg.clusterSize+cfg.proxySize)
 	initialCluster := make([]string, cfg.clusterSize)
 	for i := 0; i < cfg.clusterSize; i++ {
 		var curls []string
 		var curl, curltls string
-		port := etcdProcessBasePort + 2*i
+		port := basePort + 2*i
 
 		switch cfg.clientTLS {
 		case clientNonTLS, clientTLS:
@@ -356,7 +365,7 @@ func (cfg *etcdProcessClusterConfig) etcdProcessConfigs() []*etcdProcessConfig {
 		}
 	}
 	for i := 0; i < cfg.proxySize; i++ {
-		port := etcdProcessBasePort + 2*cfg.clusterSize + i + 1
+		port := basePort + 2*cfg.clusterSize + i + 1
 		curl := url.URL{Scheme: clientScheme, Host: fmt.Sprintf("localhost:%d", port)}
 		name := fmt.Sprintf("testname-proxy%d", i)
 		dataDirPath, derr := ioutil.TempDir("", name+".etcd")
@@ -450,6 +459,21 @@ func spawnWithExpect(args []string, expected string) error {
 	return perr
 }
 
+// spawnWithExpects expects the given strings to be output in order.
+func spawnWithExpects(args []string, xs ...string) error {
+	proc, err := spawnCmd(args)
+	if err != nil {
+		return err
+	}
+	for _, x := range xs {
+		if err = proc.Expect(x); err != nil {
+			proc.Close()
+			return fmt.Errorf("couldn't get expected output %q (%v)", x, err)
+		}
+	}
+	return proc.Close()
+}
+
 // spawnWithExpectedString compares outputs in string format.
 // This is useful when gexpect does not match regex correctly with
 // some UTF-8 format characters.
@@ -15,6 +15,13 @@
 package e2e
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -95,6 +102,963 @@ func testCtlV3Set(t *testing.T, cfg *etcdProcessClusterConfig, dialTimeout time.
 	}
 }
 
+func TestCtlV3GetEmptyIndicator(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	if err := ctlV3Put(epc, "foo", "bar", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+
+	getArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--empty-indicator", "EMPTY")
+	if err := spawnWithExpect(append(getArgs, "nokey"), "EMPTY"); err != nil {
+		t.Fatalf("expected empty indicator (%v)", err)
+	}
+	// first line is the key, not the indicator
+	if err := spawnWithExpectedString(append(getArgs, "foo"), "foo"); err != nil {
+		t.Fatalf("get error (%v)", err)
+	}
+
+	// json output prints no indicator, so it stays valid json
+	args := append(ctlV3PrefixArgs(epc, dialTimeout), "-w", "json", "get", "--empty-indicator", "EMPTY", "nokey")
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		t.Fatalf("get error (%v)", err)
+	}
+	if strings.Contains(string(out), "EMPTY") {
+		t.Fatalf("get -w json printed %q, want no empty indicator", out)
+	}
+}
+
+func TestCtlV3GetOutputTemplate(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	for _, kv := range [][]string{{"foo1", "bar1"}, {"foo2", "bar2"}} {
+		if err := ctlV3Put(epc, kv[0], kv[1], dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+
+	// print only the values; quotes are escaped for the spawning shell
+	tmpl := `'{{range .}}{{printf \"%s;\" .Value}}{{end}}'`
+	cmdArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--prefix", "--output-template", tmpl, "foo")
+	if err := spawnWithExpect(cmdArgs, "bar1;bar2;"); err != nil {
+		t.Fatalf("unexpected template output (%v)", err)
+	}
+}
+
+func TestCtlV3GetFollowerWarning(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, true)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	if err := ctlV3Put(epc, "foo", "bar", 3*time.Second); err != nil {
+		t.Fatalf("failed to put (%v)", err)
+	}
+
+	// every member but the leader serves the read as a follower
+	warnings := 0
+	for _, b := range epc.backends() {
+		args := []string{"../bin/etcdctlv3", "--endpoints", stripSchema(b.cfg.acurl), "get", "foo"}
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("get failed (%v): %s", err, out)
+		}
+		if strings.Contains(string(out), "[warning: read served by follower") {
+			warnings++
+		}
+	}
+	if warnings != len(epc.backends())-1 {
+		t.Fatalf("got %d follower warnings, want %d", warnings, len(epc.backends())-1)
+	}
+}
+
+func TestCtlV3PutExpectVersion(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	tests := []struct {
+		version string
+		wout    string
+	}{
+		// key absent
+		{"0", "OK"},
+		{"0", `put rejected: key "foo" is at version 1, expected 0`},
+		{"1", "OK"},
+		// stale version
+		{"1", `put rejected: key "foo" is at version 2, expected 1`},
+	}
+	for i, tt := range tests {
+		cmdArgs := append(ctlV3PrefixArgs(epc, 3*time.Second), "put", "--expect-version", tt.version, "foo", "bar")
+		if err := spawnWithExpectedString(cmdArgs, tt.wout); err != nil {
+			t.Fatalf("#%d: expected %q (%v)", i, tt.wout, err)
+		}
+	}
+}
+
+func TestCtlV3PutSync(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, true)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	backends := epc.backends()
+	for i := 0; i < 5; i++ {
+		value := fmt.Sprintf("bar%d", i)
+		putArgs := []string{"../bin/etcdctlv3", "--endpoints", stripSchema(backends[0].cfg.acurl), "put", "--sync", "foo", value}
+		if err := spawnWithExpectedString(putArgs, "OK"); err != nil {
+			t.Fatalf("#%d: failed to put (%v)", i, err)
+		}
+		// every member serves the write right away
+		for j, b := range backends {
+			getArgs := []string{"../bin/etcdctlv3", "--endpoints", stripSchema(b.cfg.acurl), "get", "--consistency", "s", "foo"}
+			if err := spawnWithExpects(getArgs, "foo", value); err != nil {
+				t.Fatalf("#%d: member %d did not return %q (%v)", i, j, value, err)
+			}
+		}
+	}
+}
+
+func TestCtlV3EndpointHashKVCluster(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, true)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := ctlV3Put(epc, fmt.Sprintf("foo%d", i), "bar", 3*time.Second); err != nil {
+			t.Fatalf("failed to put (%v)", err)
+		}
+	}
+	args := append(ctlV3PrefixArgs(epc, 3*time.Second), "endpoint-hashkv", "--cluster")
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("endpoint-hashkv failed (%v): %s", err, out)
+	}
+	if !strings.Contains(string(out), "PASS: all 3 members report hash") {
+		t.Fatalf("expected matching hashes, got %s", out)
+	}
+}
+
+func TestCtlV3EndpointStatusCluster(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, true)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	args := append(ctlV3PrefixArgs(epc, 3*time.Second), "endpoint-status", "--cluster")
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("endpoint-status failed (%v): %s", err, out)
+	}
+	rows, leaders := 0, 0
+	for _, l := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(l, "| 127.0.0.1:") && !strings.HasPrefix(l, "| localhost:") {
+			continue
+		}
+		rows++
+		if strings.Contains(l, "| true ") {
+			leaders++
+		}
+	}
+	if rows != 3 || leaders != 1 {
+		t.Fatalf("expected 3 members with one leader, got %d members with %d leaders: %s", rows, leaders, out)
+	}
+}
+
+func TestCtlV3CompactionCluster(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, true)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	// revisions 2 to 21
+	for i := 0; i < 10; i++ {
+		if err := ctlV3Put(epc, fmt.Sprintf("foo%d", i), "bar", 3*time.Second); err != nil {
+			t.Fatalf("failed to put (%v)", err)
+		}
+		dargs := append(ctlV3PrefixArgs(epc, 3*time.Second), "del", fmt.Sprintf("foo%d", i))
+		if dout, err := exec.Command(dargs[0], dargs[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("failed to delete (%v): %s", err, dout)
+		}
+	}
+
+	args := append(ctlV3PrefixArgs(epc, 3*time.Second), "compaction", "--cluster", "--parallelism", "2", "20")
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("compaction failed (%v): %s", err, out)
+	}
+	compacted, already := strings.Count(string(out), ": compacted revision 20"), strings.Count(string(out), ": revision 20 already compacted")
+	if compacted < 1 || compacted+already != 3 {
+		t.Fatalf("expected 3 compacted members, got %s", out)
+	}
+
+	// every member refuses the compacted revisions
+	for _, p := range epc.backends() {
+		gargs := []string{"../bin/etcdctlv3", "--endpoints", stripSchema(p.cfg.acurl), "get", "foo0", "--revision", "19"}
+		gout, gerr := exec.Command(gargs[0], gargs[1:]...).CombinedOutput()
+		if gerr == nil || !strings.Contains(string(gout), "compacted") {
+			t.Errorf("%s: get at compacted revision: got %q (%v), want compacted error", p.cfg.acurl, gout, gerr)
+		}
+	}
+}
+
+func TestCtlV3AuthStatus(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	status := func(wantSimple, wantJSON string) {
+		args := append(ctlV3PrefixArgs(epc, 3*time.Second), "auth", "status")
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if err != nil || strings.TrimSpace(string(out)) != wantSimple {
+			t.Fatalf("auth status = %q (%v), want %q", out, err, wantSimple)
+		}
+		args = append(ctlV3PrefixArgs(epc, 3*time.Second), "-w", "json", "auth", "status")
+		out, err = exec.Command(args[0], args[1:]...).CombinedOutput()
+		if err != nil || strings.TrimSpace(string(out)) != wantJSON {
+			t.Fatalf("auth status -w json = %q (%v), want %q", out, err, wantJSON)
+		}
+	}
+
+	status("Authentication: disabled", `{"enabled":false}`)
+	args := append(ctlV3PrefixArgs(epc, 3*time.Second), "auth", "enable")
+	if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		t.Fatalf("auth enable failed (%v): %s", err, out)
+	}
+	status("Authentication: enabled", `{"enabled":true}`)
+}
+
+func TestCtlV3Profile(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dir, err := ioutil.TempDir("", "etcdctlv3-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, kind := range []string{"cpu", "mem", "trace"} {
+		p := filepath.Join(dir, kind+".out")
+		// run to completion; the profile is written on exit
+		args := append(ctlV3PrefixArgs(epc, 3*time.Second), "get", "foo", "--profile", kind+","+p)
+		if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: get failed (%v): %s", kind, err, out)
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) == 0 {
+			t.Fatalf("%s: empty profile", kind)
+		}
+		switch kind {
+		case "trace":
+			if !bytes.HasPrefix(b, []byte("go 1.")) {
+				t.Errorf("%s: bad trace header %q", kind, b[:8])
+			}
+		default:
+			// pprof profiles are gzipped protobufs
+			if _, err := gzip.NewReader(bytes.NewReader(b)); err != nil {
+				t.Errorf("%s: bad profile (%v)", kind, err)
+			}
+		}
+	}
+}
+
+func TestCtlV3PutBatchFromStdin(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	// more pairs than fit in one txn
+	var in bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&in, "key/%03d\tval %d\n", i, i)
+	}
+	dialTimeout := 3 * time.Second
+	args := append(ctlV3PrefixArgs(epc, dialTimeout), "put", "--batch-from-stdin")
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = &in
+	out, err := cmd.CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "200" {
+		t.Fatalf("put --batch-from-stdin = %q (%v), want 200", out, err)
+	}
+
+	args = append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--prefix", "key/")
+	if out, err = exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		t.Fatalf("get failed (%v): %s", err, out)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("got %d lines, want 400 for 200 pairs", len(lines))
+	}
+	for i := 0; i < 200; i++ {
+		if k, v := lines[2*i], lines[2*i+1]; k != fmt.Sprintf("key/%03d", i) || v != fmt.Sprintf("val %d", i) {
+			t.Fatalf("#%d: got %q=%q", i, k, v)
+		}
+	}
+
+	// a repeated key starts a new txn, so the last value wins; CRLF input
+	// leaves no carriage return in the values
+	args = append(ctlV3PrefixArgs(epc, dialTimeout), "put", "--batch-from-stdin")
+	cmd = exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader("dup/a\tv1\r\ndup/b\tv1\r\ndup/a\tv2\r\n")
+	if out, err = cmd.CombinedOutput(); err != nil || strings.TrimSpace(string(out)) != "3" {
+		t.Fatalf("put --batch-from-stdin = %q (%v), want 3", out, err)
+	}
+	args = append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--prefix", "dup/")
+	if out, err = exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		t.Fatalf("get failed (%v): %s", err, out)
+	}
+	if s := string(out); s != "dup/a\nv2\ndup/b\nv1\n" {
+		t.Fatalf("got %q, want dup/a=v2 and dup/b=v1", s)
+	}
+
+	// a malformed line is rejected before anything is written
+	args = append(ctlV3PrefixArgs(epc, dialTimeout), "put", "--batch-from-stdin")
+	cmd = exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader("bad/1\tv\nno tab\n")
+	if out, err = cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "line 2") {
+		t.Fatalf("expected line 2 to be rejected, got %q (%v)", out, err)
+	}
+	if err = spawnWithExpect(append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--empty-indicator", "EMPTY", "bad/1"), "EMPTY"); err != nil {
+		t.Fatalf("expected nothing written (%v)", err)
+	}
+}
+
+func TestCtlV3GetKeysSinceRevision(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	// a fresh cluster is at revision 1, so the puts are at revisions 2 to 5
+	dialTimeout := 3 * time.Second
+	for _, kv := range [][]string{{"key/a", "1"}, {"key/b", "2"}, {"key/c", "3"}, {"key/a", "4"}, {"other", "5"}} {
+		if err := ctlV3Put(epc, kv[0], kv[1], dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+
+	args := append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--prefix", "--keys-since-revision", "4", "key/")
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("get failed (%v): %s", err, out)
+	}
+	// keys only; each key is followed by an empty value line
+	if w := "key/a\n\nkey/c\n\n"; string(out) != w {
+		t.Fatalf("got %q, want %q", out, w)
+	}
+}
+
+func TestCtlV3GetCount(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	for i := 0; i < 42; i++ {
+		if err := ctlV3Put(epc, fmt.Sprintf("__prefix__%d", i), "v", dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+	if err := ctlV3Put(epc, "other", "v", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+
+	tests := []struct {
+		args []string
+		wout string
+	}{
+		{[]string{"__prefix__", "--prefix", "--count"}, "42"},
+		{[]string{"__prefix__", "--from-key", "--count"}, "43"},
+		{[]string{"__prefix__0", "--count"}, "1"},
+		{[]string{"missing", "--count"}, "0"},
+	}
+	for i, tt := range tests {
+		args := append(ctlV3PrefixArgs(epc, dialTimeout), "get")
+		args = append(args, tt.args...)
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			t.Fatalf("#%d: get failed (%v)", i, err)
+		}
+		if string(out) != tt.wout+"\n" {
+			t.Errorf("#%d: got %q, want %q", i, out, tt.wout+"\n")
+		}
+	}
+}
+
+func TestCtlV3GetMaxResultsSize(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	val := strings.Repeat("v", 1024)
+	for i := 0; i < 100; i++ {
+		if err := ctlV3Put(epc, fmt.Sprintf("key%03d", i), val, dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+
+	args := append(ctlV3PrefixArgs(epc, dialTimeout), "get", "key", "--prefix", "--max-results-size", "10KiB")
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected get to fail, got %q", out)
+	}
+	if !strings.Contains(string(out), "exceeds --max-results-size 10240 bytes") {
+		t.Fatalf("unexpected error %q", out)
+	}
+
+	// the server drops the keys past the limit instead
+	args = append(args, "--server-limit")
+	cmd := exec.Command(args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if out, err = cmd.Output(); err != nil {
+		t.Fatalf("get failed (%v, %q)", err, stderr.String())
+	}
+	if n := strings.Count(string(out), val); n == 0 || n >= 10 {
+		t.Fatalf("got %d values, want fewer than 10", n)
+	}
+	if !strings.Contains(stderr.String(), "results truncated") {
+		t.Fatalf("expected truncation warning, got %q", stderr.String())
+	}
+}
+
+func TestCtlV3GetRevision(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	// revisions 2 and 3
+	for _, v := range []string{"bar1", "bar2"} {
+		if err := ctlV3Put(epc, "foo", v, dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+
+	get := func(rev string) (string, error) {
+		args := append(ctlV3PrefixArgs(epc, dialTimeout), "get", "--revision", rev, "foo")
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		return string(out), err
+	}
+	for i, tt := range []struct{ rev, wout string }{
+		{"2", "foo\nbar1\n"},
+		{"3", "foo\nbar2\n"},
+		{"latest", "foo\nbar2\n"},
+	} {
+		out, err := get(tt.rev)
+		if err != nil {
+			t.Fatalf("#%d: get failed (%v): %s", i, err, out)
+		}
+		if out != tt.wout {
+			t.Errorf("#%d: got %q, want %q", i, out, tt.wout)
+		}
+	}
+
+	args := append(ctlV3PrefixArgs(epc, dialTimeout), "compaction", "3")
+	if err := spawnWithExpectedString(args, "compacted revision 3"); err != nil {
+		t.Fatal(err)
+	}
+	// a write after the compaction keeps the current revision readable
+	if err := ctlV3Put(epc, "baz", "qux", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+	if out, err := get("2"); err == nil || !strings.Contains(out, "compacted") {
+		t.Errorf("get at compacted revision: got %q (%v), want compacted error", out, err)
+	}
+	if out, err := get("latest"); err != nil || out != "foo\nbar2\n" {
+		t.Errorf("get at latest: got %q (%v), want %q", out, err, "foo\nbar2\n")
+	}
+}
+
+func TestCtlV3GetBinaryFormat(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	// "c2VjcmV0" is "secret" in base64
+	if err := ctlV3Put(epc, "secret", "c2VjcmV0", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+
+	tests := []struct {
+		flags []string
+		wout  string
+	}{
+		{[]string{"--binary-format", "raw"}, "secret\nc2VjcmV0\n"},
+		{[]string{"--binary-format", "base64"}, "secret\nsecret\n"},
+		{[]string{"--binary-format", "hex"}, "\\x73\\x65\\x63\\x72\\x65\\x74\n\\x63\\x32\\x56\\x6a\\x63\\x6d\\x56\\x30\n"},
+		// --hex is the same as --binary-format hex
+		{[]string{"--hex"}, "\\x73\\x65\\x63\\x72\\x65\\x74\n\\x63\\x32\\x56\\x6a\\x63\\x6d\\x56\\x30\n"},
+	}
+	for i, tt := range tests {
+		args := append(ctlV3PrefixArgs(epc, dialTimeout), tt.flags...)
+		args = append(args, "get", "secret")
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("#%d: get failed (%v): %s", i, err, out)
+		}
+		if string(out) != tt.wout {
+			t.Errorf("#%d: got %q, want %q", i, out, tt.wout)
+		}
+	}
+}
+
+func TestCtlV3GetSchema(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	for _, kv := range [][]string{{"foo", `{"b":[1,"x"],"a":{"c":true}}`}, {"bar", "notjson"}} {
+		// run directly so the shell does not strip the JSON quotes
+		cmdArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "put", kv[0], kv[1])
+		if out, err := exec.Command(cmdArgs[0], cmdArgs[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("put error (%v): %s", err, out)
+		}
+	}
+
+	tests := []struct {
+		key    string
+		schema string
+		wout   string
+	}{
+		{"foo", "json", "foo\n{\n  \"b\": [\n    1,\n    \"x\"\n  ],\n  \"a\": {\n    \"c\": true\n  }\n}\n"},
+		{"foo", "yaml", "foo\na:\n  c: true\nb:\n  - 1\n  - x\n"},
+		{"bar", "json", "bar\nnotjson\n"},
+	}
+	for i, tt := range tests {
+		cmdArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "get", tt.key, "--schema", tt.schema)
+		out, err := exec.Command(cmdArgs[0], cmdArgs[1:]...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("#%d: get error (%v): %s", i, err, out)
+		}
+		if string(out) != tt.wout {
+			t.Errorf("#%d: output = %q, want %q", i, out, tt.wout)
+		}
+	}
+}
+
+func TestCtlV3TxnBadInput(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	tests := []struct {
+		in   string
+		werr string
+	}{
+		{`mod("a") > "0"` + "\n" + `compare(version("foo") = "abc")` + "\n\n\n", `line 2: malformed comparison`},
+		{`version("foo") = "abc"` + "\n\n\n", `line 1: invalid txn compare request`},
+		{`val("a") != "x"` + "\n\n\n", `line 1: malformed comparison: val("a") != "x" (unknown operator "!="`},
+		{"\nput a b\n\npt a b\n\n", `line 4: invalid txn request: pt a b (unknown request "pt"`},
+		{"\nput a\n\n", `line 2: invalid txn request: put a (expected put <key> <value>)`},
+	}
+	for i, tt := range tests {
+		// nothing listens on the endpoint; bad input must fail before dialing
+		cmd := exec.Command("../bin/etcdctlv3", "--endpoints", "127.0.0.1:1", "--dial-timeout", "10s", "txn")
+		cmd.Stdin = strings.NewReader(tt.in)
+		start := time.Now()
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			t.Fatalf("#%d: expected txn to fail", i)
+		}
+		if !strings.Contains(string(out), tt.werr) {
+			t.Errorf("#%d: output = %q, want %q", i, out, tt.werr)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Errorf("#%d: took %v, expected failure before dialing", i, d)
+		}
+	}
+}
+
+func TestCtlV3WatchInteractiveMultiLine(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	cmdArgs := append(ctlV3PrefixArgs(epc, 3*time.Second), "watch", "-i", "--multi-line-value")
+	proc, err := spawnCmd(cmdArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close()
+
+	for _, l := range []string{"watch <<EOF", "foo", "bar", "EOF"} {
+		// SendLine terminates with "\r\n", which the tty turns into an extra empty line
+		if err = proc.Send(l + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// give the watcher a moment to register before writing
+	time.Sleep(time.Second)
+
+	// single quotes keep the embedded newline inside one shell word
+	if err = ctlV3Put(epc, "'foo\nbar'", "baz", 3*time.Second); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+	for _, s := range []string{"PUT", "bar", "baz"} {
+		if err = proc.Expect(s); err != nil {
+			t.Fatalf("expected %q from watch (%v)", s, err)
+		}
+	}
+}
+
+func TestCtlV3WatchFragmentSize(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	kvs := [][]string{{"foo1", "bar1"}, {"foo2", "bar2"}, {"foo3", "bar3"}}
+	for _, kv := range kvs {
+		if err := ctlV3Put(epc, kv[0], kv[1], dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+
+	// a one byte limit puts each past event in its own fragment
+	cmdArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "watch", "--prefix", "--rev", "1", "--fragment-size", "1", "foo")
+	proc, err := spawnCmd(cmdArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close()
+
+	for _, kv := range kvs {
+		for _, s := range []string{"PUT", kv[0], kv[1]} {
+			if err = proc.Expect(s); err != nil {
+				t.Fatalf("expected %q from watch (%v)", s, err)
+			}
+		}
+	}
+}
+
+func TestCtlV3WatchOutputFile(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "watch.out")
+
+	// a one byte limit rotates the file before every response but the first
+	dialTimeout := 3 * time.Second
+	cmdArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "watch", "--output-file", p, "--max-output-file-size", "1", "foo")
+	proc, err := spawnCmd(cmdArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close()
+	// give the watcher a moment to register before writing
+	time.Sleep(time.Second)
+
+	for _, v := range []string{"bar1", "bar2"} {
+		if err = ctlV3Put(epc, "foo", v, dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+		waitFileContains(t, p, "PUT\nfoo\n"+v+"\n")
+	}
+	waitFileContains(t, p+".1", "PUT\nfoo\nbar1\n")
+}
+
+func TestCtlV3WatchDiff(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "watch.out")
+
+	// single quotes keep the embedded newlines inside one shell word
+	dialTimeout := 3 * time.Second
+	if err = ctlV3Put(epc, "config", "'a=1\nb=2\nc=3'", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+
+	cmdArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "watch", "--diff", "--output-file", p, "config")
+	proc, err := spawnCmd(cmdArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close()
+	// give the watcher a moment to register before writing
+	time.Sleep(time.Second)
+
+	// the previous value of the first event is read from the store
+	if err = ctlV3Put(epc, "config", "'a=1\nb=20\nc=3'", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+	waitFileContains(t, p, "PUT\nconfig\n@@ -2 +2 @@\n-b=2\n+b=20\n")
+}
+
+// waitFileContains waits for the file at p to hold s and nothing else.
+func waitFileContains(t *testing.T, p, s string) {
+	var (
+		b   []byte
+		err error
+	)
+	for i := 0; i < 50; i++ {
+		if b, err = ioutil.ReadFile(p); err == nil && string(b) == s {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("%s = %q (%v), want %q", p, b, err, s)
+}
+
+func TestCtlV3EndpointLatency(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	cmdArgs := append(ctlV3PrefixArgs(epc, 3*time.Second), "endpoint-latency", "--count", "10")
+	// endpoint, requests, errors, then min/p50/p90/p99/max
+	lat := ` +[0-9.]+[^ |]*s +\|`
+	row := stripSchema(epc.procs[0].cfg.acurl) + ` +\| +10 +\| +0 +\|` + strings.Repeat(lat, 5)
+	if err := spawnWithExpect(cmdArgs, row); err != nil {
+		t.Fatalf("unexpected latency output (%v)", err)
+	}
+}
+
+func TestCtlV3MakeMirror(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+	destCfg := configNoTLS
+	destCfg.basePort = etcdProcessBasePort + 1000
+	destc := setupCtlV3Test(t, &destCfg, false)
+	defer func() {
+		if errC := destc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dialTimeout := 3 * time.Second
+	for _, kv := range [][]string{{"foo1", "v1"}, {"foo2", "v2"}, {"other", "v3"}} {
+		if err := ctlV3Put(epc, kv[0], kv[1], dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+
+	dest := stripSchema(destc.procs[0].cfg.acurl)
+	mmArgs := append(ctlV3PrefixArgs(epc, dialTimeout), "make-mirror", "--prefix", "foo", "--dest-prefix", "bar")
+	if err := spawnWithExpectedString(append(mmArgs, "--sync-once", dest), "keys synced: 2"); err != nil {
+		t.Fatalf("sync-once error (%v)", err)
+	}
+	getArgs := append(ctlV3PrefixArgs(destc, dialTimeout), "get", "--prefix", "bar")
+	if err := spawnWithExpects(getArgs, "bar1", "v1", "bar2", "v2"); err != nil {
+		t.Fatalf("expected mirrored keys (%v)", err)
+	}
+
+	proc, err := spawnCmd(append(mmArgs, dest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close()
+	// wait for the base sync before writing updates
+	if err = proc.Expect("keys synced: 2, lag: 0"); err != nil {
+		t.Fatalf("expected stats from make-mirror (%v)", err)
+	}
+	if err = ctlV3Put(epc, "foo3", "v3", dialTimeout); err != nil {
+		t.Fatalf("put error (%v)", err)
+	}
+	if err = proc.Expect("keys synced: 3, lag: 0"); err != nil {
+		t.Fatalf("expected stats from make-mirror (%v)", err)
+	}
+	getArgs = append(ctlV3PrefixArgs(destc, dialTimeout), "get", "bar3")
+	if err = spawnWithExpects(getArgs, "bar3", "v3"); err != nil {
+		t.Fatalf("expected mirrored update (%v)", err)
+	}
+}
+
+func TestCtlV3SnapshotDiff(t *testing.T) {
+	defer testutil.AfterTest(t)
+
+	epc := setupCtlV3Test(t, &configNoTLS, false)
+	defer func() {
+		if errC := epc.Close(); errC != nil {
+			t.Fatalf("error closing etcd processes (%v)", errC)
+		}
+	}()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "snapdiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	snap1, snap2 := filepath.Join(dir, "snap1"), filepath.Join(dir, "snap2")
+
+	dialTimeout := 3 * time.Second
+	for _, kv := range [][]string{{"foo", "bar"}, {"gone", "x"}} {
+		if err = ctlV3Put(epc, kv[0], kv[1], dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+	if err = ctlV3Snapshot(epc, snap1, dialTimeout); err != nil {
+		t.Fatalf("snapshot error (%v)", err)
+	}
+	for _, kv := range [][]string{{"foo", "baz"}, {"newkey", "v"}} {
+		if err = ctlV3Put(epc, kv[0], kv[1], dialTimeout); err != nil {
+			t.Fatalf("put error (%v)", err)
+		}
+	}
+	if err = spawnWithExpect(append(ctlV3PrefixArgs(epc, dialTimeout), "del", "gone"), "0"); err != nil {
+		t.Fatalf("del error (%v)", err)
+	}
+	if err = ctlV3Snapshot(epc, snap2, dialTimeout); err != nil {
+		t.Fatalf("snapshot error (%v)", err)
+	}
+
+	proc, err := spawnCmd(append(ctlV3PrefixArgs(epc, dialTimeout), "snapshot", "diff", snap1, snap2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close()
+	for _, l := range []string{"~foo old=bar new=baz", "-gone=x", "+newkey=v"} {
+		if err = proc.Expect(l); err != nil {
+			t.Fatalf("expected %q from diff (%v)", l, err)
+		}
+	}
+}
+
+func ctlV3Snapshot(clus *etcdProcessCluster, path string, dialTimeout time.Duration) error {
+	proc, err := spawnCmd(append(ctlV3PrefixArgs(clus, dialTimeout), "snapshot", path))
+	if err != nil {
+		return err
+	}
+	return proc.Wait()
+}
+
 func ctlV3PrefixArgs(clus *etcdProcessCluster, dialTimeout time.Duration) []string {
 	if len(clus.proxies()) > 0 { // TODO: add proxy check as in v2
 		panic("v3 proxy not implemented")
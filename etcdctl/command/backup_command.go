@@ -38,6 +38,7 @@ func NewBackupCommand() cli.Command {
 		Flags: []cli.Flag{
 			cli.StringFlag{Name: "data-dir", Value: "", Usage: "Path to the etcd data dir"},
 			cli.StringFlag{Name: "backup-dir", Value: "", Usage: "Path to the backup dir"},
+			cli.StringFlag{Name: "wal-encryption-key-file", Value: "", Usage: "Path to the file the wal encryption key is derived from, if the wal is encrypted"},
 		},
 		Action: handleBackup,
 	}
@@ -67,7 +68,14 @@ func handleBackup(c *cli.Context) {
 		}
 	}
 
-	w, err := wal.OpenForRead(srcWAL, walsnap)
+	var walKey []byte
+	if kf := c.String("wal-encryption-key-file"); kf != "" {
+		if walKey, err = wal.ReadEncryptionKeyFile(kf); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	w, err := wal.OpenForReadWithKey(srcWAL, walsnap, walKey)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -87,7 +95,7 @@ func handleBackup(c *cli.Context) {
 	metadata.NodeID = idgen.Next()
 	metadata.ClusterID = idgen.Next()
 
-	neww, err := wal.Create(destWAL, pbutil.MustMarshal(&metadata))
+	neww, err := wal.CreateWithKey(destWAL, pbutil.MustMarshal(&metadata), walKey)
 	if err != nil {
 		log.Fatal(err)
 	}
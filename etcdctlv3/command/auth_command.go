@@ -24,11 +24,12 @@ import (
 // NewAuthCommand returns the cobra command for "auth".
 func NewAuthCommand() *cobra.Command {
 	ac := &cobra.Command{
-		Use:   "auth <enable or disable>",
-		Short: "Enable or disable authentication.",
+		Use:   "auth <enable, disable or status>",
+		Short: "Enable or disable authentication, or show whether it is enabled.",
 	}
 
 	ac.AddCommand(NewAuthEnableCommand())
+	ac.AddCommand(NewAuthStatusCommand())
 
 	return ac
 }
@@ -52,3 +53,24 @@ func authEnableCommandFunc(cmd *cobra.Command, args []string) {
 		ExitWithError(ExitError, err)
 	}
 }
+
+func NewAuthStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "show whether authentication is enabled",
+		Run:   authStatusCommandFunc,
+	}
+}
+
+// authStatusCommandFunc executes the "auth status" command.
+func authStatusCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("auth status command does not accept argument."))
+	}
+
+	resp, err := mustClientFromCmd(cmd).Auth.AuthStatus(context.TODO())
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	display.AuthStatus(*resp)
+}
@@ -16,19 +16,32 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"strconv"
+	"sync"
 
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
 
+var (
+	compactCluster     bool
+	compactParallelism int
+)
+
 // NewCompactionCommand returns the cobra command for "compaction".
 func NewCompactionCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "compaction <revision>",
 		Short: "Compaction compacts the event history in etcd.",
 		Run:   compactionCommandFunc,
 	}
+	cmd.Flags().BoolVar(&compactCluster, "cluster", false, "compact every cluster member to the revision and report the result of each")
+	cmd.Flags().IntVar(&compactParallelism, "parallelism", 0, "number of members compacted at the same time with --cluster; 0 compacts all of them at once")
+	return cmd
 }
 
 // compactionCommandFunc executes the "compaction" command.
@@ -41,11 +54,71 @@ func compactionCommandFunc(cmd *cobra.Command, args []string) {
 	if err != nil {
 		ExitWithError(ExitError, err)
 	}
+	if compactParallelism < 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("--parallelism must not be negative"))
+	}
 
 	c := mustClientFromCmd(cmd)
+	if compactCluster {
+		compactionClusterFunc(c, rev)
+		return
+	}
 	if cerr := c.Compact(context.TODO(), rev); cerr != nil {
 		ExitWithError(ExitError, cerr)
 		return
 	}
 	fmt.Println("compacted revision", rev)
 }
+
+// compactionClusterFunc compacts every member to rev, compactParallelism
+// members at a time, and fails if any of them could not be compacted.
+func compactionClusterFunc(c *clientv3.Client, rev int64) {
+	eps := memberEndpoints(c)
+	n := compactParallelism
+	if n == 0 || n > len(eps) {
+		n = len(eps)
+	}
+
+	errs := make([]error, len(eps))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, ep := range eps {
+		wg.Add(1)
+		go func(i int, ep string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = compactEndpoint(c, ep, rev)
+		}(i, ep)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		switch err {
+		case nil:
+			fmt.Printf("%s: compacted revision %d\n", eps[i], rev)
+		case rpctypes.ErrCompacted:
+			// compactions go through raft, so the member may have
+			// applied the one sent to another member first
+			fmt.Printf("%s: revision %d already compacted\n", eps[i], rev)
+		default:
+			fmt.Fprintf(os.Stderr, "%s: failed to compact: %v\n", eps[i], err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		ExitWithError(ExitError, fmt.Errorf("failed to compact %d of %d members", failed, len(eps)))
+	}
+}
+
+// compactEndpoint sends the compaction of rev to the member at ep.
+func compactEndpoint(c *clientv3.Client, ep string, rev int64) error {
+	conn, err := c.Dial(ep)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = pb.NewKVClient(conn).Compact(context.TODO(), &pb.CompactionRequest{Revision: rev})
+	return err
+}
@@ -0,0 +1,182 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// hexDiffLineBytes is the number of bytes per line in the hex dump that
+// binary values are diffed as.
+const hexDiffLineBytes = 16
+
+// diffLines splits a value into the lines that are compared by
+// valueDiff, or into the lines of its hex dump if asHex is set.
+func diffLines(v []byte, asHex bool) []string {
+	if len(v) == 0 {
+		return nil
+	}
+	if !asHex {
+		return strings.Split(strings.TrimSuffix(string(v), "\n"), "\n")
+	}
+	var lines []string
+	for i := 0; i < len(v); i += hexDiffLineBytes {
+		end := i + hexDiffLineBytes
+		if end > len(v) {
+			end = len(v)
+		}
+		lines = append(lines, fmt.Sprintf("%08x  %s", i, hex.EncodeToString(v[i:end])))
+	}
+	return lines
+}
+
+func isPrintableText(v []byte) bool {
+	if !utf8.Valid(v) {
+		return false
+	}
+	for _, r := range string(v) {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueDiff returns the unified diff, without context lines, that turns
+// the value old into new. If one of them is binary, both are compared as
+// hex dumps.
+func valueDiff(old, new []byte) []string {
+	asHex := !isPrintableText(old) || !isPrintableText(new)
+	return unifiedDiff(diffLines(old, asHex), diffLines(new, asHex))
+}
+
+// unifiedDiff returns the hunks, in the format of "diff -U0", that turn
+// the lines a into b. The lines common to both are found as their longest
+// common subsequence, in space linear in the number of lines. If the lines
+// between the common head and tail are too many to align, they are
+// replaced in a single hunk.
+func unifiedDiff(a, b []string) []string {
+	head := 0
+	for head < len(a) && head < len(b) && a[head] == b[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(a)-head && tail < len(b)-head && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+	ma, mb := a[head:len(a)-tail], b[head:len(b)-tail]
+
+	var common [][2]int
+	if int64(len(ma))*int64(len(mb)) <= maxDiffCells {
+		common = lcsPairs(ma, mb, head, head, nil)
+	}
+	// the common tail ends the last hunk
+	common = append(common, [2]int{len(a) - tail, len(b) - tail})
+
+	var out []string
+	i, j := head, head
+	for _, c := range common {
+		if c[0] > i || c[1] > j {
+			out = append(out, fmt.Sprintf("@@ -%s +%s @@", hunkRange(i, c[0]-i), hunkRange(j, c[1]-j)))
+			for _, l := range a[i:c[0]] {
+				out = append(out, "-"+l)
+			}
+			for _, l := range b[j:c[1]] {
+				out = append(out, "+"+l)
+			}
+		}
+		i, j = c[0]+1, c[1]+1
+	}
+	return out
+}
+
+// maxDiffCells bounds the product of the numbers of changed lines that
+// unifiedDiff aligns line by line, which takes time proportional to it.
+const maxDiffCells = 1 << 26
+
+// lcsPairs appends to pairs the indexes, offset by ai and bj, of the lines
+// of a longest common subsequence of a and b, in order. It splits a in
+// half and b where the subsequences of both halves are longest together
+// (Hirschberg's algorithm), so it only keeps a row of lengths at a time.
+func lcsPairs(a, b []string, ai, bj int, pairs [][2]int) [][2]int {
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		return pairs
+	case len(a) == 1:
+		for j := range b {
+			if a[0] == b[j] {
+				return append(pairs, [2]int{ai, bj + j})
+			}
+		}
+		return pairs
+	}
+
+	mid := len(a) / 2
+	l := lcsLens(a[:mid], b)
+	r := lcsLens(reversed(a[mid:]), reversed(b))
+	k, best := 0, -1
+	for j := 0; j <= len(b); j++ {
+		if n := l[j] + r[len(b)-j]; n > best {
+			k, best = j, n
+		}
+	}
+	pairs = lcsPairs(a[:mid], b[:k], ai, bj, pairs)
+	return lcsPairs(a[mid:], b[k:], ai+mid, bj+k, pairs)
+}
+
+// lcsLens returns the lengths of the longest common subsequences of a and
+// each prefix b[:j], indexed by j.
+func lcsLens(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+func reversed(s []string) []string {
+	r := make([]string, len(s))
+	for i := range s {
+		r[len(s)-1-i] = s[i]
+	}
+	return r
+}
+
+// hunkRange formats the range of n lines at index start as in a unified
+// diff hunk header.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		// an empty range names the line before it
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
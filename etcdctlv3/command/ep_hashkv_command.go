@@ -0,0 +1,138 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+// hashKVRetries is how many times a member that has not yet applied the
+// hashed revision is asked again, waiting hashKVRetryInterval in between.
+const (
+	hashKVRetries       = 10
+	hashKVRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	epHashKVCluster bool
+	epHashKVRev     int64
+)
+
+// NewEpHashKVCommand returns the cobra command for "endpoint-hashkv".
+func NewEpHashKVCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "endpoint-hashkv",
+		Short: "endpoint-hashkv prints the hash of the key-value store of endpoints specified in `--endpoints` flag",
+		Run:   epHashKVCommandFunc,
+	}
+	cmd.Flags().BoolVar(&epHashKVCluster, "cluster", false, "hash every cluster member at the same revision and report any divergence")
+	cmd.Flags().Int64Var(&epHashKVRev, "rev", 0, "revision to hash up to; 0 uses the current revision (of the first member with --cluster)")
+	return cmd
+}
+
+// epHash is the hash reported by one endpoint.
+type epHash struct {
+	ep   string
+	resp *clientv3.HashKVResponse
+	err  error
+}
+
+// epHashKVCommandFunc executes the "endpoint-hashkv" command.
+func epHashKVCommandFunc(cmd *cobra.Command, args []string) {
+	c := mustClientFromCmd(cmd)
+	if epHashKVCluster {
+		epHashKVClusterFunc(c)
+		return
+	}
+
+	var hashes []epHash
+	for _, ep := range c.Endpoints() {
+		resp, err := c.HashKV(context.TODO(), ep, epHashKVRev)
+		hashes = append(hashes, epHash{ep, resp, err})
+	}
+	printHashes(hashes)
+}
+
+// epHashKVClusterFunc hashes all members at the revision of the first one
+// and fails if any of the hashes differ.
+func epHashKVClusterFunc(c *clientv3.Client) {
+	eps := memberEndpoints(c)
+
+	rev := epHashKVRev
+	hashes := make([]epHash, len(eps))
+	for i, ep := range eps {
+		resp, err := hashKVAt(c, ep, rev)
+		hashes[i] = epHash{ep, resp, err}
+		if err == nil && rev == 0 {
+			// hash the other members at the same revision
+			rev = resp.Header.Revision
+		}
+	}
+	printHashes(hashes)
+
+	var want *clientv3.HashKVResponse
+	for _, h := range hashes {
+		if h.err != nil {
+			ExitWithError(ExitError, fmt.Errorf("FAIL: could not hash every member at revision %d", rev))
+		}
+		if want == nil {
+			want = h.resp
+		}
+		if h.resp.Hash != want.Hash || h.resp.CompactRevision != want.CompactRevision {
+			ExitWithError(ExitError, fmt.Errorf("FAIL: members diverge at revision %d", rev))
+		}
+	}
+	fmt.Printf("PASS: all %d members report hash %d at revision %d\n", len(hashes), want.Hash, rev)
+}
+
+// hashKVAt hashes the member at ep, waiting for it to apply rev if it has
+// not yet.
+func hashKVAt(c *clientv3.Client, ep string, rev int64) (*clientv3.HashKVResponse, error) {
+	for i := 0; ; i++ {
+		resp, err := c.HashKV(context.TODO(), ep, rev)
+		if err != rpctypes.ErrFutureRev || i == hashKVRetries {
+			return resp, err
+		}
+		time.Sleep(hashKVRetryInterval)
+	}
+}
+
+func printHashes(hashes []epHash) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Endpoint", "Member", "Current Revision", "Compact Revision", "Hash"})
+	for _, h := range hashes {
+		if h.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: failed to hash: %v\n", h.ep, h.err)
+			continue
+		}
+		table.Append([]string{
+			h.ep,
+			types.ID(h.resp.Header.MemberId).String(),
+			fmt.Sprint(h.resp.Header.Revision),
+			fmt.Sprint(h.resp.CompactRevision),
+			fmt.Sprint(h.resp.Hash),
+		})
+	}
+	table.Render()
+}
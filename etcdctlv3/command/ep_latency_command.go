@@ -0,0 +1,162 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+// latencyProbeKey is the key read to measure request latency.
+const latencyProbeKey = "__latency_probe__"
+
+var (
+	epLatencyCount    int
+	epLatencyDuration time.Duration
+)
+
+// NewEpLatencyCommand returns the cobra command for "endpoint-latency".
+func NewEpLatencyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "endpoint-latency",
+		Short: "endpoint-latency measures the request latency of endpoints specified in `--endpoints` flag",
+		Run:   epLatencyCommandFunc,
+	}
+	cmd.Flags().IntVar(&epLatencyCount, "count", 100, "number of concurrent requests sent to each endpoint")
+	cmd.Flags().DurationVar(&epLatencyDuration, "duration", 0, "keep sending requests for this long; 0 sends each of the concurrent requests once")
+	return cmd
+}
+
+// epLatency holds the latencies measured against one endpoint.
+type epLatency struct {
+	ep   string
+	lats []time.Duration
+	errs int
+	err  error
+}
+
+// epLatencyCommandFunc executes the "endpoint-latency" command.
+func epLatencyCommandFunc(cmd *cobra.Command, args []string) {
+	if epLatencyCount <= 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("--count must be positive"))
+	}
+
+	endpoints, err := cmd.Flags().GetStringSlice("endpoints")
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	cert, key, cacert := keyAndCertFromCmd(cmd)
+	dt := dialTimeoutFromCmd(cmd)
+	results := make([]*epLatency, len(endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range endpoints {
+		cfg, err := newClientCfg([]string{ep}, dt, cert, key, cacert)
+		if err != nil {
+			ExitWithError(ExitBadArgs, err)
+		}
+		results[i] = &epLatency{ep: ep}
+		wg.Add(1)
+		go func(cfg *clientv3.Config, r *epLatency) {
+			defer wg.Done()
+			r.measure(cfg, epLatencyCount, epLatencyDuration)
+		}(cfg, results[i])
+	}
+	wg.Wait()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Endpoint", "Requests", "Errors", "Min", "P50", "P90", "P99", "Max"})
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(os.Stderr, "%s: failed to connect: %v\n", r.ep, r.err)
+			continue
+		}
+		row := []string{r.ep, fmt.Sprint(len(r.lats)), fmt.Sprint(r.errs)}
+		if len(r.lats) == 0 {
+			row = append(row, "-", "-", "-", "-", "-")
+		} else {
+			sort.Sort(durationSlice(r.lats))
+			row = append(row,
+				fmt.Sprint(r.lats[0]),
+				fmt.Sprint(percentile(r.lats, 50)),
+				fmt.Sprint(percentile(r.lats, 90)),
+				fmt.Sprint(percentile(r.lats, 99)),
+				fmt.Sprint(r.lats[len(r.lats)-1]),
+			)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
+// measure sends n concurrent probe requests to the endpoint in cfg. If d is
+// non-zero, every request is repeated until d has passed.
+func (r *epLatency) measure(cfg *clientv3.Config, n int, d time.Duration) {
+	cli, err := clientv3.New(*cfg)
+	if err != nil {
+		r.err = err
+		return
+	}
+	defer cli.Close()
+
+	deadline := time.Now().Add(d)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				st := time.Now()
+				_, err := cli.Get(context.TODO(), latencyProbeKey)
+				lat := time.Since(st)
+
+				mu.Lock()
+				if err != nil {
+					r.errs++
+				} else {
+					r.lats = append(r.lats, lat)
+				}
+				mu.Unlock()
+
+				if !time.Now().Before(deadline) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// percentile returns the p-th percentile of the sorted latencies.
+func percentile(lats []time.Duration, p int) time.Duration {
+	return lats[(len(lats)-1)*p/100]
+}
+
+type durationSlice []time.Duration
+
+func (s durationSlice) Len() int           { return len(s) }
+func (s durationSlice) Less(i, j int) bool { return s[i] < s[j] }
+func (s durationSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,72 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var epStatusCluster bool
+
+// NewEpStatusCommand returns the cobra command for "endpoint-status".
+func NewEpStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "endpoint-status",
+		Short: "endpoint-status prints the status of endpoints specified in `--endpoints` flag",
+		Run:   epStatusCommandFunc,
+	}
+	cmd.Flags().BoolVar(&epStatusCluster, "cluster", false, "print the status of every cluster member instead of the given endpoints")
+	return cmd
+}
+
+// epStatusCommandFunc executes the "endpoint-status" command.
+func epStatusCommandFunc(cmd *cobra.Command, args []string) {
+	c := mustClientFromCmd(cmd)
+	eps := c.Endpoints()
+	if epStatusCluster {
+		eps = memberEndpoints(c)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Endpoint", "ID", "Version", "DB Size", "Is Leader", "Raft Term", "Raft Index"})
+	failed := false
+	for _, ep := range eps {
+		resp, err := c.Status(context.TODO(), ep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: failed to get status: %v\n", ep, err)
+			failed = true
+			continue
+		}
+		table.Append([]string{
+			ep,
+			types.ID(resp.Header.MemberId).String(),
+			resp.Version,
+			fmt.Sprint(resp.DbSize),
+			fmt.Sprint(resp.Leader == resp.Header.MemberId),
+			fmt.Sprint(resp.RaftTerm),
+			fmt.Sprint(resp.RaftIndex),
+		})
+	}
+	table.Render()
+	if failed {
+		ExitWithError(ExitError, fmt.Errorf("could not get the status of every endpoint"))
+	}
+}
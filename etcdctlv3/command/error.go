@@ -38,5 +38,6 @@ func ExitWithError(code int, err error) {
 	if cerr, ok := err.(*client.ClusterError); ok {
 		fmt.Fprintln(os.Stderr, cerr.Detail())
 	}
+	StopProfile()
 	os.Exit(code)
 }
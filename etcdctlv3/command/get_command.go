@@ -16,9 +16,14 @@ package command
 
 import (
 	"fmt"
+	"math"
+	"os"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/pkg/types"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
@@ -30,6 +35,16 @@ var (
 	getSortTarget  string
 	getPrefix      bool
 	getFromKey     bool
+	getKeysSince   int64
+	getRevision    string
+	getCount       bool
+
+	getMaxResultsSize string
+	getServerLimit    bool
+
+	getEmptyIndicator string
+	getOutputTemplate string
+	getSchema         string
 )
 
 // NewGetCommand returns the cobra command for "get".
@@ -46,20 +61,138 @@ func NewGetCommand() *cobra.Command {
 	cmd.Flags().Int64Var(&getLimit, "limit", 0, "maximum number of results")
 	cmd.Flags().BoolVar(&getPrefix, "prefix", false, "get keys with matching prefix")
 	cmd.Flags().BoolVar(&getFromKey, "from-key", false, "get keys that are greater than or equal to the given key")
+	cmd.Flags().Int64Var(&getKeysSince, "keys-since-revision", 0, "get only the keys, without values, modified at or after the given revision")
+	cmd.Flags().StringVar(&getRevision, "revision", "", "revision to read the keys at; latest pins the current revision")
+	cmd.Flags().BoolVar(&getCount, "count", false, "print only the number of matching keys")
+	cmd.Flags().StringVar(&getMaxResultsSize, "max-results-size", "", "abort if the keys and values returned exceed this size, e.g. 10KiB")
+	cmd.Flags().BoolVar(&getServerLimit, "server-limit", false, "have the server drop the keys past --max-results-size instead of aborting")
+	cmd.Flags().StringVar(&getEmptyIndicator, "empty-indicator", "", "string to print when no keys match; ignored with json or protobuf output")
+	cmd.Flags().StringVar(&getOutputTemplate, "output-template", "", "Go template to format the list of returned key-value pairs")
+	cmd.Flags().StringVar(&getSchema, "schema", "", "decode values stored as JSON for display; json pretty-prints them, yaml converts them to YAML")
 	return cmd
 }
 
 // getCommandFunc executes the "get" command.
 func getCommandFunc(cmd *cobra.Command, args []string) {
 	key, opts := getGetOp(cmd, args)
-	resp, err := mustClientFromCmd(cmd).Get(context.TODO(), key, opts...)
+
+	var tmpl *template.Template
+	if getOutputTemplate != "" {
+		var err error
+		if tmpl, err = template.New("get").Parse(getOutputTemplate); err != nil {
+			ExitWithError(ExitBadArgs, fmt.Errorf("bad output template (%v)", err))
+		}
+	}
+
+	switch getSchema {
+	case "", schemaJSON, schemaYAML:
+	default:
+		ExitWithError(ExitBadArgs, fmt.Errorf("unknown schema %q, expected %s or %s", getSchema, schemaJSON, schemaYAML))
+	}
+
+	var maxSize int64
+	if getMaxResultsSize != "" {
+		var err error
+		if maxSize, err = parseByteSize(getMaxResultsSize); err != nil || maxSize <= 0 || maxSize > math.MaxInt32 {
+			ExitWithError(ExitBadArgs, fmt.Errorf("bad --max-results-size %q", getMaxResultsSize))
+		}
+	}
+	if getServerLimit {
+		if maxSize == 0 {
+			ExitWithError(ExitBadArgs, fmt.Errorf("--server-limit requires --max-results-size"))
+		}
+		opts = append(opts, clientv3.WithMaxResponseSize(int(maxSize)))
+	}
+
+	c := mustClientFromCmd(cmd)
+	if getRevision == "latest" {
+		opts = append(opts, clientv3.WithRev(currentRevision(c, key)))
+	}
+	resp, err := c.Get(context.TODO(), key, opts...)
 	if err != nil {
 		ExitWithError(ExitError, err)
 	}
+	if getCount {
+		fmt.Fprintln(displayOut, resp.Count)
+		return
+	}
+	if maxSize > 0 {
+		checkResultsSize(resp, maxSize)
+	}
+	if getSchema != "" {
+		for _, kv := range resp.Kvs {
+			kv.Value = decodeValue(getSchema, kv.Value)
+		}
+	}
+	if getConsistency == "l" {
+		warnIfFollower(c, resp.Header.MemberId)
+	}
 
+	// the indicator would corrupt structured output formats
+	if _, ok := display.(*simplePrinter); ok && len(resp.Kvs) == 0 && getEmptyIndicator != "" {
+		fmt.Fprintln(displayOut, getEmptyIndicator)
+		return
+	}
+	if tmpl != nil {
+		if err = tmpl.Execute(displayOut, resp.Kvs); err != nil {
+			ExitWithError(ExitError, err)
+		}
+		return
+	}
 	display.Get(*resp)
 }
 
+// checkResultsSize exits with an error if the keys and values of resp are
+// larger than maxSize bytes. With --server-limit, the server already dropped
+// the keys past the limit, so it warns on stderr that keys are missing.
+func checkResultsSize(resp *clientv3.GetResponse, maxSize int64) {
+	if getServerLimit {
+		// with --limit, More may only mean the key limit was reached
+		if resp.More && (getLimit == 0 || int64(len(resp.Kvs)) < getLimit) {
+			fmt.Fprintf(os.Stderr, "[warning: results truncated to --max-results-size %d bytes]\n", maxSize)
+		}
+		return
+	}
+	var size int64
+	for _, kv := range resp.Kvs {
+		size += int64(kv.Size())
+	}
+	if size > maxSize {
+		ExitWithError(ExitError, fmt.Errorf("results size %d bytes exceeds --max-results-size %d bytes", size, maxSize))
+	}
+}
+
+// warnIfFollower prints a warning to stderr if the member that served a
+// linearizable read is not the leader, which means the read was proxied
+// to the leader. The warning is advisory; if no endpoint reports a leader,
+// nothing is printed.
+func warnIfFollower(c *clientv3.Client, memberID uint64) {
+	for _, ep := range c.Endpoints() {
+		sresp, err := c.Status(context.TODO(), ep)
+		if err != nil {
+			continue
+		}
+		if sresp.Leader != memberID {
+			fmt.Fprintf(os.Stderr, "[warning: read served by follower %s, proxied to leader]\n", types.ID(memberID))
+		}
+		return
+	}
+}
+
+// currentRevision returns the current revision of the store, as seen by a
+// read of key with the flags' consistency.
+func currentRevision(c *clientv3.Client, key string) int64 {
+	opts := []clientv3.OpOption{clientv3.WithLimit(1), clientv3.WithKeysOnly()}
+	if getConsistency == "s" {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+	resp, err := c.Get(context.TODO(), key, opts...)
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	return resp.Header.Revision
+}
+
 func getGetOp(cmd *cobra.Command, args []string) (string, []clientv3.OpOption) {
 	if len(args) == 0 {
 		ExitWithError(ExitBadArgs, fmt.Errorf("range command needs arguments."))
@@ -130,5 +263,25 @@ func getGetOp(cmd *cobra.Command, args []string) (string, []clientv3.OpOption) {
 		opts = append(opts, clientv3.WithFromKey())
 	}
 
+	if getKeysSince < 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("`--keys-since-revision` must not be negative"))
+	}
+	if getKeysSince > 0 {
+		opts = append(opts, clientv3.WithMinModRev(getKeysSince), clientv3.WithKeysOnly())
+	}
+
+	// "latest" is resolved once connected
+	if getRevision != "" && getRevision != "latest" {
+		rev, err := strconv.ParseInt(getRevision, 10, 64)
+		if err != nil || rev <= 0 {
+			ExitWithError(ExitBadArgs, fmt.Errorf("bad revision %q, expected a positive revision or latest", getRevision))
+		}
+		opts = append(opts, clientv3.WithRev(rev))
+	}
+
+	if getCount {
+		opts = append(opts, clientv3.WithCountOnly())
+	}
+
 	return key, opts
 }
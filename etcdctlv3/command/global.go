@@ -16,8 +16,11 @@ package command
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
@@ -35,21 +38,81 @@ type GlobalFlags struct {
 
 	OutputFormat string
 	IsHex        bool
+	BinaryFormat string
+
+	// Profile is "<cpu|mem|trace>,<path>" to profile the command.
+	Profile string
+
+	// ConfigFile is a client config file replacing the connection flags.
+	ConfigFile string
 }
 
 var display printer = &simplePrinter{}
 
 func mustClientFromCmd(cmd *cobra.Command) *clientv3.Client {
+	configFile, err := cmd.Flags().GetString("config-file")
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	if configFile != "" {
+		display = printerFromCmd(cmd)
+		client, err := clientv3.NewFromConfigFile(configFile)
+		if err != nil {
+			ExitWithError(ExitBadConnection, err)
+		}
+		return client
+	}
+
 	endpoints, err := cmd.Flags().GetStringSlice("endpoints")
 	if err != nil {
 		ExitWithError(ExitError, err)
 	}
 	dialTimeout := dialTimeoutFromCmd(cmd)
 	cert, key, cacert := keyAndCertFromCmd(cmd)
+	display = printerFromCmd(cmd)
 
 	return mustClient(endpoints, dialTimeout, cert, key, cacert)
 }
 
+// printerFromCmd returns the printer for the --write-out, --binary-format
+// and --hex flags.
+func printerFromCmd(cmd *cobra.Command) printer {
+	outputType, err := cmd.Flags().GetString("write-out")
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	p := NewPrinter(outputType, binaryFormatFromCmd(cmd))
+	if p == nil {
+		ExitWithError(ExitBadFeature, fmt.Errorf("unsupported output format %q", outputType))
+	}
+	return p
+}
+
+// binaryFormatFromCmd returns the --binary-format flag; --hex is kept as a
+// shorthand for "--binary-format hex".
+func binaryFormatFromCmd(cmd *cobra.Command) string {
+	binFormat, err := cmd.Flags().GetString("binary-format")
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	isHex, err := cmd.Flags().GetBool("hex")
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	if isHex {
+		if cmd.Flags().Changed("binary-format") && binFormat != binaryFormatHex {
+			ExitWithError(ExitBadArgs, fmt.Errorf("`--hex` conflicts with `--binary-format %s`", binFormat))
+		}
+		binFormat = binaryFormatHex
+	}
+	switch binFormat {
+	case binaryFormatRaw, binaryFormatHex, binaryFormatBase64:
+	default:
+		ExitWithError(ExitBadArgs, fmt.Errorf("unknown binary format %q, expected %s, %s or %s", binFormat, binaryFormatRaw, binaryFormatHex, binaryFormatBase64))
+	}
+	return binFormat
+}
+
 func mustClient(endpoints []string, dialTimeout time.Duration, cert, key, cacert string) *clientv3.Client {
 	cfg, err := newClientCfg(endpoints, dialTimeout, cert, key, cacert)
 	if err != nil {
@@ -68,7 +131,6 @@ func newClientCfg(endpoints []string, dialTimeout time.Duration, cert, key, cace
 	// set tls if any one tls option set
 	var cfgtls *transport.TLSInfo
 	tls := transport.TLSInfo{}
-	var file string
 	if cert != "" {
 		tls.CertFile = cert
 		cfgtls = &tls
@@ -79,20 +141,22 @@ func newClientCfg(endpoints []string, dialTimeout time.Duration, cert, key, cace
 		cfgtls = &tls
 	}
 
-	if cacert != "" {
-		tls.CAFile = file
-		cfgtls = &tls
-	}
-
 	cfg := &clientv3.Config{
 		Endpoints:   endpoints,
 		DialTimeout: dialTimeout,
 	}
+	if cacert != "" {
+		// a comma-separated list to trust several CAs, e.g. during rotation
+		cfg.TLSCACerts = strings.Split(cacert, ",")
+	}
 	if cfgtls != nil {
 		clientTLS, err := cfgtls.ClientConfig()
 		if err != nil {
 			return nil, err
 		}
+		if w := cfgtls.CertExpiry(); w != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w.Warn())
+		}
 		cfg.TLS = clientTLS
 	}
 
@@ -17,6 +17,7 @@ package command
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -33,6 +34,9 @@ var (
 	mmkey    string
 	mmcacert string
 	mmprefix string
+
+	mmdestprefix string
+	mmsynconce   bool
 )
 
 // NewMakeMirrorCommand returns the cobra command for "makeMirror".
@@ -44,7 +48,8 @@ func NewMakeMirrorCommand() *cobra.Command {
 	}
 
 	c.Flags().StringVar(&mmprefix, "prefix", "", "the key-value prefix to mirror")
-	// TODO: add dest-prefix to mirror a prefix to a different prefix in the destionation cluster?
+	c.Flags().StringVar(&mmdestprefix, "dest-prefix", "", "destination prefix to mirror a prefix to a different prefix in the destination cluster; defaults to --prefix")
+	c.Flags().BoolVar(&mmsynconce, "sync-once", false, "copy the current key-values to the destination and exit instead of mirroring updates")
 	c.Flags().StringVar(&mmcert, "dest-cert", "", "identify secure client using this TLS certificate file for the destination cluster")
 	c.Flags().StringVar(&mmkey, "dest-key", "", "identify secure client using this TLS key file")
 	c.Flags().StringVar(&mmcacert, "dest-cacert", "", "verify certificates of TLS enabled secure servers using this CA bundle")
@@ -57,33 +62,48 @@ func makeMirrorCommandFunc(cmd *cobra.Command, args []string) {
 		ExitWithError(ExitBadArgs, errors.New("make-mirror takes one destination arguement."))
 	}
 
+	if !cmd.Flags().Changed("dest-prefix") {
+		mmdestprefix = mmprefix
+	}
+
 	dialTimeout := dialTimeoutFromCmd(cmd)
 
 	dc := mustClient([]string{args[0]}, dialTimeout, mmcert, mmkey, mmcacert)
 	c := mustClientFromCmd(cmd)
 
-	err := makeMirror(context.TODO(), c, dc)
-	ExitWithError(ExitError, err)
+	if err := makeMirror(context.TODO(), c, dc); err != nil {
+		ExitWithError(ExitError, err)
+	}
 }
 
 func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) error {
 	total := int64(0)
+	// syncedRev is the source revision the destination is known to be synced to.
+	syncedRev := int64(0)
 
-	go func() {
-		for {
-			time.Sleep(30 * time.Second)
-			fmt.Println(atomic.LoadInt64(&total))
-		}
-	}()
+	resp, err := c.Get(ctx, "foo")
+	if err != nil {
+		return err
+	}
+	baseRev := resp.Header.Revision
+
+	if !mmsynconce {
+		go func() {
+			for {
+				time.Sleep(time.Second)
+				printMirrorStats(ctx, c, atomic.LoadInt64(&total), atomic.LoadInt64(&syncedRev))
+			}
+		}()
+	}
 
 	// TODO: remove the prefix of the destination cluster?
-	s := mirror.NewSyncer(c, mmprefix, 0)
+	s := mirror.NewSyncer(c, mmprefix, baseRev)
 
 	rc, errc := s.SyncBase(ctx)
 
 	for r := range rc {
 		for _, kv := range r.Kvs {
-			_, err := dc.Put(ctx, string(kv.Key), string(kv.Value))
+			_, err := dc.Put(ctx, modifyPrefix(string(kv.Key)), string(kv.Value))
 			if err != nil {
 				return err
 			}
@@ -91,10 +111,16 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 		}
 	}
 
-	err := <-errc
+	err = <-errc
 	if err != nil {
 		return err
 	}
+	atomic.StoreInt64(&syncedRev, baseRev)
+
+	if mmsynconce {
+		fmt.Printf("keys synced: %d\n", atomic.LoadInt64(&total))
+		return nil
+	}
 
 	wc := s.SyncUpdates(ctx)
 
@@ -117,10 +143,10 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 			}
 			switch ev.Type {
 			case storagepb.PUT:
-				ops = append(ops, clientv3.OpPut(string(ev.Kv.Key), string(ev.Kv.Value)))
+				ops = append(ops, clientv3.OpPut(modifyPrefix(string(ev.Kv.Key)), string(ev.Kv.Value)))
 				atomic.AddInt64(&total, 1)
 			case storagepb.DELETE, storagepb.EXPIRE:
-				ops = append(ops, clientv3.OpDelete(string(ev.Kv.Key)))
+				ops = append(ops, clientv3.OpDelete(modifyPrefix(string(ev.Kv.Key))))
 				atomic.AddInt64(&total, 1)
 			default:
 				panic("unexpected event type")
@@ -133,7 +159,33 @@ func makeMirror(ctx context.Context, c *clientv3.Client, dc *clientv3.Client) er
 				return err
 			}
 		}
+		// the header revision may not yet include the events it carries
+		synced := wr.Header.Revision
+		if n := len(wr.Events); n != 0 && wr.Events[n-1].Kv.ModRevision > synced {
+			synced = wr.Events[n-1].Kv.ModRevision
+		}
+		atomic.StoreInt64(&syncedRev, synced)
 	}
 
 	return nil
 }
+
+// modifyPrefix replaces the source prefix of key with the destination prefix.
+func modifyPrefix(key string) string {
+	return mmdestprefix + strings.TrimPrefix(key, mmprefix)
+}
+
+// printMirrorStats prints the number of keys synced so far and how many
+// revisions the source cluster is ahead of the mirrored revision.
+func printMirrorStats(ctx context.Context, c *clientv3.Client, total, rev int64) {
+	resp, err := c.Get(ctx, "foo")
+	if err != nil {
+		fmt.Printf("keys synced: %d, lag: unknown (%v)\n", total, err)
+		return
+	}
+	lag := resp.Header.Revision - rev
+	if lag < 0 {
+		lag = 0
+	}
+	fmt.Printf("keys synced: %d, lag: %d\n", total, lag)
+}
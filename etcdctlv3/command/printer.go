@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -27,6 +28,10 @@ import (
 	"github.com/olekukonko/tablewriter"
 )
 
+// displayOut is where printers write responses. It is stdout unless watch
+// is given --output-file.
+var displayOut io.Writer = os.Stdout
+
 type printer interface {
 	Del(v3.DeleteResponse)
 	Get(v3.GetResponse)
@@ -35,12 +40,14 @@ type printer interface {
 	Watch(v3.WatchResponse)
 
 	MemberList(v3.MemberListResponse)
+
+	AuthStatus(v3.AuthStatusResponse)
 }
 
-func NewPrinter(printerType string, isHex bool) printer {
+func NewPrinter(printerType string, binFormat string) printer {
 	switch printerType {
 	case "simple":
-		return &simplePrinter{isHex: isHex}
+		return &simplePrinter{binFormat: binFormat}
 	case "json":
 		return &jsonPrinter{}
 	case "protobuf":
@@ -50,32 +57,32 @@ func NewPrinter(printerType string, isHex bool) printer {
 }
 
 type simplePrinter struct {
-	isHex bool
+	binFormat string
 }
 
 func (s *simplePrinter) Del(v3.DeleteResponse) {
 	// TODO: add number of key removed into the response of delete.
 	// TODO: print out the number of removed keys.
-	fmt.Println(0)
+	fmt.Fprintln(displayOut, 0)
 }
 
 func (s *simplePrinter) Get(resp v3.GetResponse) {
 	for _, kv := range resp.Kvs {
-		printKV(s.isHex, kv)
+		printKV(s.binFormat, kv)
 	}
 }
 
-func (s *simplePrinter) Put(r v3.PutResponse) { fmt.Println("OK") }
+func (s *simplePrinter) Put(r v3.PutResponse) { fmt.Fprintln(displayOut, "OK") }
 
 func (s *simplePrinter) Txn(resp v3.TxnResponse) {
 	if resp.Succeeded {
-		fmt.Println("SUCCESS")
+		fmt.Fprintln(displayOut, "SUCCESS")
 	} else {
-		fmt.Println("FAILURE")
+		fmt.Fprintln(displayOut, "FAILURE")
 	}
 
 	for _, r := range resp.Responses {
-		fmt.Println("")
+		fmt.Fprintln(displayOut, "")
 		switch v := r.Response.(type) {
 		case *pb.ResponseUnion_ResponseDeleteRange:
 			s.Del((v3.DeleteResponse)(*v.ResponseDeleteRange))
@@ -84,20 +91,20 @@ func (s *simplePrinter) Txn(resp v3.TxnResponse) {
 		case *pb.ResponseUnion_ResponseRange:
 			s.Get(((v3.GetResponse)(*v.ResponseRange)))
 		default:
-			fmt.Printf("unexpected response %+v\n", r)
+			fmt.Fprintf(displayOut, "unexpected response %+v\n", r)
 		}
 	}
 }
 
 func (s *simplePrinter) Watch(resp v3.WatchResponse) {
 	for _, e := range resp.Events {
-		fmt.Println(e.Type)
-		printKV(s.isHex, e.Kv)
+		fmt.Fprintln(displayOut, e.Type)
+		printKV(s.binFormat, e.Kv)
 	}
 }
 
 func (s *simplePrinter) MemberList(resp v3.MemberListResponse) {
-	table := tablewriter.NewWriter(os.Stdout)
+	table := tablewriter.NewWriter(displayOut)
 	table.SetHeader([]string{"ID", "Status", "Name", "Peer Addrs", "Client Addrs", "Is Leader"})
 
 	for _, m := range resp.Members {
@@ -119,6 +126,14 @@ func (s *simplePrinter) MemberList(resp v3.MemberListResponse) {
 	table.Render()
 }
 
+func (s *simplePrinter) AuthStatus(r v3.AuthStatusResponse) {
+	if r.Enabled {
+		fmt.Fprintln(displayOut, "Authentication: enabled")
+	} else {
+		fmt.Fprintln(displayOut, "Authentication: disabled")
+	}
+}
+
 type jsonPrinter struct{}
 
 func (p *jsonPrinter) Del(r v3.DeleteResponse) { printJSON(r) }
@@ -132,13 +147,20 @@ func (p *jsonPrinter) Txn(r v3.TxnResponse)               { printJSON(r) }
 func (p *jsonPrinter) Watch(r v3.WatchResponse)           { printJSON(r) }
 func (p *jsonPrinter) MemberList(r v3.MemberListResponse) { printJSON(r) }
 
+// AuthStatus always prints enabled, which the response omits when false.
+func (p *jsonPrinter) AuthStatus(r v3.AuthStatusResponse) {
+	printJSON(struct {
+		Enabled bool `json:"enabled"`
+	}{r.Enabled})
+}
+
 func printJSON(v interface{}) {
 	b, err := json.Marshal(v)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return
 	}
-	fmt.Println(string(b))
+	fmt.Fprintln(displayOut, string(b))
 }
 
 type pbPrinter struct{}
@@ -173,11 +195,15 @@ func (pb *pbPrinter) MemberList(r v3.MemberListResponse) {
 	ExitWithError(ExitBadFeature, errors.New("only support simple or json as output format"))
 }
 
+func (p *pbPrinter) AuthStatus(r v3.AuthStatusResponse) {
+	printPB((*pb.AuthStatusResponse)(&r))
+}
+
 func printPB(m pbMarshal) {
 	b, err := m.Marshal()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return
 	}
-	fmt.Printf(string(b))
+	displayOut.Write(b)
 }
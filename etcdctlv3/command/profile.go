@@ -0,0 +1,84 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+)
+
+// stopProfile finishes the profile started by StartProfile, if any.
+var stopProfile func()
+
+// StartProfile starts profiling the command as given by spec, which has the
+// form "<kind>,<path>" where kind is cpu, mem or trace. The profile is
+// written to path by StopProfile.
+func StartProfile(spec string) error {
+	fields := strings.SplitN(spec, ",", 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return fmt.Errorf("bad profile %q, want <cpu|mem|trace>,<path>", spec)
+	}
+	kind, path := fields[0], fields[1]
+	if kind != "cpu" && kind != "mem" && kind != "trace" {
+		return fmt.Errorf("unknown profile kind %q, want cpu, mem or trace", kind)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case "cpu":
+		if err = pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return err
+		}
+		stopProfile = func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		}
+	case "mem":
+		stopProfile = func() {
+			// include all allocations up to now in the profile
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not write heap profile (%v)\n", err)
+			}
+			f.Close()
+		}
+	case "trace":
+		if err = trace.Start(f); err != nil {
+			f.Close()
+			return err
+		}
+		stopProfile = func() {
+			trace.Stop()
+			f.Close()
+		}
+	}
+	return nil
+}
+
+// StopProfile writes out the profile started by StartProfile.
+func StopProfile() {
+	if stopProfile != nil {
+		stopProfile()
+		stopProfile = nil
+	}
+}
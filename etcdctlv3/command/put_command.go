@@ -15,9 +15,13 @@
 package command
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/spf13/cobra"
@@ -25,9 +29,19 @@ import (
 )
 
 var (
-	leaseStr string
+	leaseStr         string
+	putExpectVersion int64
+	putIgnoreVal     bool
+	putIgnoreLease   bool
+	putBatch         bool
+	putSync          bool
+	putSyncTimeout   time.Duration
 )
 
+// putBatchSize is the most puts sent in one txn, matching the server's
+// limit on operations per txn.
+const putBatchSize = 128
+
 // NewPutCommand returns the cobra command for "put".
 func NewPutCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -46,21 +60,49 @@ If <value> isn't given as command line arguement, this command tries to read the
 For example,
 $ cat file | put <key>
 will store the content of the file to <key>.
+
+With --batch-from-stdin, no arguments are given and each line of standard
+input is a tab-separated <key> and <value>. Up to 128 lines are written
+per txn; a key repeated within them starts a new txn, so the pairs are
+written in order.
+
+With --sync, put returns once every member has applied the write, so a
+serializable read from any member sees it. It fails if that takes longer
+than --sync-timeout.
 `,
 		Run: putCommandFunc,
 	}
 	cmd.Flags().StringVar(&leaseStr, "lease", "0", "lease ID (in hexadecimal) to attach to the key")
+	cmd.Flags().BoolVar(&putIgnoreVal, "ignore-value", false, "keep the current value of the key and only update its lease")
+	cmd.Flags().BoolVar(&putIgnoreLease, "ignore-lease", false, "keep the current lease of the key and only update its value")
+	cmd.Flags().Int64Var(&putExpectVersion, "expect-version", -1, "only put if the key is at this version; 0 requires the key to not exist, -1 disables the check")
+	cmd.Flags().BoolVar(&putBatch, "batch-from-stdin", false, "put the tab-separated key and value on each line of stdin, in txns of up to 128 puts")
+	cmd.Flags().BoolVar(&putSync, "sync", false, "wait until all members have applied the put")
+	cmd.Flags().DurationVar(&putSyncTimeout, "sync-timeout", 5*time.Second, "how long --sync waits for the members")
 	return cmd
 }
 
 // putCommandFunc executes the "put" command.
 func putCommandFunc(cmd *cobra.Command, args []string) {
+	if putBatch {
+		putBatchCommandFunc(cmd, args)
+		return
+	}
+
 	key, value, opts := getPutOp(cmd, args)
+	if putExpectVersion >= 0 {
+		putWithExpectedVersion(cmd, key, value, opts)
+		return
+	}
 
-	resp, err := mustClientFromCmd(cmd).Put(context.TODO(), key, value, opts...)
+	c := mustClientFromCmd(cmd)
+	resp, err := c.Put(context.TODO(), key, value, opts...)
 	if err != nil {
 		ExitWithError(ExitError, err)
 	}
+	if putSync {
+		waitPutSynced(c, resp.Header.Revision)
+	}
 	display.Put(*resp)
 }
 
@@ -70,20 +112,153 @@ func getPutOp(cmd *cobra.Command, args []string) (string, string, []clientv3.OpO
 	}
 
 	key := args[0]
-	value, err := argOrStdin(args, os.Stdin, 1)
-	if err != nil {
-		ExitWithError(ExitBadArgs, fmt.Errorf("put command needs 1 argument and input from stdin or 2 arguments."))
+	var value string
+	if putIgnoreVal {
+		if len(args) != 1 {
+			ExitWithError(ExitBadArgs, fmt.Errorf("put command takes no value with --ignore-value."))
+		}
+	} else {
+		var err error
+		if value, err = argOrStdin(args, os.Stdin, 1); err != nil {
+			ExitWithError(ExitBadArgs, fmt.Errorf("put command needs 1 argument and input from stdin or 2 arguments."))
+		}
 	}
 
+	opts := putLeaseOpts()
+	if putIgnoreVal {
+		opts = append(opts, clientv3.WithIgnoreValue())
+	}
+
+	return key, value, opts
+}
+
+// putLeaseOpts returns the options for the --lease and --ignore-lease flags.
+func putLeaseOpts() []clientv3.OpOption {
 	id, err := strconv.ParseInt(leaseStr, 16, 64)
 	if err != nil {
 		ExitWithError(ExitBadArgs, fmt.Errorf("bad lease ID (%v), expecting ID in Hex", err))
 	}
 
+	if putIgnoreLease && id != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("put command takes no lease with --ignore-lease."))
+	}
+
 	opts := []clientv3.OpOption{}
 	if id != 0 {
 		opts = append(opts, clientv3.WithLease(clientv3.LeaseID(id)))
 	}
+	if putIgnoreLease {
+		opts = append(opts, clientv3.WithKeepLease())
+	}
+	return opts
+}
 
-	return key, value, opts
+// putBatchCommandFunc executes "put --batch-from-stdin".
+func putBatchCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("put command takes no arguments with --batch-from-stdin."))
+	}
+	if putIgnoreVal || putExpectVersion >= 0 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("`--batch-from-stdin` cannot be used with `--ignore-value` or `--expect-version`."))
+	}
+	opts := putLeaseOpts()
+
+	// parse all pairs before connecting so bad input fails fast; a txn
+	// may not put the same key twice, so a repeated key starts a new txn
+	var (
+		batches [][]clientv3.Op
+		batch   []clientv3.Op
+		keys    = make(map[string]struct{})
+		n       int
+	)
+	r := bufio.NewReader(os.Stdin)
+	for line := 1; ; line++ {
+		s, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			ExitWithError(ExitInvalidInput, fmt.Errorf("line %d: %v", line, err))
+		}
+		if s = strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r"); s != "" {
+			kv := strings.SplitN(s, "\t", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				ExitWithError(ExitInvalidInput, fmt.Errorf("line %d: expected <key>\\t<value>, got %q", line, s))
+			}
+			if _, ok := keys[kv[0]]; ok || len(batch) == putBatchSize {
+				batches = append(batches, batch)
+				batch, keys = nil, make(map[string]struct{})
+			}
+			keys[kv[0]] = struct{}{}
+			batch = append(batch, clientv3.OpPut(kv[0], kv[1], opts...))
+			n++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	c := mustClientFromCmd(cmd)
+	var rev int64
+	written := 0
+	for _, ops := range batches {
+		resp, err := c.Txn(context.TODO()).Then(ops...).Commit()
+		if err != nil {
+			ExitWithError(ExitError, fmt.Errorf("wrote %d of %d pairs (%v)", written, n, err))
+		}
+		written += len(ops)
+		rev = resp.Header.Revision
+	}
+	if putSync && rev > 0 {
+		waitPutSynced(c, rev)
+	}
+	fmt.Println(n)
+}
+
+// putWithExpectedVersion puts the key in a txn guarded by the key's version,
+// so the write is rejected if the key was changed in the meantime.
+func putWithExpectedVersion(cmd *cobra.Command, key, value string, opts []clientv3.OpOption) {
+	c := mustClientFromCmd(cmd)
+	resp, err := c.Txn(context.TODO()).
+		If(clientv3.Compare(clientv3.Version(key), "=", putExpectVersion)).
+		Then(clientv3.OpPut(key, value, opts...)).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+	if !resp.Succeeded {
+		var ver int64
+		if kvs := resp.Responses[0].GetResponseRange().Kvs; len(kvs) != 0 {
+			ver = kvs[0].Version
+		}
+		ExitWithError(ExitError, fmt.Errorf("put rejected: key %q is at version %d, expected %d", key, ver, putExpectVersion))
+	}
+	if putSync {
+		waitPutSynced(c, resp.Header.Revision)
+	}
+	display.Put((clientv3.PutResponse)(*resp.Responses[0].GetResponsePut()))
+}
+
+// putSyncInterval is how often --sync polls the status of the members.
+const putSyncInterval = 50 * time.Millisecond
+
+// waitPutSynced waits until every member of the cluster has applied the
+// revision rev, or exits once --sync-timeout has passed.
+func waitPutSynced(c *clientv3.Client, rev int64) {
+	ctx, cancel := context.WithTimeout(context.TODO(), putSyncTimeout)
+	defer cancel()
+	for _, ep := range memberEndpoints(c) {
+		for {
+			resp, err := c.Status(ctx, ep)
+			if err == nil && resp.Header.Revision >= rev {
+				break
+			}
+			select {
+			case <-time.After(putSyncInterval):
+			case <-ctx.Done():
+				ExitWithError(ExitError, fmt.Errorf("put is written, but %s did not apply revision %d within %v", ep, rev, putSyncTimeout))
+			}
+		}
+	}
 }
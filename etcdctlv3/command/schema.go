@@ -0,0 +1,155 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	schemaJSON = "json"
+	schemaYAML = "yaml"
+)
+
+// decodeValue formats a value stored as JSON according to schema. A value
+// that is not valid JSON is returned unchanged.
+func decodeValue(schema string, v []byte) []byte {
+	switch schema {
+	case schemaJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, v, "", "  "); err != nil {
+			return v
+		}
+		return buf.Bytes()
+	case schemaYAML:
+		d := json.NewDecoder(bytes.NewReader(v))
+		d.UseNumber()
+		var i interface{}
+		if err := d.Decode(&i); err != nil || d.More() {
+			return v
+		}
+		var buf bytes.Buffer
+		writeYAML(&buf, i, 0, false)
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	}
+	return v
+}
+
+// writeYAML writes a value decoded from JSON as block-style YAML, indented
+// by indent spaces. If inline is set, the first line continues the current
+// one, as after "- " in a sequence.
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int, inline bool) {
+	pad := strings.Repeat(" ", indent)
+	first := true
+	prefix := func() string {
+		if first && inline {
+			first = false
+			return ""
+		}
+		return pad
+	}
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if len(vv) == 0 {
+			buf.WriteString(prefix() + "{}\n")
+			return
+		}
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf.WriteString(prefix() + yamlScalar(k) + ":")
+			if isYAMLBlock(vv[k]) {
+				buf.WriteString("\n")
+				writeYAML(buf, vv[k], indent+2, false)
+			} else {
+				buf.WriteString(" ")
+				writeYAML(buf, vv[k], 0, true)
+			}
+		}
+	case []interface{}:
+		if len(vv) == 0 {
+			buf.WriteString(prefix() + "[]\n")
+			return
+		}
+		for _, e := range vv {
+			buf.WriteString(prefix() + "- ")
+			writeYAML(buf, e, indent+2, true)
+		}
+	default:
+		buf.WriteString(prefix() + yamlScalar(v) + "\n")
+	}
+}
+
+// isYAMLBlock returns true if v is written on lines of its own after a
+// mapping key.
+func isYAMLBlock(v interface{}) bool {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return len(vv) > 0
+	case []interface{}:
+		return len(vv) > 0
+	}
+	return false
+}
+
+func yamlScalar(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(vv)
+	case json.Number:
+		return vv.String()
+	case string:
+		if yamlNeedsQuote(vv) {
+			return strconv.Quote(vv)
+		}
+		return vv
+	}
+	return fmt.Sprint(v)
+}
+
+// yamlNeedsQuote returns true if s would not read back as the same plain
+// YAML string.
+func yamlNeedsQuote(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r > '~' {
+			return true
+		}
+	}
+	return strings.Contains(s, ": ") || strings.Contains(s, " #")
+}
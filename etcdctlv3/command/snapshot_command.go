@@ -15,24 +15,44 @@
 package command
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/mirror"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/storage/storagepb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
 
+// snapshotPageSize is the number of keys fetched per range request while
+// writing out the base revision of a snapshot.
+const snapshotPageSize = 1000
+
 // NewSnapshotCommand returns the cobra command for "snapshot".
 func NewSnapshotCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "snapshot [filename]",
 		Short: "Snapshot streams a point-in-time snapshot of the store",
 		Run:   snapshotCommandFunc,
 	}
+	cmd.AddCommand(NewSnapshotDiffCommand())
+	return cmd
+}
+
+// NewSnapshotDiffCommand returns the cobra command for "snapshot diff".
+func NewSnapshotDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <snapshot1> <snapshot2>",
+		Short: "diff prints the keys added, deleted, and changed between two snapshot files",
+		Run:   snapshotDiffCommandFunc,
+	}
 }
 
 // snapshotCommandFunc watches for the length of the entire store and records
@@ -90,16 +110,10 @@ func snapshotToFile(c *clientv3.Client, path string) {
 func snapshot(w io.Writer, c *clientv3.Client, rev int64) int64 {
 	s := mirror.NewSyncer(c, "", rev)
 
-	rc, errc := s.SyncBase(context.TODO())
-
-	for r := range rc {
-		for _, kv := range r.Kvs {
-			fmt.Fprintln(w, kv)
-		}
-	}
-
-	err := <-errc
-	if err != nil {
+	// page through the base revision here instead of using SyncBase, which
+	// reads ahead into a large buffered channel; each page is written out
+	// before the next one is fetched so memory stays bounded by the page size
+	if err := snapshotBase(w, c, rev); err != nil {
 		if err == rpctypes.ErrCompacted {
 			// will get correct compact revision on retry
 			return rev + 1
@@ -125,3 +139,119 @@ func snapshot(w io.Writer, c *clientv3.Client, rev int64) int64 {
 
 	return 0
 }
+
+// snapshotBase writes every key-value at revision rev to w, one page at a time.
+func snapshotBase(w io.Writer, c *clientv3.Client, rev int64) error {
+	key := "\x00"
+	opts := []clientv3.OpOption{
+		clientv3.WithFromKey(),
+		clientv3.WithRev(rev),
+		clientv3.WithLimit(snapshotPageSize),
+	}
+	for {
+		resp, err := c.Get(context.TODO(), key, opts...)
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			fmt.Fprintln(w, kv)
+		}
+		if !resp.More {
+			return nil
+		}
+		key = string(append(resp.Kvs[len(resp.Kvs)-1].Key, 0))
+	}
+}
+
+// snapshotDiffCommandFunc executes the "snapshot diff" command.
+func snapshotDiffCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("snapshot diff command needs two snapshot files as arguments"))
+	}
+
+	before, err := loadSnapshot(args[0])
+	if err != nil {
+		ExitWithError(ExitIO, err)
+	}
+	after, err := loadSnapshot(args[1])
+	if err != nil {
+		ExitWithError(ExitIO, err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	printSnapshotDiff(w, before, after)
+	w.Flush()
+}
+
+// loadSnapshot replays a snapshot file written by the snapshot command and
+// returns the resulting key-value pairs. The file holds the base key-values
+// followed by the events needed to reach a consistent revision.
+func loadSnapshot(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s (%v)", path, err)
+	}
+	defer f.Close()
+
+	kvs := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	// a single line holds a whole key-value; allow large values
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		l := sc.Text()
+		if len(l) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(l, "key:") {
+			var ev storagepb.Event
+			if err := proto.UnmarshalText(l, &ev); err != nil || ev.Kv == nil {
+				return nil, fmt.Errorf("%s:%d: bad snapshot event (%v)", path, n, err)
+			}
+			switch ev.Type {
+			case storagepb.PUT:
+				kvs[string(ev.Kv.Key)] = string(ev.Kv.Value)
+			case storagepb.DELETE, storagepb.EXPIRE:
+				delete(kvs, string(ev.Kv.Key))
+			}
+			continue
+		}
+		var kv storagepb.KeyValue
+		if err := proto.UnmarshalText(l, &kv); err != nil {
+			return nil, fmt.Errorf("%s:%d: bad snapshot key-value (%v)", path, n, err)
+		}
+		kvs[string(kv.Key)] = string(kv.Value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s (%v)", path, err)
+	}
+	return kvs, nil
+}
+
+// printSnapshotDiff writes the difference between two snapshots in key order:
+// "+key=value" for added keys, "-key=value" for deleted keys, and
+// "~key old=value new=value" for changed keys.
+func printSnapshotDiff(w io.Writer, before, after map[string]string) {
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		ov, inBefore := before[k]
+		nv, inAfter := after[k]
+		switch {
+		case !inBefore:
+			fmt.Fprintf(w, "+%s=%s\n", k, nv)
+		case !inAfter:
+			fmt.Fprintf(w, "-%s=%s\n", k, ov)
+		case ov != nv:
+			fmt.Fprintf(w, "~%s old=%s new=%s\n", k, ov, nv)
+		}
+	}
+}
@@ -47,17 +47,18 @@ func txnCommandFunc(cmd *cobra.Command, args []string) {
 		ExitWithError(ExitBadArgs, fmt.Errorf("txn command does not accept argument."))
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := &txnReader{r: bufio.NewReader(os.Stdin)}
 
-	txn := mustClientFromCmd(cmd).Txn(context.Background())
+	// parse the whole txn before connecting so bad input fails fast
 	promptInteractive("compares:")
-	txn.If(readCompares(reader)...)
+	cmps := readCompares(reader)
 	promptInteractive("success requests (get, put, delete):")
-	txn.Then(readOps(reader)...)
+	thenOps := readOps(reader)
 	promptInteractive("failure requests (get, put, delete):")
-	txn.Else(readOps(reader)...)
+	elseOps := readOps(reader)
 
-	resp, err := txn.Commit()
+	txn := mustClientFromCmd(cmd).Txn(context.Background())
+	resp, err := txn.If(cmps...).Then(thenOps...).Else(elseOps...).Commit()
 	if err != nil {
 		ExitWithError(ExitError, err)
 	}
@@ -71,21 +72,41 @@ func promptInteractive(s string) {
 	}
 }
 
-func readCompares(r *bufio.Reader) (cmps []clientv3.Cmp) {
+// txnReader reads the txn input line by line, counting lines so errors
+// can point at the offending one.
+type txnReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// next returns the next line without its trailing newline, or false on
+// the empty line that ends a section.
+func (tr *txnReader) next() (string, bool) {
+	line, err := tr.r.ReadString('\n')
+	tr.line++
+	if err != nil {
+		ExitWithError(ExitInvalidInput, fmt.Errorf("line %d: %v", tr.line, err))
+	}
+	if len(line) == 1 {
+		return "", false
+	}
+	// remove trialling \n
+	return line[:len(line)-1], true
+}
+
+func (tr *txnReader) fail(err error) {
+	ExitWithError(ExitInvalidInput, fmt.Errorf("line %d: %v", tr.line, err))
+}
+
+func readCompares(r *txnReader) (cmps []clientv3.Cmp) {
 	for {
-		line, err := r.ReadString('\n')
-		if err != nil {
-			ExitWithError(ExitInvalidInput, err)
-		}
-		if len(line) == 1 {
+		line, ok := r.next()
+		if !ok {
 			break
 		}
-
-		// remove trialling \n
-		line = line[:len(line)-1]
 		cmp, err := parseCompare(line)
 		if err != nil {
-			ExitWithError(ExitInvalidInput, err)
+			r.fail(err)
 		}
 		cmps = append(cmps, *cmp)
 	}
@@ -93,21 +114,15 @@ func readCompares(r *bufio.Reader) (cmps []clientv3.Cmp) {
 	return cmps
 }
 
-func readOps(r *bufio.Reader) (ops []clientv3.Op) {
+func readOps(r *txnReader) (ops []clientv3.Op) {
 	for {
-		line, err := r.ReadString('\n')
-		if err != nil {
-			ExitWithError(ExitInvalidInput, err)
-		}
-		if len(line) == 1 {
+		line, ok := r.next()
+		if !ok {
 			break
 		}
-
-		// remove trialling \n
-		line = line[:len(line)-1]
 		op, err := parseRequestUnion(line)
 		if err != nil {
-			ExitWithError(ExitInvalidInput, err)
+			r.fail(err)
 		}
 		ops = append(ops, *op)
 	}
@@ -118,7 +133,16 @@ func readOps(r *bufio.Reader) (ops []clientv3.Op) {
 func parseRequestUnion(line string) (*clientv3.Op, error) {
 	args := argify(line)
 	if len(args) < 2 {
-		return nil, fmt.Errorf("invalid txn compare request: %s", line)
+		return nil, fmt.Errorf("invalid txn request: %s (expected get, put or del followed by its arguments)", line)
+	}
+	switch args[0] {
+	case "get", "del":
+	case "put":
+		if len(args) < 3 {
+			return nil, fmt.Errorf("invalid txn request: %s (expected put <key> <value>)", line)
+		}
+	default:
+		return nil, fmt.Errorf("invalid txn request: %s (unknown request %q, expected get, put or del)", line, args[0])
 	}
 
 	opc := make(chan clientv3.Op, 1)
@@ -159,17 +183,27 @@ func parseCompare(line string) (*clientv3.Cmp, error) {
 
 	lparenSplit := strings.SplitN(line, "(", 2)
 	if len(lparenSplit) != 2 {
-		return nil, fmt.Errorf("malformed comparison: %s", line)
+		return nil, fmt.Errorf("malformed comparison: %s (expected <target>(\"<key>\") <op> \"<value>\")", line)
 	}
 
 	target := lparenSplit[0]
+	switch target {
+	case "ver", "version", "c", "create", "m", "mod", "val", "value":
+	default:
+		return nil, fmt.Errorf("malformed comparison: %s (unknown target %q, expected ver, create, mod or val)", line, target)
+	}
 	n, serr := fmt.Sscanf(lparenSplit[1], "%q) %s %q", &key, &op, &val)
 	if n != 3 {
-		return nil, fmt.Errorf("malformed comparison: %s; got %s(%q) %s %q", line, target, key, op, val)
+		return nil, fmt.Errorf("malformed comparison: %s; got %s(%q) %s %q (expected <target>(\"<key>\") <op> \"<value>\")", line, target, key, op, val)
 	}
 	if serr != nil {
 		return nil, fmt.Errorf("malformed comparison: %s (%v)", line, serr)
 	}
+	switch op {
+	case "=", ">", "<":
+	default:
+		return nil, fmt.Errorf("malformed comparison: %s (unknown operator %q, expected =, > or <)", line, op)
+	}
 
 	var (
 		v   int64
@@ -191,12 +225,10 @@ func parseCompare(line string) (*clientv3.Cmp, error) {
 		}
 	case "val", "value":
 		cmp = clientv3.Compare(clientv3.Value(key), op, val)
-	default:
-		return nil, fmt.Errorf("malformed comparison: %s (unknown target %s)", line, target)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid txn compare request: %s", line)
+		return nil, fmt.Errorf("invalid txn compare request: %s (expected an integer %s, got %q)", line, target, val)
 	}
 
 	return &cmp, nil
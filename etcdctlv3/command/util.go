@@ -15,21 +15,68 @@
 package command
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"math"
+	"net/url"
+	"os"
 	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/pkg/types"
 	pb "github.com/coreos/etcd/storage/storagepb"
+	"golang.org/x/net/context"
 )
 
-func printKV(isHex bool, kv *pb.KeyValue) {
+const (
+	binaryFormatRaw    = "raw"
+	binaryFormatHex    = "hex"
+	binaryFormatBase64 = "base64"
+)
+
+func printKV(binFormat string, kv *pb.KeyValue) {
 	k, v := string(kv.Key), string(kv.Value)
-	if isHex {
+	switch binFormat {
+	case binaryFormatHex:
 		k = addHexPrefix(hex.EncodeToString(kv.Key))
 		v = addHexPrefix(hex.EncodeToString(kv.Value))
+	case binaryFormatBase64:
+		// values that are not base64 are printed as is
+		if dv, err := base64.StdEncoding.DecodeString(v); err == nil {
+			v = string(dv)
+		}
+	}
+	fmt.Fprintln(displayOut, k)
+	fmt.Fprintln(displayOut, v)
+}
+
+// memberEndpoints returns the first client URL host of every cluster
+// member, skipping members without client URLs.
+func memberEndpoints(c *clientv3.Client) []string {
+	mresp, err := c.MemberList(context.TODO())
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	var eps []string
+	for _, m := range mresp.Members {
+		if len(m.ClientURLs) == 0 {
+			fmt.Fprintf(os.Stderr, "member %s has no client URLs, skipping\n", types.ID(m.ID))
+			continue
+		}
+		u, err := url.Parse(m.ClientURLs[0])
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		eps = append(eps, u.Host)
 	}
-	fmt.Println(k)
-	fmt.Println(v)
+	if len(eps) == 0 {
+		ExitWithError(ExitError, fmt.Errorf("no cluster members with client URLs"))
+	}
+	return eps
 }
 
 func addHexPrefix(s string) string {
@@ -47,3 +94,29 @@ func argify(s string) []string {
 	r := regexp.MustCompile("'.+'|\".+\"|\\S+")
 	return r.FindAllString(s, -1)
 }
+
+// byteSizeUnits are the units of parseByteSize.
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+}
+
+// parseByteSize parses a size in bytes such as "4096", "10KiB" or "1MB".
+func parseByteSize(s string) (int64, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	unit, ok := byteSizeUnits[strings.ToUpper(strings.TrimSpace(s[i:]))]
+	if err != nil || !ok || n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("bad size %q, expected e.g. 4096, 10KiB or 1MB", s)
+	}
+	return n * unit, nil
+}
@@ -19,16 +19,24 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/storage/storagepb"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 )
 
 var (
-	watchRev         int64
-	watchPrefix      bool
-	watchInteractive bool
+	watchRev            int64
+	watchPrefix         bool
+	watchInteractive    bool
+	watchMultiLineValue bool
+	watchFragmentSize   int
+	watchDiff           bool
+
+	watchOutputFile        string
+	watchMaxOutputFileSize int64
 )
 
 // NewWatchCommand returns the cobra command for "watch".
@@ -42,12 +50,25 @@ func NewWatchCommand() *cobra.Command {
 	cmd.Flags().BoolVarP(&watchInteractive, "interactive", "i", false, "interactive mode")
 	cmd.Flags().BoolVar(&watchPrefix, "prefix", false, "watch on a prefix if prefix is set")
 	cmd.Flags().Int64Var(&watchRev, "rev", 0, "revision to start watching")
+	cmd.Flags().BoolVar(&watchMultiLineValue, "multi-line-value", false, "accept a heredoc-style '<<EOF ... EOF' block as the key in interactive mode")
+	cmd.Flags().IntVar(&watchFragmentSize, "fragment-size", 0, "split watch responses larger than this many bytes into fragments on the server; 0 disables fragmentation")
+	cmd.Flags().BoolVar(&watchDiff, "diff", false, "print the changed lines of each put as a unified diff against the previous value of the key")
+	cmd.Flags().StringVar(&watchOutputFile, "output-file", "", "append events to this file instead of printing them to stdout")
+	cmd.Flags().Int64Var(&watchMaxOutputFileSize, "max-output-file-size", 0, "rename the output file to <output-file>.1 and start a new one once it grows past this many bytes; 0 never rotates")
 
 	return cmd
 }
 
 // watchCommandFunc executes the "watch" command.
 func watchCommandFunc(cmd *cobra.Command, args []string) {
+	if watchOutputFile != "" {
+		of, err := openOutputFile(watchOutputFile, watchMaxOutputFileSize)
+		if err != nil {
+			ExitWithError(ExitError, err)
+		}
+		displayOut = of
+	}
+
 	if watchInteractive {
 		watchInteractiveFunc(cmd, args)
 		return
@@ -57,13 +78,13 @@ func watchCommandFunc(cmd *cobra.Command, args []string) {
 		ExitWithError(ExitBadArgs, fmt.Errorf("watch in non-interactive mode requires an argument as key or prefix"))
 	}
 
-	opts := []clientv3.OpOption{clientv3.WithRev(watchRev)}
-	if watchPrefix {
-		opts = append(opts, clientv3.WithPrefix())
-	}
 	c := mustClientFromCmd(cmd)
-	wc := c.Watch(context.TODO(), args[0], opts...)
-	printWatchCh(wc)
+	wc := c.Watch(context.TODO(), args[0], getWatchOpts()...)
+	if watchDiff {
+		printWatchDiff(c, wc)
+	} else {
+		printWatchCh(wc)
+	}
 	err := c.Close()
 	if err == nil {
 		ExitWithError(ExitInterrupted, fmt.Errorf("watch is canceled by the server"))
@@ -74,6 +95,10 @@ func watchCommandFunc(cmd *cobra.Command, args []string) {
 func watchInteractiveFunc(cmd *cobra.Command, args []string) {
 	c := mustClientFromCmd(cmd)
 
+	// parsing each request line below resets the flag variables, so
+	// remember whether heredoc input was requested on the command line.
+	multiLine := watchMultiLineValue
+
 	reader := bufio.NewReader(os.Stdin)
 
 	for {
@@ -83,7 +108,17 @@ func watchInteractiveFunc(cmd *cobra.Command, args []string) {
 		}
 		l = strings.TrimSuffix(l, "\n")
 
+		var heredoc *string
+		if multiLine {
+			if l, heredoc, err = readHeredoc(reader, l); err != nil {
+				ExitWithError(ExitInvalidInput, fmt.Errorf("Error reading watch request heredoc: %v", err))
+			}
+		}
+
 		args := argify(l)
+		if heredoc != nil {
+			args = append(args, *heredoc)
+		}
 		if len(args) < 2 {
 			fmt.Fprintf(os.Stderr, "Invalid command %s (command type or key is not provided)\n", l)
 			continue
@@ -106,21 +141,173 @@ func watchInteractiveFunc(cmd *cobra.Command, args []string) {
 			continue
 		}
 		var key string
-		_, err = fmt.Sscanf(moreargs[0], "%q", &key)
-		if err != nil {
+		if heredoc != nil {
+			key = *heredoc
+		} else if _, err = fmt.Sscanf(moreargs[0], "%q", &key); err != nil {
 			key = moreargs[0]
 		}
-		opts := []clientv3.OpOption{clientv3.WithRev(watchRev)}
-		if watchPrefix {
-			opts = append(opts, clientv3.WithPrefix())
+		ch := c.Watch(context.TODO(), key, getWatchOpts()...)
+		if watchDiff {
+			go printWatchDiff(c, ch)
+		} else {
+			go printWatchCh(ch)
+		}
+	}
+}
+
+// getWatchOpts returns the watch options given by the flags.
+func getWatchOpts() []clientv3.OpOption {
+	opts := []clientv3.OpOption{clientv3.WithRev(watchRev)}
+	if watchPrefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	if watchFragmentSize > 0 {
+		// fragments are reassembled by the client before they are printed
+		opts = append(opts, clientv3.WithFragmentSize(watchFragmentSize))
+	}
+	return opts
+}
+
+// readHeredoc checks whether the request line l ends with a heredoc
+// marker ("<<DELIM"). If so, it reads the following lines from reader
+// until a line equal to DELIM and returns the request line without the
+// marker together with the joined block. Otherwise l is returned as is.
+func readHeredoc(reader *bufio.Reader, l string) (string, *string, error) {
+	i := strings.LastIndex(l, "<<")
+	if i < 0 {
+		return l, nil, nil
+	}
+	delim := strings.TrimSpace(l[i+2:])
+	if len(delim) == 0 || strings.ContainsAny(delim, " \t") {
+		return l, nil, nil
+	}
+
+	lines := []string{}
+	for {
+		bl, err := reader.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		bl = strings.TrimSuffix(bl, "\n")
+		if bl == delim {
+			break
 		}
-		ch := c.Watch(context.TODO(), key, opts...)
-		go printWatchCh(ch)
+		lines = append(lines, bl)
 	}
+	body := strings.Join(lines, "\n")
+	return strings.TrimSpace(l[:i]), &body, nil
 }
 
 func printWatchCh(ch clientv3.WatchChan) {
 	for resp := range ch {
+		if of, ok := displayOut.(*outputFile); ok {
+			of.print(func() { display.Watch(resp) })
+			continue
+		}
 		display.Watch(resp)
 	}
 }
+
+// printWatchDiff prints the events of ch with the value of each put
+// replaced by its diff against the previous value of the key. The first
+// time a key is seen, its previous value is read at the revision before
+// the put.
+func printWatchDiff(c *clientv3.Client, ch clientv3.WatchChan) {
+	prev := make(map[string][]byte)
+	for resp := range ch {
+		for _, e := range resp.Events {
+			key := string(e.Kv.Key)
+			if e.Type == storagepb.DELETE {
+				delete(prev, key)
+				printOutput(fmt.Sprintf("%s\n%s\n", e.Type, key))
+				continue
+			}
+			old, ok := prev[key]
+			if !ok && e.Kv.Version > 1 {
+				gresp, err := c.Get(context.TODO(), key, clientv3.WithRev(e.Kv.ModRevision-1))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "cannot read the previous value of %q (%v)\n", key, err)
+				} else if len(gresp.Kvs) != 0 {
+					old = gresp.Kvs[0].Value
+				}
+			}
+			prev[key] = e.Kv.Value
+
+			lines := append([]string{e.Type.String(), key}, valueDiff(old, e.Kv.Value)...)
+			printOutput(strings.Join(lines, "\n") + "\n")
+		}
+	}
+}
+
+// printOutput writes s to the display output, rotating the output file
+// if needed.
+func printOutput(s string) {
+	if of, ok := displayOut.(*outputFile); ok {
+		of.print(func() { fmt.Fprint(displayOut, s) })
+		return
+	}
+	fmt.Fprint(displayOut, s)
+}
+
+// outputFile appends to a file opened in sync mode. Once the file grows
+// past maxSize bytes, it is renamed to "<path>.1", replacing the previous
+// one, and a new file is started.
+type outputFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+func openOutputFile(path string, maxSize int64) (*outputFile, error) {
+	of := &outputFile{path: path, maxSize: maxSize}
+	if err := of.open(); err != nil {
+		return nil, err
+	}
+	return of, nil
+}
+
+func (of *outputFile) open() error {
+	f, err := os.OpenFile(of.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_SYNC, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	of.f, of.size = f, fi.Size()
+	return nil
+}
+
+// print runs f, which writes one watch response, rotating the file first
+// if it is full so a response is never split across files.
+func (of *outputFile) print(f func()) {
+	of.mu.Lock()
+	defer of.mu.Unlock()
+	if of.maxSize > 0 && of.size >= of.maxSize {
+		if err := of.rotate(); err != nil {
+			ExitWithError(ExitError, fmt.Errorf("failed to rotate output file %s (%v)", of.path, err))
+		}
+	}
+	f()
+}
+
+func (of *outputFile) rotate() error {
+	if err := of.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(of.path, of.path+".1"); err != nil {
+		return err
+	}
+	return of.open()
+}
+
+// Write must be called by the function given to print.
+func (of *outputFile) Write(p []byte) (int, error) {
+	n, err := of.f.Write(p)
+	of.size += int64(n)
+	return n, err
+}
@@ -40,6 +40,14 @@ var (
 		Use:        cliName,
 		Short:      cliDescription,
 		SuggestFor: []string{"etcctlv3", "etcdcltv3", "etlctlv3"},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if globalFlags.Profile == "" {
+				return
+			}
+			if err := command.StartProfile(globalFlags.Profile); err != nil {
+				command.ExitWithError(command.ExitBadArgs, err)
+			}
+		},
 	}
 )
 
@@ -47,13 +55,16 @@ func init() {
 	rootCmd.PersistentFlags().StringSliceVar(&globalFlags.Endpoints, "endpoints", []string{"127.0.0.1:2379", "127.0.0.1:22379", "127.0.0.1:32379"}, "gRPC endpoints")
 
 	rootCmd.PersistentFlags().StringVarP(&globalFlags.OutputFormat, "write-out", "w", "simple", "set the output format (simple, json, protobuf)")
-	rootCmd.PersistentFlags().BoolVar(&globalFlags.IsHex, "hex", false, "print byte strings as hex encoded strings")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.IsHex, "hex", false, "print byte strings as hex encoded strings; same as --binary-format hex")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.BinaryFormat, "binary-format", "raw", "how to print keys and values (raw, hex, base64); base64 decodes values stored as base64")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.Profile, "profile", "", "write a profile of the command: cpu,<path>, mem,<path> or trace,<path>")
 
-	rootCmd.PersistentFlags().DurationVar(&globalFlags.DialTimeout, "dial-timeout", defaultDialTimeout, "dial timeout for client connections")
+	rootCmd.PersistentFlags().DurationVar(&globalFlags.DialTimeout, "dial-timeout", defaultDialTimeout, "dial timeout for client connections; 0 uses the client default and a negative value waits forever")
 
 	rootCmd.PersistentFlags().StringVar(&globalFlags.TLS.CertFile, "cert", "", "identify secure client using this TLS certificate file")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.TLS.KeyFile, "key", "", "identify secure client using this TLS key file")
-	rootCmd.PersistentFlags().StringVar(&globalFlags.TLS.CAFile, "cacert", "", "verify certificates of TLS-enabled secure servers using this CA bundle")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.TLS.CAFile, "cacert", "", "verify certificates of TLS-enabled secure servers using this CA bundle; a comma-separated list trusts several CAs")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.ConfigFile, "config-file", "", "read the endpoints, dial timeout and TLS files from a YAML client config file instead of their flags")
 
 	rootCmd.AddCommand(
 		command.NewGetCommand(),
@@ -67,6 +78,9 @@ func init() {
 		command.NewLeaseCommand(),
 		command.NewMemberCommand(),
 		command.NewEpHealthCommand(),
+		command.NewEpLatencyCommand(),
+		command.NewEpHashKVCommand(),
+		command.NewEpStatusCommand(),
 		command.NewSnapshotCommand(),
 		command.NewMakeMirrorCommand(),
 		command.NewLockCommand(),
@@ -88,4 +102,5 @@ func main() {
 	if err := rootCmd.Execute(); err != nil {
 		command.ExitWithError(command.ExitError, err)
 	}
+	command.StopProfile()
 }
@@ -19,15 +19,18 @@ package etcdmain
 import (
 	"flag"
 	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/coreos/etcd/etcdserver"
 	"github.com/coreos/etcd/pkg/cors"
 	"github.com/coreos/etcd/pkg/flags"
 	"github.com/coreos/etcd/pkg/transport"
+	"github.com/coreos/etcd/storage"
 	"github.com/coreos/etcd/version"
 )
 
@@ -79,11 +82,19 @@ type config struct {
 	corsInfo       *cors.CORSInfo
 	dir            string
 	walDir         string
+	walKeyFile     string
 	lpurls, lcurls []url.URL
 	maxSnapFiles   uint
 	maxWalFiles    uint
 	name           string
 	snapCount      uint64
+	// snapCatchUp is the number of raft entries kept after a snapshot.
+	snapCatchUp uint64
+	// compactionBatchLimit and compactionSleepInterval pace compaction.
+	compactionBatchLimit    int
+	compactionSleepInterval time.Duration
+	// walFsyncInterval is the minimum time between fsyncs of the wal.
+	walFsyncInterval time.Duration
 	// TickMs is the number of milliseconds between heartbeat ticks.
 	// TODO: decouple tickMs and heartbeat tick (current heartbeat tick = 1).
 	// make ticks a cluster wide configuration.
@@ -100,6 +111,10 @@ type config struct {
 	initialCluster      string
 	initialClusterToken string
 	strictReconfigCheck bool
+	proxyRedirect       bool
+
+	auditLogFile    string
+	auditLogMaxSize int64
 
 	// proxy
 	proxy                  *flags.StringsFlag
@@ -112,18 +127,26 @@ type config struct {
 	// security
 	clientTLSInfo, peerTLSInfo transport.TLSInfo
 	peerAutoTLS                bool
+	cipherSuites               string
 
 	// logging
 	debug        bool
 	logPkgLevels string
 
 	// unsafe
-	forceNewCluster bool
+	forceNewCluster       bool
+	allowLowSnapshotCount bool
 
 	printVersion bool
 
 	v3demo                  bool
 	autoCompactionRetention int
+	txnDedupTTL             time.Duration
+	maxConcurrentStreams    uint
+	// grpcHeaders is the --grpc-additional-headers flag, parsed into
+	// grpcAdditionalHeaders.
+	grpcHeaders           string
+	grpcAdditionalHeaders map[string]string
 
 	enablePprof bool
 
@@ -159,12 +182,17 @@ func NewConfig() *config {
 	fs.Var(cfg.corsInfo, "cors", "Comma-separated white list of origins for CORS (cross-origin resource sharing).")
 	fs.StringVar(&cfg.dir, "data-dir", "", "Path to the data directory.")
 	fs.StringVar(&cfg.walDir, "wal-dir", "", "Path to the dedicated wal directory.")
+	fs.StringVar(&cfg.walKeyFile, "wal-encryption-key-file", "", "Path to the file the wal encryption key is derived from.")
+	fs.DurationVar(&cfg.walFsyncInterval, "wal-fsync-interval", 0, "Minimum time between fsyncs of the wal, so the entries saved in between are flushed together (0 fsyncs every write).")
 	fs.Var(flags.NewURLsValue("http://localhost:2380,http://localhost:7001"), "listen-peer-urls", "List of URLs to listen on for peer traffic.")
 	fs.Var(flags.NewURLsValue("http://localhost:2379,http://localhost:4001"), "listen-client-urls", "List of URLs to listen on for client traffic.")
 	fs.UintVar(&cfg.maxSnapFiles, "max-snapshots", defaultMaxSnapshots, "Maximum number of snapshot files to retain (0 is unlimited).")
 	fs.UintVar(&cfg.maxWalFiles, "max-wals", defaultMaxWALs, "Maximum number of wal files to retain (0 is unlimited).")
 	fs.StringVar(&cfg.name, "name", defaultName, "Human-readable name for this member.")
 	fs.Uint64Var(&cfg.snapCount, "snapshot-count", etcdserver.DefaultSnapCount, "Number of committed transactions to trigger a snapshot to disk.")
+	fs.Uint64Var(&cfg.snapCatchUp, "snapshot-catchup-entries", etcdserver.DefaultSnapshotCatchUpEntries, "Number of entries kept after a snapshot for slow followers to catch up from; a follower further behind is sent the snapshot.")
+	fs.IntVar(&cfg.compactionBatchLimit, "compaction-batch-limit", storage.DefaultCompactionBatchLimit, "Number of keys deleted per step of a compaction.")
+	fs.DurationVar(&cfg.compactionSleepInterval, "compaction-sleep-interval", storage.DefaultCompactionSleepInterval, "Pause between the steps of a compaction.")
 	fs.UintVar(&cfg.TickMs, "heartbeat-interval", 100, "Time (in milliseconds) of a heartbeat interval.")
 	fs.UintVar(&cfg.ElectionMs, "election-timeout", 1000, "Time (in milliseconds) for an election to timeout.")
 
@@ -187,6 +215,9 @@ func NewConfig() *config {
 		plog.Panicf("unexpected error setting up clusterStateFlag: %v", err)
 	}
 	fs.BoolVar(&cfg.strictReconfigCheck, "strict-reconfig-check", false, "Reject reconfiguration requests that would cause quorum loss.")
+	fs.BoolVar(&cfg.proxyRedirect, "proxy-redirect", false, "Redirect quorum v2 GET requests received by a follower to the leader.")
+	fs.StringVar(&cfg.auditLogFile, "audit-log-file", "", "Path to append a JSON line to for every mutating v3 request.")
+	fs.Int64Var(&cfg.auditLogMaxSize, "audit-log-max-size", 100*1024*1024, "Size (in bytes) at which the audit log is rotated; 0 disables rotation.")
 
 	// proxy
 	fs.Var(cfg.proxy, "proxy", fmt.Sprintf("Valid values include %s", strings.Join(cfg.proxy.Values, ", ")))
@@ -212,6 +243,7 @@ func NewConfig() *config {
 	fs.BoolVar(&cfg.peerTLSInfo.ClientCertAuth, "peer-client-cert-auth", false, "Enable peer client cert authentication.")
 	fs.StringVar(&cfg.peerTLSInfo.TrustedCAFile, "peer-trusted-ca-file", "", "Path to the peer server TLS trusted CA file.")
 	fs.BoolVar(&cfg.peerAutoTLS, "peer-auto-tls", false, "Peer TLS using generated certificates")
+	fs.StringVar(&cfg.cipherSuites, "cipher-suites", "", "Comma-separated list of TLS cipher suites the client and peer servers accept (empty allows the Go defaults).")
 
 	// logging
 	fs.BoolVar(&cfg.debug, "debug", false, "Enable debug-level logging for etcd.")
@@ -219,6 +251,7 @@ func NewConfig() *config {
 
 	// unsafe
 	fs.BoolVar(&cfg.forceNewCluster, "force-new-cluster", false, "Force to create a new one member cluster.")
+	fs.BoolVar(&cfg.allowLowSnapshotCount, "unsafe-allow-low-snapshot-count", false, fmt.Sprintf("Allow a --snapshot-count lower than %d, e.g. for testing.", etcdserver.MinSnapshotCount))
 
 	// version
 	fs.BoolVar(&cfg.printVersion, "version", false, "Print the version and exit.")
@@ -226,6 +259,9 @@ func NewConfig() *config {
 	// demo flag
 	fs.BoolVar(&cfg.v3demo, "experimental-v3demo", false, "Enable experimental v3 demo API.")
 	fs.IntVar(&cfg.autoCompactionRetention, "experimental-auto-compaction-retention", 0, "Auto compaction retention in hour. 0 means disable auto compaction.")
+	fs.DurationVar(&cfg.txnDedupTTL, "experimental-txn-dedup-ttl", etcdserver.DefaultTxnDedupTTL, "How long the result of an idempotent txn is kept for deduplication.")
+	fs.UintVar(&cfg.maxConcurrentStreams, "max-concurrent-streams", math.MaxUint32, "Maximum concurrent gRPC streams, including unary RPCs, on each client connection.")
+	fs.StringVar(&cfg.grpcHeaders, "grpc-additional-headers", "", "Comma-separated name=value headers added to every gRPC response.")
 
 	// backwards-compatibility with v0.4.6
 	fs.Var(&flags.IPAddressPort{}, "addr", "DEPRECATED: Use --advertise-client-urls instead.")
@@ -286,6 +322,12 @@ func (cfg *config) Parse(arguments []string) error {
 		return ErrConflictBootstrapFlags
 	}
 
+	css, err := transport.GetCipherSuites(cfg.cipherSuites)
+	if err != nil {
+		return fmt.Errorf("invalid --cipher-suites (%v)", err)
+	}
+	cfg.clientTLSInfo.CipherSuites, cfg.peerTLSInfo.CipherSuites = css, css
+
 	flags.SetBindAddrFromAddr(cfg.FlagSet, "peer-bind-addr", "peer-addr")
 	flags.SetBindAddrFromAddr(cfg.FlagSet, "bind-addr", "addr")
 
@@ -316,6 +358,29 @@ func (cfg *config) Parse(arguments []string) error {
 		}
 	}
 
+	// 0 keeps meaning the default snapshot count
+	if cfg.snapCount != 0 && cfg.snapCount < etcdserver.MinSnapshotCount && !cfg.allowLowSnapshotCount {
+		return fmt.Errorf("--snapshot-count[%v] should be at least %v, unless --unsafe-allow-low-snapshot-count is set", cfg.snapCount, etcdserver.MinSnapshotCount)
+	}
+	if flags.IsSet(cfg.FlagSet, "snapshot-catchup-entries") && cfg.snapCatchUp == 0 {
+		return fmt.Errorf("--snapshot-catchup-entries[%v] should be positive", cfg.snapCatchUp)
+	}
+	if cfg.walFsyncInterval < 0 {
+		return fmt.Errorf("--wal-fsync-interval[%v] should not be negative", cfg.walFsyncInterval)
+	}
+	if cfg.compactionBatchLimit <= 0 {
+		return fmt.Errorf("--compaction-batch-limit[%v] should be positive", cfg.compactionBatchLimit)
+	}
+	if cfg.compactionSleepInterval <= 0 {
+		return fmt.Errorf("--compaction-sleep-interval[%v] should be positive", cfg.compactionSleepInterval)
+	}
+	if cfg.maxConcurrentStreams == 0 || cfg.maxConcurrentStreams > math.MaxUint32 {
+		return fmt.Errorf("--max-concurrent-streams[%v] should be between 1 and %v", cfg.maxConcurrentStreams, uint32(math.MaxUint32))
+	}
+	if cfg.grpcAdditionalHeaders, err = parseGRPCHeaders(cfg.grpcHeaders); err != nil {
+		return fmt.Errorf("--grpc-additional-headers: %v", err)
+	}
+
 	if 5*cfg.TickMs > cfg.ElectionMs {
 		return fmt.Errorf("--election-timeout[%vms] should be at least as 5 times as --heartbeat-interval[%vms]", cfg.ElectionMs, cfg.TickMs)
 	}
@@ -340,3 +405,28 @@ func (cfg config) isReadonlyProxy() bool       { return cfg.proxy.String() == pr
 func (cfg config) shouldFallbackToProxy() bool { return cfg.fallback.String() == fallbackFlagProxy }
 
 func (cfg config) electionTicks() int { return int(cfg.ElectionMs / cfg.TickMs) }
+
+// parseGRPCHeaders parses a comma-separated list of name=value headers.
+// Names are lowercased, as gRPC metadata keys are, and the names starting
+// with "grpc-" are reserved by gRPC.
+func parseGRPCHeaders(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	h := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not of the form name=value", kv)
+		}
+		name := strings.ToLower(strings.TrimSpace(kv[:i]))
+		if name == "" || strings.TrimLeft(name, "abcdefghijklmnopqrstuvwxyz0123456789-_.") != "" {
+			return nil, fmt.Errorf("bad header name %q", kv[:i])
+		}
+		if strings.HasPrefix(name, "grpc-") {
+			return nil, fmt.Errorf("header name %q is reserved by gRPC", name)
+		}
+		h[name] = kv[i+1:]
+	}
+	return h, nil
+}
@@ -15,9 +15,12 @@
 package etcdmain
 
 import (
+	"crypto/tls"
 	"net/url"
 	"reflect"
 	"testing"
+
+	"github.com/coreos/etcd/etcdserver"
 )
 
 func TestConfigParsingMemberFlags(t *testing.T) {
@@ -26,7 +29,7 @@ func TestConfigParsingMemberFlags(t *testing.T) {
 		"-name=testname",
 		"-max-wals=10",
 		"-max-snapshots=10",
-		"-snapshot-count=10",
+		"-snapshot-count=1000",
 		"-listen-peer-urls=http://localhost:8000,https://localhost:8001",
 		"-listen-client-urls=http://localhost:7000,https://localhost:7001",
 		// it should be set if -listen-client-urls is set
@@ -39,7 +42,7 @@ func TestConfigParsingMemberFlags(t *testing.T) {
 		maxSnapFiles: 10,
 		maxWalFiles:  10,
 		name:         "testname",
-		snapCount:    10,
+		snapCount:    1000,
 	}
 
 	cfg := NewConfig()
@@ -212,6 +215,66 @@ func TestConfigParsingConflictClusteringFlags(t *testing.T) {
 	}
 }
 
+func TestConfigParsingCipherSuites(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.Parse([]string{"-cipher-suites=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}); err != nil {
+		t.Fatal(err)
+	}
+	wcss := []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	if !reflect.DeepEqual(cfg.clientTLSInfo.CipherSuites, wcss) || !reflect.DeepEqual(cfg.peerTLSInfo.CipherSuites, wcss) {
+		t.Errorf("cipher suites = %v, %v; want %v", cfg.clientTLSInfo.CipherSuites, cfg.peerTLSInfo.CipherSuites, wcss)
+	}
+
+	cfg = NewConfig()
+	if err := cfg.Parse([]string{"-cipher-suites=TLS_RSA_WITH_BOGUS"}); err == nil {
+		t.Errorf("expected error on unknown cipher suite")
+	}
+}
+
+func TestConfigParsingSnapshotCatchUpEntries(t *testing.T) {
+	tests := []struct {
+		args []string
+		wn   uint64
+		werr bool
+	}{
+		{[]string{}, etcdserver.DefaultSnapshotCatchUpEntries, false},
+		{[]string{"-snapshot-count=1000"}, etcdserver.DefaultSnapshotCatchUpEntries, false},
+		{[]string{"-snapshot-count=1000", "-snapshot-catchup-entries=500"}, 500, false},
+		{[]string{"-snapshot-count=1000", "-snapshot-catchup-entries=2000"}, 2000, false},
+		{[]string{"-snapshot-catchup-entries=0"}, 0, true},
+	}
+	for i, tt := range tests {
+		cfg := NewConfig()
+		err := cfg.Parse(tt.args)
+		if (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+			continue
+		}
+		if err == nil && cfg.snapCatchUp != tt.wn {
+			t.Errorf("#%d: snapCatchUp = %d, want %d", i, cfg.snapCatchUp, tt.wn)
+		}
+	}
+}
+
+func TestConfigParsingSnapshotCount(t *testing.T) {
+	tests := []struct {
+		args []string
+		werr bool
+	}{
+		{[]string{}, false},
+		{[]string{"-snapshot-count=0"}, false},
+		{[]string{"-snapshot-count=100"}, false},
+		{[]string{"-snapshot-count=1"}, true},
+		{[]string{"-snapshot-count=1", "-unsafe-allow-low-snapshot-count"}, false},
+	}
+	for i, tt := range tests {
+		cfg := NewConfig()
+		if err := cfg.Parse(tt.args); (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+		}
+	}
+}
+
 func TestConfigParsingMissedAdvertiseClientURLsFlag(t *testing.T) {
 	tests := []struct {
 		args []string
@@ -354,3 +417,30 @@ func TestConfigShouldFallbackToProxy(t *testing.T) {
 		}
 	}
 }
+
+func TestParseGRPCHeaders(t *testing.T) {
+	tests := []struct {
+		s string
+
+		wh   map[string]string
+		werr bool
+	}{
+		{"", nil, false},
+		{"x-a=1", map[string]string{"x-a": "1"}, false},
+		{"X-A=1, x-b=a=b", map[string]string{"x-a": "1", "x-b": "a=b"}, false},
+		{"x-a=", map[string]string{"x-a": ""}, false},
+		{"x-a", nil, true},
+		{"=1", nil, true},
+		{"x a=1", nil, true},
+		{"grpc-status=0", nil, true},
+	}
+	for i, tt := range tests {
+		h, err := parseGRPCHeaders(tt.s)
+		if (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+		}
+		if !reflect.DeepEqual(h, tt.wh) {
+			t.Errorf("#%d: headers = %v, want %v", i, h, tt.wh)
+		}
+	}
+}
@@ -34,6 +34,7 @@ import (
 
 	"github.com/coreos/etcd/discovery"
 	"github.com/coreos/etcd/etcdserver"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc"
 	"github.com/coreos/etcd/etcdserver/etcdhttp"
 	"github.com/coreos/etcd/pkg/cors"
 	"github.com/coreos/etcd/pkg/fileutil"
@@ -45,6 +46,7 @@ import (
 	"github.com/coreos/etcd/proxy"
 	"github.com/coreos/etcd/rafthttp"
 	"github.com/coreos/etcd/version"
+	"github.com/coreos/etcd/wal"
 	"github.com/coreos/go-systemd/daemon"
 	systemdutil "github.com/coreos/go-systemd/util"
 	"github.com/coreos/pkg/capnslog"
@@ -70,6 +72,10 @@ const (
 	// in a cluster, so it should reserve 96.
 	// For the safety, we set the total reserved number to 150.
 	reservedInternalFDNum = 150
+
+	// leaderTransferTimeout bounds how long a leader that is shutting down
+	// waits for a follower to take over before it stops anyway.
+	leaderTransferTimeout = 5 * time.Second
 )
 
 var (
@@ -208,10 +214,12 @@ func startEtcd(cfg *config) (<-chan struct{}, error) {
 		for _, u := range cfg.lpurls {
 			phosts = append(phosts, u.Host)
 		}
+		css := cfg.peerTLSInfo.CipherSuites
 		cfg.peerTLSInfo, err = transport.SelfCert(cfg.dir, phosts)
 		if err != nil {
 			plog.Fatalf("could not get certs (%v)", err)
 		}
+		cfg.peerTLSInfo.CipherSuites = css
 	} else if cfg.peerAutoTLS {
 		plog.Warningf("ignoring peer auto TLS since certs given")
 	}
@@ -219,6 +227,14 @@ func startEtcd(cfg *config) (<-chan struct{}, error) {
 	if !cfg.peerTLSInfo.Empty() {
 		plog.Infof("peerTLS: %s", cfg.peerTLSInfo)
 	}
+
+	var walKey []byte
+	if cfg.walKeyFile != "" {
+		if walKey, err = wal.ReadEncryptionKeyFile(cfg.walKeyFile); err != nil {
+			return nil, fmt.Errorf("error reading wal encryption key: %v", err)
+		}
+	}
+
 	plns := make([]net.Listener, 0)
 	for _, u := range cfg.lpurls {
 		if u.Scheme == "http" && !cfg.peerTLSInfo.Empty() {
@@ -322,6 +338,9 @@ func startEtcd(cfg *config) (<-chan struct{}, error) {
 		DataDir:                 cfg.dir,
 		DedicatedWALDir:         cfg.walDir,
 		SnapCount:               cfg.snapCount,
+		SnapshotCatchUpEntries:  cfg.snapCatchUp,
+		CompactionBatchLimit:    cfg.compactionBatchLimit,
+		CompactionSleepInterval: cfg.compactionSleepInterval,
 		MaxSnapFiles:            cfg.maxSnapFiles,
 		MaxWALFiles:             cfg.maxWalFiles,
 		InitialPeerURLsMap:      urlsmap,
@@ -335,16 +354,47 @@ func startEtcd(cfg *config) (<-chan struct{}, error) {
 		ElectionTicks:           cfg.electionTicks(),
 		V3demo:                  cfg.v3demo,
 		AutoCompactionRetention: cfg.autoCompactionRetention,
+		TxnDedupTTL:             cfg.txnDedupTTL,
+		MaxConcurrentStreams:    uint32(cfg.maxConcurrentStreams),
+		GRPCAdditionalHeaders:   cfg.grpcAdditionalHeaders,
+		WALEncryptionKey:        walKey,
+		WALFsyncInterval:        cfg.walFsyncInterval,
 		StrictReconfigCheck:     cfg.strictReconfigCheck,
+		ProxyRedirect:           cfg.proxyRedirect,
 		EnablePprof:             cfg.enablePprof,
 	}
+	var al *v3rpc.AuditLog
+	if cfg.auditLogFile != "" {
+		if al, err = v3rpc.NewAuditLog(cfg.auditLogFile, cfg.auditLogMaxSize); err != nil {
+			return nil, err
+		}
+		plog.Infof("recording mutating v3 requests in audit log %s", cfg.auditLogFile)
+		defer func() {
+			if err != nil {
+				al.Close()
+			}
+		}()
+	}
+
 	var s *etcdserver.EtcdServer
 	s, err = etcdserver.NewServer(srvcfg)
 	if err != nil {
 		return nil, err
 	}
 	s.Start()
-	osutil.RegisterInterruptHandler(s.Stop)
+	osutil.RegisterInterruptHandler(func() {
+		// hand off leadership first so clients are not left without a
+		// leader for an election timeout
+		if err := s.TransferLeadership(leaderTransferTimeout); err != nil {
+			plog.Warningf("failed to transfer leadership before stopping (%v)", err)
+		}
+		s.Stop()
+		if al != nil {
+			if err := al.Close(); err != nil {
+				plog.Errorf("failed to close audit log %s (%v)", cfg.auditLogFile, err)
+			}
+		}
+	})
 
 	if cfg.corsInfo.String() != "" {
 		plog.Infof("cors = %s", cfg.corsInfo)
@@ -366,7 +416,7 @@ func startEtcd(cfg *config) (<-chan struct{}, error) {
 		go func(sctx *serveCtx) {
 			// read timeout does not work with http close notify
 			// TODO: https://github.com/golang/go/issues/9524
-			plog.Fatal(serve(sctx, s, ctlscfg, ch))
+			plog.Fatal(serve(sctx, s, ctlscfg, ch, al))
 		}(sctx)
 	}
 
@@ -35,8 +35,18 @@ member flags:
 		path to the data directory.
 	--wal-dir ''
 		path to the dedicated wal directory.
+	--wal-encryption-key-file ''
+		path to the file the wal encryption key is derived from; the wal is not encrypted if empty.
+	--wal-fsync-interval '0s'
+		minimum time between fsyncs of the wal; entries saved in between are flushed together and may be lost on a crash.
 	--snapshot-count '10000'
 		number of committed transactions to trigger a snapshot to disk.
+	--snapshot-catchup-entries '5000'
+		number of entries kept after a snapshot for slow followers; a follower further behind is sent the snapshot.
+	--compaction-batch-limit '1000'
+		number of keys deleted per step of a compaction.
+	--compaction-sleep-interval '10ms'
+		pause between the steps of a compaction.
 	--heartbeat-interval '100'
 		time (in milliseconds) of a heartbeat interval.
 	--election-timeout '1000'
@@ -77,6 +87,16 @@ clustering flags:
 		dns srv domain used to bootstrap the cluster.
 	--strict-reconfig-check
 		reject reconfiguration requests that would cause quorum loss.
+	--proxy-redirect 'false'
+		redirect quorum v2 GET requests received by a follower to the leader.
+	--audit-log-file ''
+		path to append a JSON line to for every mutating v3 request.
+	--audit-log-max-size 104857600
+		size (in bytes) at which the audit log is rotated; 0 disables rotation.
+	--max-concurrent-streams 4294967295
+		maximum concurrent gRPC streams, including unary RPCs, on each client connection.
+	--grpc-additional-headers ''
+		comma-separated name=value headers added to every gRPC response.
 
 proxy flags:
 
@@ -116,6 +136,8 @@ security flags:
 		enable peer client cert authentication.
 	--peer-trusted-ca-file ''
 		path to the peer server TLS trusted CA file.
+	--cipher-suites ''
+		comma-separated list of TLS cipher suites accepted by the client and peer servers (empty allows the Go defaults).
 
 logging flags
 
@@ -131,6 +153,8 @@ given by the consensus protocol.
 
 	--force-new-cluster 'false'
 		force to create a new one-member cluster.
+	--unsafe-allow-low-snapshot-count 'false'
+		allow a --snapshot-count lower than 100, e.g. for testing.
 
 
 experimental flags:
@@ -139,6 +163,8 @@ experimental flags:
 		enable experimental v3 demo API.
 	--experimental-auto-compaction-retention '0'
 		auto compaction retention in hour. 0 means disable auto compaction.
+	--experimental-txn-dedup-ttl '5m0s'
+		how long the result of an idempotent txn is kept for deduplication.
 
 profiling flags:
 	--enable-pprof 'false'
@@ -39,13 +39,13 @@ type serveCtx struct {
 // serve accepts incoming connections on the listener l,
 // creating a new service goroutine for each. The service goroutines
 // read requests and then call handler to reply to them.
-func serve(sctx *serveCtx, s *etcdserver.EtcdServer, tlscfg *tls.Config, handler http.Handler) error {
+func serve(sctx *serveCtx, s *etcdserver.EtcdServer, tlscfg *tls.Config, handler http.Handler, al *v3rpc.AuditLog) error {
 	logger := defaultLog.New(ioutil.Discard, "etcdhttp", 0)
 
 	m := cmux.New(sctx.l)
 
 	if sctx.insecure {
-		gs := v3rpc.Server(s, nil)
+		gs := v3rpc.Server(s, nil, al)
 		grpcl := m.Match(cmux.HTTP2())
 		go func() { plog.Fatal(gs.Serve(grpcl)) }()
 
@@ -59,7 +59,7 @@ func serve(sctx *serveCtx, s *etcdserver.EtcdServer, tlscfg *tls.Config, handler
 	}
 
 	if sctx.secure {
-		gs := v3rpc.Server(s, tlscfg)
+		gs := v3rpc.Server(s, tlscfg, al)
 		handler = grpcHandlerFunc(gs, handler)
 
 		tlsl := tls.NewListener(m.Match(cmux.Any()), tlscfg)
@@ -0,0 +1,337 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// AuditLog appends one JSON line to a file for every mutating RPC. Once the
+// file grows past maxSize bytes, it is renamed to "<path>.1", replacing the
+// previous one, and a new file is started.
+type AuditLog struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+type auditEntry struct {
+	Time     string       `json:"time"`
+	Peer     string       `json:"peer,omitempty"`
+	RPC      string       `json:"rpc"`
+	Key      string       `json:"key,omitempty"`
+	RangeEnd string       `json:"rangeEnd,omitempty"`
+	Ops      []auditTxnOp `json:"ops,omitempty"`
+	Lease    int64        `json:"lease,omitempty"`
+	Member   string       `json:"member,omitempty"`
+	Success  bool         `json:"success"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// auditTxnOp is a write a txn may make.
+type auditTxnOp struct {
+	RPC      string `json:"rpc"`
+	Key      string `json:"key"`
+	RangeEnd string `json:"rangeEnd,omitempty"`
+}
+
+// NewAuditLog opens the audit log at path for appending. A maxSize of zero
+// never rotates the file.
+func NewAuditLog(path string, maxSize int64) (*AuditLog, error) {
+	al := &AuditLog{path: path, maxSize: maxSize}
+	if err := al.open(); err != nil {
+		return nil, err
+	}
+	return al, nil
+}
+
+func (al *AuditLog) open() error {
+	f, err := os.OpenFile(al.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	al.f, al.size = f, fi.Size()
+	return nil
+}
+
+// Close closes the audit log file. RPCs that finish after Close are no
+// longer recorded.
+func (al *AuditLog) Close() error {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if al.f == nil {
+		return nil
+	}
+	err := al.f.Close()
+	al.f = nil
+	return err
+}
+
+func (al *AuditLog) log(ctx context.Context, e auditEntry, err error) {
+	e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.Peer = p.Addr.String()
+	}
+	e.Success = err == nil
+	if err != nil {
+		e.Error = grpc.ErrorDesc(err)
+	}
+	b, merr := json.Marshal(e)
+	if merr != nil {
+		plog.Panicf("marshal audit entry should never fail (%v)", merr)
+	}
+	b = append(b, '\n')
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if al.f == nil {
+		return
+	}
+	if al.maxSize > 0 && al.size > 0 && al.size+int64(len(b)) > al.maxSize {
+		if rerr := al.rotate(); rerr != nil {
+			plog.Errorf("failed to rotate audit log %s (%v)", al.path, rerr)
+		}
+	}
+	n, werr := al.f.Write(b)
+	al.size += int64(n)
+	if werr != nil {
+		plog.Errorf("failed to write audit log %s (%v)", al.path, werr)
+	}
+}
+
+func (al *AuditLog) rotate() error {
+	if err := al.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(al.path, al.path+".1"); err != nil {
+		return err
+	}
+	return al.open()
+}
+
+// The audit servers record the mutating RPCs of the server they wrap. They
+// hold it in a named field rather than embedding it, so a new RPC does not
+// compile until its audit server handles it.
+
+type auditKVServer struct {
+	kvs pb.KVServer
+	al  *AuditLog
+}
+
+func (s *auditKVServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
+	return s.kvs.Range(ctx, r)
+}
+
+func (s *auditKVServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	resp, err := s.kvs.Put(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "Put", Key: string(r.Key), Lease: r.Lease}, err)
+	return resp, err
+}
+
+func (s *auditKVServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	resp, err := s.kvs.DeleteRange(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "DeleteRange", Key: string(r.Key), RangeEnd: string(r.RangeEnd)}, err)
+	return resp, err
+}
+
+func (s *auditKVServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	resp, err := s.kvs.Txn(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "Txn", Ops: txnWriteOps(r)}, err)
+	return resp, err
+}
+
+func (s *auditKVServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	resp, err := s.kvs.Compact(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "Compact"}, err)
+	return resp, err
+}
+
+func (s *auditKVServer) Hash(ctx context.Context, r *pb.HashRequest) (*pb.HashResponse, error) {
+	return s.kvs.Hash(ctx, r)
+}
+
+// txnWriteOps returns the writes the txn may make in either branch.
+func txnWriteOps(r *pb.TxnRequest) []auditTxnOp {
+	var ops []auditTxnOp
+	for _, ru := range append(r.Success, r.Failure...) {
+		if p := ru.GetRequestPut(); p != nil {
+			ops = append(ops, auditTxnOp{RPC: "Put", Key: string(p.Key)})
+		}
+		if d := ru.GetRequestDeleteRange(); d != nil {
+			ops = append(ops, auditTxnOp{RPC: "DeleteRange", Key: string(d.Key), RangeEnd: string(d.RangeEnd)})
+		}
+	}
+	return ops
+}
+
+type auditLeaseServer struct {
+	ls pb.LeaseServer
+	al *AuditLog
+}
+
+func (s *auditLeaseServer) LeaseCreate(ctx context.Context, r *pb.LeaseCreateRequest) (*pb.LeaseCreateResponse, error) {
+	resp, err := s.ls.LeaseCreate(ctx, r)
+	e := auditEntry{RPC: "LeaseCreate", Lease: r.ID}
+	if resp != nil {
+		e.Lease = resp.ID
+	}
+	s.al.log(ctx, e, err)
+	return resp, err
+}
+
+func (s *auditLeaseServer) LeaseRevoke(ctx context.Context, r *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	resp, err := s.ls.LeaseRevoke(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "LeaseRevoke", Lease: r.ID}, err)
+	return resp, err
+}
+
+// LeaseKeepAlive is not recorded; keepalives only extend existing leases.
+func (s *auditLeaseServer) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error {
+	return s.ls.LeaseKeepAlive(stream)
+}
+
+type auditClusterServer struct {
+	cs pb.ClusterServer
+	al *AuditLog
+}
+
+func (s *auditClusterServer) MemberAdd(ctx context.Context, r *pb.MemberAddRequest) (*pb.MemberAddResponse, error) {
+	resp, err := s.cs.MemberAdd(ctx, r)
+	e := auditEntry{RPC: "MemberAdd"}
+	if resp != nil && resp.Member != nil {
+		e.Member = types.ID(resp.Member.ID).String()
+	}
+	s.al.log(ctx, e, err)
+	return resp, err
+}
+
+func (s *auditClusterServer) MemberRemove(ctx context.Context, r *pb.MemberRemoveRequest) (*pb.MemberRemoveResponse, error) {
+	resp, err := s.cs.MemberRemove(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "MemberRemove", Member: types.ID(r.ID).String()}, err)
+	return resp, err
+}
+
+func (s *auditClusterServer) MemberUpdate(ctx context.Context, r *pb.MemberUpdateRequest) (*pb.MemberUpdateResponse, error) {
+	resp, err := s.cs.MemberUpdate(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "MemberUpdate", Member: types.ID(r.ID).String()}, err)
+	return resp, err
+}
+
+func (s *auditClusterServer) MemberList(ctx context.Context, r *pb.MemberListRequest) (*pb.MemberListResponse, error) {
+	return s.cs.MemberList(ctx, r)
+}
+
+type auditAuthServer struct {
+	as pb.AuthServer
+	al *AuditLog
+}
+
+func (s *auditAuthServer) AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*pb.AuthEnableResponse, error) {
+	resp, err := s.as.AuthEnable(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "AuthEnable"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) AuthDisable(ctx context.Context, r *pb.AuthDisableRequest) (*pb.AuthDisableResponse, error) {
+	resp, err := s.as.AuthDisable(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "AuthDisable"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*pb.AuthStatusResponse, error) {
+	return s.as.AuthStatus(ctx, r)
+}
+
+func (s *auditAuthServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	return s.as.Authenticate(ctx, r)
+}
+
+func (s *auditAuthServer) UserAdd(ctx context.Context, r *pb.UserAddRequest) (*pb.UserAddResponse, error) {
+	resp, err := s.as.UserAdd(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "UserAdd"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) UserGet(ctx context.Context, r *pb.UserGetRequest) (*pb.UserGetResponse, error) {
+	return s.as.UserGet(ctx, r)
+}
+
+func (s *auditAuthServer) UserDelete(ctx context.Context, r *pb.UserDeleteRequest) (*pb.UserDeleteResponse, error) {
+	resp, err := s.as.UserDelete(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "UserDelete"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) UserChangePassword(ctx context.Context, r *pb.UserChangePasswordRequest) (*pb.UserChangePasswordResponse, error) {
+	resp, err := s.as.UserChangePassword(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "UserChangePassword"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) UserGrant(ctx context.Context, r *pb.UserGrantRequest) (*pb.UserGrantResponse, error) {
+	resp, err := s.as.UserGrant(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "UserGrant"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) UserRevoke(ctx context.Context, r *pb.UserRevokeRequest) (*pb.UserRevokeResponse, error) {
+	resp, err := s.as.UserRevoke(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "UserRevoke"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) RoleAdd(ctx context.Context, r *pb.RoleAddRequest) (*pb.RoleAddResponse, error) {
+	resp, err := s.as.RoleAdd(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "RoleAdd"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) RoleGet(ctx context.Context, r *pb.RoleGetRequest) (*pb.RoleGetResponse, error) {
+	return s.as.RoleGet(ctx, r)
+}
+
+func (s *auditAuthServer) RoleDelete(ctx context.Context, r *pb.RoleDeleteRequest) (*pb.RoleDeleteResponse, error) {
+	resp, err := s.as.RoleDelete(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "RoleDelete"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) RoleGrant(ctx context.Context, r *pb.RoleGrantRequest) (*pb.RoleGrantResponse, error) {
+	resp, err := s.as.RoleGrant(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "RoleGrant"}, err)
+	return resp, err
+}
+
+func (s *auditAuthServer) RoleRevoke(ctx context.Context, r *pb.RoleRevokeRequest) (*pb.RoleRevokeResponse, error) {
+	resp, err := s.as.RoleRevoke(ctx, r)
+	s.al.log(ctx, auditEntry{RPC: "RoleRevoke"}, err)
+	return resp, err
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+)
+
+// fakeKVServer fails every request on key "bad".
+type fakeKVServer struct {
+	pb.KVServer
+}
+
+func (s *fakeKVServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	if string(r.Key) == "bad" {
+		return nil, rpctypes.ErrKeyNotFound
+	}
+	return &pb.PutResponse{}, nil
+}
+
+func (s *fakeKVServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	return &pb.DeleteRangeResponse{}, nil
+}
+
+func (s *fakeKVServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	return &pb.TxnResponse{}, nil
+}
+
+func readAuditLog(t *testing.T, p string) []auditEntry {
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var es []auditEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("bad audit log line %q (%v)", sc.Text(), err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, e.Time); err != nil {
+			t.Errorf("bad time in %q (%v)", sc.Text(), err)
+		}
+		e.Time = ""
+		es = append(es, e)
+	}
+	return es
+}
+
+func TestAuditLogKV(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "audit.log")
+
+	al, err := NewAuditLog(p, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvs := &auditKVServer{&fakeKVServer{}, al}
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}
+	ctx := peer.NewContext(context.TODO(), &peer.Peer{Addr: addr})
+	kvs.Put(ctx, &pb.PutRequest{Key: []byte("foo"), Lease: 5})
+	kvs.Put(ctx, &pb.PutRequest{Key: []byte("bad")})
+	kvs.DeleteRange(ctx, &pb.DeleteRangeRequest{Key: []byte("a"), RangeEnd: []byte("b")})
+	put := &pb.RequestUnion{Request: &pb.RequestUnion_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("x")}}}
+	del := &pb.RequestUnion{Request: &pb.RequestUnion_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: []byte("y"), RangeEnd: []byte("z")}}}
+	kvs.Txn(ctx, &pb.TxnRequest{Success: []*pb.RequestUnion{put}, Failure: []*pb.RequestUnion{del}})
+	al.Close()
+	// closed logs record nothing
+	kvs.Put(ctx, &pb.PutRequest{Key: []byte("late")})
+
+	wes := []auditEntry{
+		{Peer: "10.0.0.1:1234", RPC: "Put", Key: "foo", Lease: 5, Success: true},
+		{Peer: "10.0.0.1:1234", RPC: "Put", Key: "bad", Error: "etcdserver: key not found"},
+		{Peer: "10.0.0.1:1234", RPC: "DeleteRange", Key: "a", RangeEnd: "b", Success: true},
+		{Peer: "10.0.0.1:1234", RPC: "Txn", Ops: []auditTxnOp{{RPC: "Put", Key: "x"}, {RPC: "DeleteRange", Key: "y", RangeEnd: "z"}}, Success: true},
+	}
+	if es := readAuditLog(t, p); !reflect.DeepEqual(es, wes) {
+		t.Errorf("entries = %+v, want %+v", es, wes)
+	}
+}
+
+func TestAuditLogRotate(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "audit.log")
+
+	// room for a single entry per file
+	al, err := NewAuditLog(p, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvs := &auditKVServer{&fakeKVServer{}, al}
+	kvs.Put(context.TODO(), &pb.PutRequest{Key: []byte("foo")})
+	kvs.Put(context.TODO(), &pb.PutRequest{Key: []byte("bar")})
+	al.Close()
+
+	if es := readAuditLog(t, p+".1"); len(es) != 1 || es[0].Key != "foo" {
+		t.Errorf("rotated entries = %+v, want put of foo", es)
+	}
+	if es := readAuditLog(t, p); len(es) != 1 || es[0].Key != "bar" {
+		t.Errorf("entries = %+v, want put of bar", es)
+	}
+}
@@ -21,20 +21,53 @@ import (
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 )
 
-func Server(s *etcdserver.EtcdServer, tls *tls.Config) *grpc.Server {
+// Server returns a gRPC server for the v3 API of s. If al is not nil,
+// mutating RPCs are recorded in it.
+func Server(s *etcdserver.EtcdServer, tls *tls.Config, al *AuditLog) *grpc.Server {
 	var opts []grpc.ServerOption
 	if tls != nil {
 		opts = append(opts, grpc.Creds(credentials.NewTLS(tls)))
 	}
+	if n := s.MaxConcurrentStreams(); n > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(n))
+	}
+
+	var (
+		kvs pb.KVServer          = NewKVServer(s)
+		ws  pb.WatchServer       = NewWatchServer(s)
+		ls  pb.LeaseServer       = NewLeaseServer(s)
+		cs  pb.ClusterServer     = NewClusterServer(s)
+		as  pb.AuthServer        = NewAuthServer(s)
+		ms  pb.MaintenanceServer = NewMaintenanceServer(s)
+		hs  pb.HealthServer      = NewHealthServer(s)
+	)
+	if al != nil {
+		kvs = &auditKVServer{kvs, al}
+		ls = &auditLeaseServer{ls, al}
+		cs = &auditClusterServer{cs, al}
+		as = &auditAuthServer{as, al}
+	}
+	if h := s.GRPCAdditionalHeaders(); len(h) > 0 {
+		md := metadata.New(h)
+		kvs = &headerKVServer{kvs, md}
+		ws = &headerWatchServer{ws, md}
+		ls = &headerLeaseServer{ls, md}
+		cs = &headerClusterServer{cs, md}
+		as = &headerAuthServer{as, md}
+		ms = &headerMaintenanceServer{ms, md}
+		hs = &headerHealthServer{hs, md}
+	}
 
 	grpcServer := grpc.NewServer(opts...)
-	pb.RegisterKVServer(grpcServer, NewKVServer(s))
-	pb.RegisterWatchServer(grpcServer, NewWatchServer(s))
-	pb.RegisterLeaseServer(grpcServer, NewLeaseServer(s))
-	pb.RegisterClusterServer(grpcServer, NewClusterServer(s))
-	pb.RegisterAuthServer(grpcServer, NewAuthServer(s))
-	pb.RegisterMaintenanceServer(grpcServer, NewMaintenanceServer(s))
+	pb.RegisterKVServer(grpcServer, kvs)
+	pb.RegisterWatchServer(grpcServer, ws)
+	pb.RegisterLeaseServer(grpcServer, ls)
+	pb.RegisterClusterServer(grpcServer, cs)
+	pb.RegisterAuthServer(grpcServer, as)
+	pb.RegisterMaintenanceServer(grpcServer, ms)
+	pb.RegisterHealthServer(grpcServer, hs)
 	return grpcServer
 }
@@ -0,0 +1,284 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The header servers send the additional gRPC headers of the server before
+// handling each RPC; see etcdserver.ServerConfig.GRPCAdditionalHeaders.
+
+type headerKVServer struct {
+	pb.KVServer
+	md metadata.MD
+}
+
+func (s *headerKVServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.KVServer.Range(ctx, r)
+}
+
+func (s *headerKVServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.KVServer.Put(ctx, r)
+}
+
+func (s *headerKVServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.KVServer.DeleteRange(ctx, r)
+}
+
+func (s *headerKVServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.KVServer.Txn(ctx, r)
+}
+
+func (s *headerKVServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.KVServer.Compact(ctx, r)
+}
+
+func (s *headerKVServer) Hash(ctx context.Context, r *pb.HashRequest) (*pb.HashResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.KVServer.Hash(ctx, r)
+}
+
+type headerWatchServer struct {
+	pb.WatchServer
+	md metadata.MD
+}
+
+func (s *headerWatchServer) Watch(stream pb.Watch_WatchServer) error {
+	if err := stream.SendHeader(s.md); err != nil {
+		return err
+	}
+	return s.WatchServer.Watch(stream)
+}
+
+type headerLeaseServer struct {
+	pb.LeaseServer
+	md metadata.MD
+}
+
+func (s *headerLeaseServer) LeaseCreate(ctx context.Context, r *pb.LeaseCreateRequest) (*pb.LeaseCreateResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.LeaseServer.LeaseCreate(ctx, r)
+}
+
+func (s *headerLeaseServer) LeaseRevoke(ctx context.Context, r *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.LeaseServer.LeaseRevoke(ctx, r)
+}
+
+func (s *headerLeaseServer) LeaseKeepAlive(stream pb.Lease_LeaseKeepAliveServer) error {
+	if err := stream.SendHeader(s.md); err != nil {
+		return err
+	}
+	return s.LeaseServer.LeaseKeepAlive(stream)
+}
+
+type headerClusterServer struct {
+	pb.ClusterServer
+	md metadata.MD
+}
+
+func (s *headerClusterServer) MemberAdd(ctx context.Context, r *pb.MemberAddRequest) (*pb.MemberAddResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.ClusterServer.MemberAdd(ctx, r)
+}
+
+func (s *headerClusterServer) MemberRemove(ctx context.Context, r *pb.MemberRemoveRequest) (*pb.MemberRemoveResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.ClusterServer.MemberRemove(ctx, r)
+}
+
+func (s *headerClusterServer) MemberUpdate(ctx context.Context, r *pb.MemberUpdateRequest) (*pb.MemberUpdateResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.ClusterServer.MemberUpdate(ctx, r)
+}
+
+func (s *headerClusterServer) MemberList(ctx context.Context, r *pb.MemberListRequest) (*pb.MemberListResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.ClusterServer.MemberList(ctx, r)
+}
+
+type headerMaintenanceServer struct {
+	pb.MaintenanceServer
+	md metadata.MD
+}
+
+func (s *headerMaintenanceServer) Defragment(ctx context.Context, r *pb.DefragmentRequest) (*pb.DefragmentResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.MaintenanceServer.Defragment(ctx, r)
+}
+
+func (s *headerMaintenanceServer) Status(ctx context.Context, r *pb.StatusRequest) (*pb.StatusResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.MaintenanceServer.Status(ctx, r)
+}
+
+type headerHealthServer struct {
+	pb.HealthServer
+	md metadata.MD
+}
+
+func (s *headerHealthServer) ReadinessProbe(ctx context.Context, r *pb.ReadinessRequest) (*pb.ReadinessResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.HealthServer.ReadinessProbe(ctx, r)
+}
+
+type headerAuthServer struct {
+	pb.AuthServer
+	md metadata.MD
+}
+
+func (s *headerAuthServer) AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*pb.AuthEnableResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.AuthEnable(ctx, r)
+}
+
+func (s *headerAuthServer) AuthDisable(ctx context.Context, r *pb.AuthDisableRequest) (*pb.AuthDisableResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.AuthDisable(ctx, r)
+}
+
+func (s *headerAuthServer) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*pb.AuthStatusResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.AuthStatus(ctx, r)
+}
+
+func (s *headerAuthServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.Authenticate(ctx, r)
+}
+
+func (s *headerAuthServer) UserAdd(ctx context.Context, r *pb.UserAddRequest) (*pb.UserAddResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.UserAdd(ctx, r)
+}
+
+func (s *headerAuthServer) UserGet(ctx context.Context, r *pb.UserGetRequest) (*pb.UserGetResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.UserGet(ctx, r)
+}
+
+func (s *headerAuthServer) UserDelete(ctx context.Context, r *pb.UserDeleteRequest) (*pb.UserDeleteResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.UserDelete(ctx, r)
+}
+
+func (s *headerAuthServer) UserChangePassword(ctx context.Context, r *pb.UserChangePasswordRequest) (*pb.UserChangePasswordResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.UserChangePassword(ctx, r)
+}
+
+func (s *headerAuthServer) UserGrant(ctx context.Context, r *pb.UserGrantRequest) (*pb.UserGrantResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.UserGrant(ctx, r)
+}
+
+func (s *headerAuthServer) UserRevoke(ctx context.Context, r *pb.UserRevokeRequest) (*pb.UserRevokeResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.UserRevoke(ctx, r)
+}
+
+func (s *headerAuthServer) RoleAdd(ctx context.Context, r *pb.RoleAddRequest) (*pb.RoleAddResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.RoleAdd(ctx, r)
+}
+
+func (s *headerAuthServer) RoleGet(ctx context.Context, r *pb.RoleGetRequest) (*pb.RoleGetResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.RoleGet(ctx, r)
+}
+
+func (s *headerAuthServer) RoleDelete(ctx context.Context, r *pb.RoleDeleteRequest) (*pb.RoleDeleteResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.RoleDelete(ctx, r)
+}
+
+func (s *headerAuthServer) RoleGrant(ctx context.Context, r *pb.RoleGrantRequest) (*pb.RoleGrantResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.RoleGrant(ctx, r)
+}
+
+func (s *headerAuthServer) RoleRevoke(ctx context.Context, r *pb.RoleRevokeRequest) (*pb.RoleRevokeResponse, error) {
+	if err := grpc.SendHeader(ctx, s.md); err != nil {
+		return nil, err
+	}
+	return s.AuthServer.RoleRevoke(ctx, r)
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"time"
+
+	"github.com/coreos/etcd/etcdserver"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/raft"
+	"golang.org/x/net/context"
+)
+
+const (
+	// readinessKey is the key read by the readiness probe.
+	readinessKey = "__readiness__"
+	// readinessTimeout bounds the probe read, so a member that lost quorum
+	// reports not ready instead of blocking the probe.
+	readinessTimeout = time.Second
+)
+
+type healthServer struct {
+	clusterID int64
+	memberID  int64
+	raftTimer etcdserver.RaftTimer
+
+	kv     etcdserver.RaftKV
+	server etcdserver.Server
+}
+
+func NewHealthServer(s *etcdserver.EtcdServer) pb.HealthServer {
+	return &healthServer{
+		clusterID: int64(s.Cluster().ID()),
+		memberID:  int64(s.ID()),
+		raftTimer: s,
+		kv:        s,
+		server:    s,
+	}
+}
+
+func (hs *healthServer) ReadinessProbe(ctx context.Context, r *pb.ReadinessRequest) (*pb.ReadinessResponse, error) {
+	resp := &pb.ReadinessResponse{
+		Header: &pb.ResponseHeader{
+			ClusterId: uint64(hs.clusterID),
+			MemberId:  uint64(hs.memberID),
+			RaftTerm:  hs.raftTimer.Term(),
+		},
+	}
+	if uint64(hs.server.Leader()) == raft.None {
+		resp.Reason = "no leader"
+		return resp, nil
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	rresp, err := hs.kv.Range(cctx, &pb.RangeRequest{Key: []byte(readinessKey)})
+	cancel()
+	if err != nil {
+		resp.Reason = "linearizable read failed: " + err.Error()
+		return resp, nil
+	}
+	resp.Header.Revision = rresp.Header.Revision
+	resp.Ready = true
+	return resp, nil
+}
@@ -17,6 +17,7 @@ package v3rpc
 
 import (
 	"sort"
+	"strconv"
 
 	"github.com/coreos/etcd/etcdserver"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
@@ -27,6 +28,7 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 )
 
 var (
@@ -68,6 +70,9 @@ func (s *kvServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResp
 		plog.Panic("unexpected nil resp.Header")
 	}
 	s.fillInHeader(resp.Header)
+	if n := maxResponseSizeFromContext(ctx); n > 0 {
+		truncateRangeResponse(resp, n)
+	}
 	return resp, err
 }
 
@@ -75,6 +80,10 @@ func (s *kvServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse,
 	if err := checkPutRequest(r); err != nil {
 		return nil, err
 	}
+	ctx, err := withFencingToken(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := s.kv.Put(ctx, r)
 	if err != nil {
@@ -92,6 +101,10 @@ func (s *kvServer) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*
 	if err := checkDeleteRequest(r); err != nil {
 		return nil, err
 	}
+	ctx, err := withFencingToken(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := s.kv.DeleteRange(ctx, r)
 	if err != nil {
@@ -109,8 +122,17 @@ func (s *kvServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse,
 	if err := checkTxnRequest(r); err != nil {
 		return nil, err
 	}
+	ctx, err := withFencingToken(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := s.kv.Txn(ctx, r)
+	var resp *pb.TxnResponse
+	if id := txnIDFromContext(ctx); id != "" {
+		resp, err = s.kv.IdempotentTxn(ctx, id, r)
+	} else {
+		resp, err = s.kv.Txn(ctx, r)
+	}
 	if err != nil {
 		return nil, togRPCError(err)
 	}
@@ -172,6 +194,60 @@ func checkDeleteRequest(r *pb.DeleteRangeRequest) error {
 	return nil
 }
 
+// txnIDFromContext returns the idempotent txn id sent with the request, if any.
+func txnIDFromContext(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok || len(md[rpctypes.MetadataTxnIDKey]) == 0 {
+		return ""
+	}
+	return md[rpctypes.MetadataTxnIDKey][0]
+}
+
+// maxResponseSizeFromContext returns the response size limit sent with the
+// request, or zero if there is none. The limit is only a hint, so a bad one
+// is ignored.
+func maxResponseSizeFromContext(ctx context.Context) int {
+	md, ok := metadata.FromContext(ctx)
+	if !ok || len(md[rpctypes.MetadataMaxResponseSizeKey]) == 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(md[rpctypes.MetadataMaxResponseSizeKey][0])
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// truncateRangeResponse drops the keys of resp past the first ones whose
+// encoded size fits in n bytes, and sets More if any were dropped.
+func truncateRangeResponse(resp *pb.RangeResponse, n int) {
+	size := 0
+	for i, kv := range resp.Kvs {
+		if size += kv.Size(); size > n {
+			resp.Kvs, resp.More = resp.Kvs[:i], true
+			return
+		}
+	}
+}
+
+// withFencingToken passes the fencing key and token sent with the request,
+// if any, on to the server.
+func withFencingToken(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromContext(ctx)
+	if !ok || (len(md[rpctypes.MetadataFencingTokenKey]) == 0 && len(md[rpctypes.MetadataFencingKeyKey]) == 0) {
+		return ctx, nil
+	}
+	if len(md[rpctypes.MetadataFencingTokenKey]) == 0 || len(md[rpctypes.MetadataFencingKeyKey]) == 0 {
+		return nil, rpctypes.ErrBadFencingToken
+	}
+	token, err := strconv.ParseInt(md[rpctypes.MetadataFencingTokenKey][0], 10, 64)
+	key := md[rpctypes.MetadataFencingKeyKey][0]
+	if err != nil || token <= 0 || key == "" {
+		return nil, rpctypes.ErrBadFencingToken
+	}
+	return etcdserver.WithFencingToken(ctx, []byte(key), token), nil
+}
+
 func checkTxnRequest(r *pb.TxnRequest) error {
 	if len(r.Compare) > MaxOpsPerTxn || len(r.Success) > MaxOpsPerTxn || len(r.Failure) > MaxOpsPerTxn {
 		return rpctypes.ErrTooManyOps
@@ -297,6 +373,10 @@ func togRPCError(err error) error {
 	// TODO: handle error from raft and timeout
 	case etcdserver.ErrRequestTooLarge:
 		return rpctypes.ErrRequestTooLarge
+	case etcdserver.ErrKeyNotFound:
+		return rpctypes.ErrKeyNotFound
+	case etcdserver.ErrStaleFencingToken:
+		return rpctypes.ErrStaleFencingToken
 	default:
 		return grpc.Errorf(codes.Internal, err.Error())
 	}
@@ -17,7 +17,9 @@ package v3rpc
 import (
 	"github.com/coreos/etcd/etcdserver"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/storage"
 	"github.com/coreos/etcd/storage/backend"
+	"github.com/coreos/etcd/version"
 	"golang.org/x/net/context"
 )
 
@@ -25,12 +27,29 @@ type BackendGetter interface {
 	Backend() backend.Backend
 }
 
+type KVGetter interface {
+	KV() storage.ConsistentWatchableKV
+}
+
 type maintenanceServer struct {
-	bg BackendGetter
+	clusterID int64
+	memberID  int64
+	raftTimer etcdserver.RaftTimer
+
+	bg     BackendGetter
+	kg     KVGetter
+	server etcdserver.Server
 }
 
 func NewMaintenanceServer(s *etcdserver.EtcdServer) pb.MaintenanceServer {
-	return &maintenanceServer{bg: s}
+	return &maintenanceServer{
+		clusterID: int64(s.Cluster().ID()),
+		memberID:  int64(s.ID()),
+		raftTimer: s,
+		bg:        s,
+		kg:        s,
+		server:    s,
+	}
 }
 
 func (ms *maintenanceServer) Defragment(ctx context.Context, sr *pb.DefragmentRequest) (*pb.DefragmentResponse, error) {
@@ -43,3 +62,19 @@ func (ms *maintenanceServer) Defragment(ctx context.Context, sr *pb.DefragmentRe
 	plog.Noticef("finished defragmenting the storage backend")
 	return &pb.DefragmentResponse{}, nil
 }
+
+func (ms *maintenanceServer) Status(ctx context.Context, ar *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return &pb.StatusResponse{
+		Header: &pb.ResponseHeader{
+			ClusterId: uint64(ms.clusterID),
+			MemberId:  uint64(ms.memberID),
+			Revision:  ms.kg.KV().Rev(),
+			RaftTerm:  ms.raftTimer.Term(),
+		},
+		Version:   version.Version,
+		DbSize:    ms.bg.Backend().Size(),
+		Leader:    uint64(ms.server.Leader()),
+		RaftIndex: ms.raftTimer.Index(),
+		RaftTerm:  ms.raftTimer.Term(),
+	}, nil
+}
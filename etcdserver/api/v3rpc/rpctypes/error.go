@@ -25,6 +25,10 @@ var (
 	ErrDuplicateKey = grpc.Errorf(codes.InvalidArgument, "etcdserver: duplicate key given in txn request")
 	ErrCompacted    = grpc.Errorf(codes.OutOfRange, "etcdserver: storage: required revision has been compacted")
 	ErrFutureRev    = grpc.Errorf(codes.OutOfRange, "etcdserver: storage: required revision is a future revision")
+	ErrKeyNotFound  = grpc.Errorf(codes.InvalidArgument, "etcdserver: key not found")
+
+	ErrBadFencingToken   = grpc.Errorf(codes.InvalidArgument, "etcdserver: fencing token must be a positive integer sent with a fencing key")
+	ErrStaleFencingToken = grpc.Errorf(codes.FailedPrecondition, "etcdserver: fencing key was lost since the fencing token was taken")
 
 	ErrLeaseNotFound = grpc.Errorf(codes.NotFound, "etcdserver: requested lease not found")
 	ErrLeaseExist    = grpc.Errorf(codes.FailedPrecondition, "etcdserver: lease already exists")
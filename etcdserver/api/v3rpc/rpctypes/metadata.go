@@ -0,0 +1,33 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpctypes
+
+const (
+	// MetadataTxnIDKey is the gRPC metadata key of the deduplication id
+	// of an idempotent txn.
+	MetadataTxnIDKey = "txn-id"
+
+	// MetadataFencingTokenKey is the gRPC metadata key of the fencing
+	// token of a write, the create revision of its fencing key.
+	MetadataFencingTokenKey = "fencing-token"
+
+	// MetadataFencingKeyKey is the gRPC metadata key of the lock or
+	// election key a fencing token was taken from.
+	MetadataFencingKeyKey = "fencing-key"
+
+	// MetadataMaxResponseSizeKey is the gRPC metadata key of the size in
+	// bytes past which the keys of a range response are dropped.
+	MetadataMaxResponseSizeKey = "max-response-size"
+)
@@ -16,6 +16,7 @@ package v3rpc
 
 import (
 	"io"
+	"sync"
 	"time"
 
 	"github.com/coreos/etcd/etcdserver"
@@ -72,6 +73,12 @@ type serverWatchStream struct {
 	// progress to.
 	progress map[storage.WatchID]bool
 
+	// mu protects fragmentSize
+	mu sync.Mutex
+	// fragmentSize tracks the watchID whose large responses should be
+	// split into fragments of at most the given number of bytes.
+	fragmentSize map[storage.WatchID]int
+
 	// closec indicates the stream is closed.
 	closec chan struct{}
 }
@@ -84,9 +91,10 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) error {
 		gRPCStream:  stream,
 		watchStream: ws.watchable.NewWatchStream(),
 		// chan for sending control response like watcher created and canceled.
-		ctrlStream: make(chan *pb.WatchResponse, ctrlStreamBufLen),
-		progress:   make(map[storage.WatchID]bool),
-		closec:     make(chan struct{}),
+		ctrlStream:   make(chan *pb.WatchResponse, ctrlStreamBufLen),
+		progress:     make(map[storage.WatchID]bool),
+		fragmentSize: make(map[storage.WatchID]int),
+		closec:       make(chan struct{}),
 	}
 	defer sws.close()
 
@@ -128,6 +136,11 @@ func (sws *serverWatchStream) recvLoop() error {
 			if id != -1 && creq.ProgressNotify {
 				sws.progress[id] = true
 			}
+			if id != -1 && creq.FragmentSize > 0 {
+				sws.mu.Lock()
+				sws.fragmentSize[id] = int(creq.FragmentSize)
+				sws.mu.Unlock()
+			}
 			sws.ctrlStream <- &pb.WatchResponse{
 				Header:   sws.newResponseHeader(wsrev),
 				WatchId:  int64(id),
@@ -145,6 +158,9 @@ func (sws *serverWatchStream) recvLoop() error {
 						Canceled: true,
 					}
 					delete(sws.progress, storage.WatchID(id))
+					sws.mu.Lock()
+					delete(sws.fragmentSize, storage.WatchID(id))
+					sws.mu.Unlock()
 				}
 			}
 			// TODO: do we need to return error back to client?
@@ -194,7 +210,7 @@ func (sws *serverWatchStream) sendLoop() {
 			}
 
 			storage.ReportEventReceived()
-			if err := sws.gRPCStream.Send(wr); err != nil {
+			if err := sws.send(wr); err != nil {
 				return
 			}
 
@@ -222,7 +238,7 @@ func (sws *serverWatchStream) sendLoop() {
 				ids[wid] = struct{}{}
 				for _, v := range pending[wid] {
 					storage.ReportEventReceived()
-					if err := sws.gRPCStream.Send(v); err != nil {
+					if err := sws.send(v); err != nil {
 						return
 					}
 				}
@@ -249,6 +265,92 @@ func (sws *serverWatchStream) sendLoop() {
 	}
 }
 
+// send sends the watch response over the gRPC stream, splitting its
+// events into fragments if the watcher asked for a fragment size.
+func (sws *serverWatchStream) send(wr *pb.WatchResponse) error {
+	sws.mu.Lock()
+	limit := sws.fragmentSize[storage.WatchID(wr.WatchId)]
+	sws.mu.Unlock()
+
+	for _, frag := range fragmentWatchResponse(wr, limit) {
+		if err := sws.gRPCStream.Send(frag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fragmentWatchResponse splits wr into responses whose events take at most
+// limit bytes each. The value of an event larger than limit is split as well:
+// the response holding its first part has PartialValue set, and each of the
+// following parts is sent as an event holding only the part in Kv.Value.
+// Every response but the last one has Fragment set.
+func fragmentWatchResponse(wr *pb.WatchResponse, limit int) []*pb.WatchResponse {
+	if limit <= 0 || wr.Size() <= limit {
+		return []*pb.WatchResponse{wr}
+	}
+
+	var frags []*pb.WatchResponse
+	var evs []*storagepb.Event
+	size := 0
+	flush := func(partial bool) {
+		frags = append(frags, &pb.WatchResponse{
+			Header:       wr.Header,
+			WatchId:      wr.WatchId,
+			Events:       evs,
+			Fragment:     true,
+			PartialValue: partial,
+		})
+		evs, size = nil, 0
+	}
+	for _, ev := range wr.Events {
+		evSize := ev.Size()
+		if len(evs) > 0 && size+evSize > limit {
+			flush(false)
+		}
+		if evSize <= limit || ev.Kv == nil || len(ev.Kv.Value) < 2 {
+			evs = append(evs, ev)
+			size += evSize
+			continue
+		}
+
+		// the first part keeps everything but the rest of the value
+		value := ev.Kv.Value
+		kv := *ev.Kv
+		kv.Value = value[:valuePartSize(limit, evSize-len(value), len(value))]
+		value = value[len(kv.Value):]
+		evs = append(evs, &storagepb.Event{Type: ev.Type, Kv: &kv})
+		for len(value) > 0 {
+			flush(true)
+			part := &storagepb.Event{Kv: &storagepb.KeyValue{Value: value}}
+			part.Kv.Value = value[:valuePartSize(limit, part.Size()-len(value), len(value))]
+			value = value[len(part.Kv.Value):]
+			evs = append(evs, part)
+		}
+		size = evs[0].Size()
+	}
+	frags = append(frags, &pb.WatchResponse{
+		Header:          wr.Header,
+		WatchId:         wr.WatchId,
+		Events:          evs,
+		CompactRevision: wr.CompactRevision,
+	})
+	return frags
+}
+
+// valuePartSize returns how many of the n value bytes left fit in limit
+// bytes next to overhead bytes of encoding; at least one byte is taken.
+func valuePartSize(limit, overhead, n int) int {
+	m := limit - overhead
+	if m < 1 {
+		m = 1
+	}
+	if m > n {
+		m = n
+	}
+	return m
+}
+
 func (sws *serverWatchStream) close() {
 	sws.watchStream.Close()
 	close(sws.closec)
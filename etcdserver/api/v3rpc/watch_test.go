@@ -0,0 +1,83 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/storage/storagepb"
+)
+
+func TestFragmentWatchResponse(t *testing.T) {
+	wr := &pb.WatchResponse{
+		Header:  &pb.ResponseHeader{Revision: 3},
+		WatchId: 1,
+		Events: []*storagepb.Event{
+			{Kv: &storagepb.KeyValue{Key: []byte("foo0"), Value: []byte("bar"), ModRevision: 3}},
+			{Kv: &storagepb.KeyValue{Key: []byte("foo1"), Value: bytes.Repeat([]byte("a"), 1000), ModRevision: 3}},
+			{Type: storagepb.DELETE, Kv: &storagepb.KeyValue{Key: []byte("foo2"), ModRevision: 3}},
+		},
+	}
+
+	for _, limit := range []int{1, 16, 100, 500, 999} {
+		frags := fragmentWatchResponse(wr, limit)
+		if len(frags) < 2 {
+			t.Errorf("limit %d: expected several fragments, got %d", limit, len(frags))
+			continue
+		}
+
+		// reassemble the fragments like the client does
+		var evs []*storagepb.Event
+		partial := false
+		for i, frag := range frags {
+			if frag.Fragment != (i != len(frags)-1) {
+				t.Errorf("limit %d: #%d: fragment = %v", limit, i, frag.Fragment)
+			}
+			for _, ev := range frag.Events {
+				if ev.Size() > limit && len(ev.Kv.Value) > 1 {
+					t.Errorf("limit %d: #%d: event size %d exceeds the limit", limit, i, ev.Size())
+				}
+			}
+			fevs := frag.Events
+			if partial {
+				last := evs[len(evs)-1]
+				last.Kv.Value = append(last.Kv.Value, fevs[0].Kv.Value...)
+				fevs = fevs[1:]
+			}
+			partial = frag.PartialValue
+			for _, ev := range fevs {
+				kv := *ev.Kv
+				if kv.Value != nil {
+					kv.Value = append([]byte{}, kv.Value...)
+				}
+				evs = append(evs, &storagepb.Event{Type: ev.Type, Kv: &kv})
+			}
+		}
+		if partial {
+			t.Errorf("limit %d: the last fragment has a partial value", limit)
+		}
+		if !reflect.DeepEqual(evs, wr.Events) {
+			t.Errorf("limit %d: reassembled events differ from %d events of the response", limit, len(wr.Events))
+		}
+	}
+
+	// a response within the limit is sent as is
+	if frags := fragmentWatchResponse(wr, wr.Size()); len(frags) != 1 || frags[0] != wr {
+		t.Errorf("expected the response itself, got %d fragments", len(frags))
+	}
+}
@@ -52,10 +52,43 @@ type ServerConfig struct {
 
 	V3demo                  bool
 	AutoCompactionRetention int
+	// TxnDedupTTL is how long the result of an idempotent txn is kept.
+	// Zero means DefaultTxnDedupTTL.
+	TxnDedupTTL time.Duration
+
+	// WALEncryptionKey is the key the WAL records are encrypted with.
+	// The WAL is not encrypted if it is empty.
+	WALEncryptionKey []byte
+
+	// GRPCAdditionalHeaders are sent as header metadata in the response
+	// of every gRPC call, e.g. to identify the deployment behind a proxy.
+	GRPCAdditionalHeaders map[string]string
+
+	// WALFsyncInterval is the minimum time between fsyncs of the WAL; see
+	// wal.WAL.SetSyncInterval. Zero fsyncs every write.
+	WALFsyncInterval time.Duration
 
 	StrictReconfigCheck bool
+	// ProxyRedirect makes a follower answer quorum v2 GETs with a
+	// redirect to the leader instead of serving them itself.
+	ProxyRedirect bool
 
 	EnablePprof bool
+
+	// MaxConcurrentStreams limits the concurrent gRPC streams, including
+	// unary RPCs, on each client connection. Zero means the gRPC default.
+	MaxConcurrentStreams uint32
+
+	// SnapshotCatchUpEntries is the number of raft entries kept in memory
+	// after a snapshot for slow followers to catch up from; a follower
+	// further behind is sent a snapshot. Zero uses the default.
+	SnapshotCatchUpEntries uint64
+
+	// CompactionBatchLimit is the number of keys a compaction deletes per
+	// step, and CompactionSleepInterval the pause between steps. Zero uses
+	// the defaults of the storage package.
+	CompactionBatchLimit    int
+	CompactionSleepInterval time.Duration
 }
 
 // VerifyBootstrap sanity-checks the initial config for bootstrap case
@@ -161,6 +194,9 @@ func (c *ServerConfig) print(initial bool) {
 	plog.Infof("heartbeat = %dms", c.TickMs)
 	plog.Infof("election = %dms", c.ElectionTicks*int(c.TickMs))
 	plog.Infof("snapshot count = %d", c.SnapCount)
+	if c.SnapshotCatchUpEntries != 0 {
+		plog.Infof("snapshot catch-up entries = %d", c.SnapshotCatchUpEntries)
+	}
 	if len(c.DiscoveryURL) != 0 {
 		plog.Infof("discovery URL= %s", c.DiscoveryURL)
 		if len(c.DiscoveryProxy) != 0 {
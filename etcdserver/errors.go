@@ -35,6 +35,10 @@ var (
 	ErrNotEnoughStartedMembers    = errors.New("etcdserver: re-configuration failed due to not enough started members")
 	ErrNoLeader                   = errors.New("etcdserver: no leader")
 	ErrRequestTooLarge            = errors.New("etcdserver: request is too large")
+	ErrKeyNotFound                = errors.New("etcdserver: key not found")
+	ErrStaleFencingToken          = errors.New("etcdserver: fencing key was lost since the fencing token was taken")
+	ErrTimeoutLeaderTransfer      = errors.New("etcdserver: request timed out, leader transfer took too long")
+	ErrNoFollowerToTransfer       = errors.New("etcdserver: no connected follower to transfer leadership to")
 )
 
 func isKeyNotFound(err error) bool {
@@ -65,11 +65,12 @@ func NewClientHandler(server *etcdserver.EtcdServer, timeout time.Duration) http
 	sec := auth.NewStore(server, timeout)
 
 	kh := &keysHandler{
-		sec:     sec,
-		server:  server,
-		cluster: server.Cluster(),
-		timer:   server,
-		timeout: timeout,
+		sec:      sec,
+		server:   server,
+		cluster:  server.Cluster(),
+		timer:    server,
+		timeout:  timeout,
+		redirect: server.IsProxyRedirectEnabled(),
 	}
 
 	sh := &statsHandler{
@@ -136,6 +137,8 @@ type keysHandler struct {
 	cluster etcdserver.Cluster
 	timer   etcdserver.RaftTimer
 	timeout time.Duration
+	// redirect makes quorum GETs on a follower redirect to the leader.
+	redirect bool
 }
 
 func (h *keysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -159,6 +162,9 @@ func (h *keysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		writeKeyNoAuth(w)
 		return
 	}
+	if h.redirect && rr.Method == "GET" && rr.Quorum && redirectToLeader(w, r, h.server, h.cluster) {
+		return
+	}
 	if !rr.Wait {
 		reportRequestReceived(rr)
 	}
@@ -185,6 +191,27 @@ func (h *keysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// redirectToLeader replies with a temporary redirect to the same request on
+// the leader's first client URL. It returns false if the server is the leader
+// or the leader is unknown, in which case the request should be served locally.
+func redirectToLeader(w http.ResponseWriter, r *http.Request, server etcdserver.Server, cluster etcdserver.Cluster) bool {
+	lead := server.Leader()
+	if lead == types.ID(raft.None) || lead == server.ID() {
+		return false
+	}
+	m := cluster.Member(lead)
+	if m == nil || len(m.ClientURLs) == 0 {
+		return false
+	}
+	u, err := url.Parse(m.ClientURLs[0])
+	if err != nil {
+		return false
+	}
+	u.Path, u.RawQuery = r.URL.Path, r.URL.RawQuery
+	http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
+	return true
+}
+
 type deprecatedMachinesHandler struct {
 	cluster etcdserver.Cluster
 }
@@ -1591,6 +1591,66 @@ func TestServeKeysEvent(t *testing.T) {
 	}
 }
 
+// followerServer is a resServer whose leader is member 2.
+type followerServer struct {
+	resServer
+}
+
+func (fs *followerServer) Leader() types.ID { return types.ID(2) }
+
+func TestServeKeysRedirect(t *testing.T) {
+	server := &followerServer{resServer{
+		etcdserver.Response{
+			Event: &store.Event{
+				Action: store.Get,
+				Node:   &store.NodeExtern{},
+			},
+		},
+	}}
+	cluster := &fakeCluster{
+		id: 1,
+		members: map[uint64]*etcdserver.Member{
+			1: {ID: 1, Attributes: etcdserver.Attributes{ClientURLs: []string{"http://10.0.0.1:2379"}}},
+			2: {ID: 2, Attributes: etcdserver.Attributes{ClientURLs: []string{"http://10.0.0.2:2379"}}},
+		},
+	}
+	tests := []struct {
+		query    string
+		redirect bool
+
+		wcode     int
+		wlocation string
+	}{
+		{"quorum=true", true, http.StatusTemporaryRedirect, "http://10.0.0.2:2379/v2/keys/foo?quorum=true"},
+		// not a quorum read
+		{"", true, http.StatusOK, ""},
+		// redirect disabled
+		{"quorum=true", false, http.StatusOK, ""},
+	}
+	for i, tt := range tests {
+		req := &http.Request{
+			Method: "GET",
+			URL:    testutil.MustNewURL(t, keysPrefix+"/foo?"+tt.query),
+		}
+		h := &keysHandler{
+			timeout:  time.Hour,
+			server:   server,
+			cluster:  cluster,
+			timer:    &dummyRaftTimer{},
+			redirect: tt.redirect,
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+
+		if rw.Code != tt.wcode {
+			t.Errorf("#%d: code = %d, want %d", i, rw.Code, tt.wcode)
+		}
+		if g := rw.Header().Get("Location"); g != tt.wlocation {
+			t.Errorf("#%d: location = %q, want %q", i, g, tt.wlocation)
+		}
+	}
+}
+
 func TestServeKeysWatch(t *testing.T) {
 	req := mustNewRequest(t, "/foo/bar")
 	ec := make(chan *store.Event)
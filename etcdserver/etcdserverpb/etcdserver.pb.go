@@ -14,6 +14,7 @@
 		Request
 		Metadata
 		InternalRaftRequest
+		IdempotentTxnRequest
 		EmptyResponse
 		ResponseHeader
 		RangeRequest
@@ -52,8 +53,13 @@
 		MemberListResponse
 		DefragmentRequest
 		DefragmentResponse
+		StatusRequest
+		StatusResponse
+		ReadinessRequest
+		ReadinessResponse
 		AuthEnableRequest
 		AuthDisableRequest
+		AuthStatusRequest
 		AuthenticateRequest
 		UserAddRequest
 		UserGetRequest
@@ -68,6 +74,7 @@
 		RoleRevokeRequest
 		AuthEnableResponse
 		AuthDisableResponse
+		AuthStatusResponse
 		AuthenticateResponse
 		UserAddResponse
 		UserGetResponse
@@ -22,22 +22,43 @@ var _ = math.Inf
 // An InternalRaftRequest is the union of all requests which can be
 // sent via raft.
 type InternalRaftRequest struct {
-	ID          uint64              `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	V2          *Request            `protobuf:"bytes,2,opt,name=v2" json:"v2,omitempty"`
-	Range       *RangeRequest       `protobuf:"bytes,3,opt,name=range" json:"range,omitempty"`
-	Put         *PutRequest         `protobuf:"bytes,4,opt,name=put" json:"put,omitempty"`
-	DeleteRange *DeleteRangeRequest `protobuf:"bytes,5,opt,name=delete_range" json:"delete_range,omitempty"`
-	Txn         *TxnRequest         `protobuf:"bytes,6,opt,name=txn" json:"txn,omitempty"`
-	Compaction  *CompactionRequest  `protobuf:"bytes,7,opt,name=compaction" json:"compaction,omitempty"`
-	LeaseCreate *LeaseCreateRequest `protobuf:"bytes,8,opt,name=lease_create" json:"lease_create,omitempty"`
-	LeaseRevoke *LeaseRevokeRequest `protobuf:"bytes,9,opt,name=lease_revoke" json:"lease_revoke,omitempty"`
-	AuthEnable  *AuthEnableRequest  `protobuf:"bytes,10,opt,name=auth_enable" json:"auth_enable,omitempty"`
+	ID            uint64                `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	V2            *Request              `protobuf:"bytes,2,opt,name=v2" json:"v2,omitempty"`
+	Range         *RangeRequest         `protobuf:"bytes,3,opt,name=range" json:"range,omitempty"`
+	Put           *PutRequest           `protobuf:"bytes,4,opt,name=put" json:"put,omitempty"`
+	DeleteRange   *DeleteRangeRequest   `protobuf:"bytes,5,opt,name=delete_range" json:"delete_range,omitempty"`
+	Txn           *TxnRequest           `protobuf:"bytes,6,opt,name=txn" json:"txn,omitempty"`
+	Compaction    *CompactionRequest    `protobuf:"bytes,7,opt,name=compaction" json:"compaction,omitempty"`
+	LeaseCreate   *LeaseCreateRequest   `protobuf:"bytes,8,opt,name=lease_create" json:"lease_create,omitempty"`
+	LeaseRevoke   *LeaseRevokeRequest   `protobuf:"bytes,9,opt,name=lease_revoke" json:"lease_revoke,omitempty"`
+	AuthEnable    *AuthEnableRequest    `protobuf:"bytes,10,opt,name=auth_enable" json:"auth_enable,omitempty"`
+	IdempotentTxn *IdempotentTxnRequest `protobuf:"bytes,11,opt,name=idempotent_txn" json:"idempotent_txn,omitempty"`
+	// fencing_token is the token the request was sent with, if any; the
+	// request fails unless fencing_key exists with that create revision.
+	FencingToken int64  `protobuf:"varint,12,opt,name=fencing_token,proto3" json:"fencing_token,omitempty"`
+	FencingKey   []byte `protobuf:"bytes,13,opt,name=fencing_key,proto3" json:"fencing_key,omitempty"`
 }
 
 func (m *InternalRaftRequest) Reset()         { *m = InternalRaftRequest{} }
 func (m *InternalRaftRequest) String() string { return proto.CompactTextString(m) }
 func (*InternalRaftRequest) ProtoMessage()    {}
 
+// An IdempotentTxnRequest is a txn that is applied at most once per id
+// until its ttl expires.
+type IdempotentTxnRequest struct {
+	// id is the client chosen deduplication id of the txn.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// time is the proposal time in unix nanoseconds.
+	Time int64 `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	// ttl is how long in nanoseconds the txn result is kept for id.
+	Ttl int64       `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	Txn *TxnRequest `protobuf:"bytes,4,opt,name=txn" json:"txn,omitempty"`
+}
+
+func (m *IdempotentTxnRequest) Reset()         { *m = IdempotentTxnRequest{} }
+func (m *IdempotentTxnRequest) String() string { return proto.CompactTextString(m) }
+func (*IdempotentTxnRequest) ProtoMessage()    {}
+
 type EmptyResponse struct {
 }
 
@@ -47,6 +68,7 @@ func (*EmptyResponse) ProtoMessage()    {}
 
 func init() {
 	proto.RegisterType((*InternalRaftRequest)(nil), "etcdserverpb.InternalRaftRequest")
+	proto.RegisterType((*IdempotentTxnRequest)(nil), "etcdserverpb.IdempotentTxnRequest")
 	proto.RegisterType((*EmptyResponse)(nil), "etcdserverpb.EmptyResponse")
 }
 func (m *InternalRaftRequest) Marshal() (data []byte, err error) {
@@ -159,6 +181,73 @@ func (m *InternalRaftRequest) MarshalTo(data []byte) (int, error) {
 		}
 		i += n9
 	}
+	if m.IdempotentTxn != nil {
+		data[i] = 0x5a
+		i++
+		i = encodeVarintRaftInternal(data, i, uint64(m.IdempotentTxn.Size()))
+		n10, err := m.IdempotentTxn.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n10
+	}
+	if m.FencingToken != 0 {
+		data[i] = 0x60
+		i++
+		i = encodeVarintRaftInternal(data, i, uint64(m.FencingToken))
+	}
+	if m.FencingKey != nil {
+		if len(m.FencingKey) > 0 {
+			data[i] = 0x6a
+			i++
+			i = encodeVarintRaftInternal(data, i, uint64(len(m.FencingKey)))
+			i += copy(data[i:], m.FencingKey)
+		}
+	}
+	return i, nil
+}
+
+func (m *IdempotentTxnRequest) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *IdempotentTxnRequest) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Id) > 0 {
+		data[i] = 0xa
+		i++
+		i = encodeVarintRaftInternal(data, i, uint64(len(m.Id)))
+		i += copy(data[i:], m.Id)
+	}
+	if m.Time != 0 {
+		data[i] = 0x10
+		i++
+		i = encodeVarintRaftInternal(data, i, uint64(m.Time))
+	}
+	if m.Ttl != 0 {
+		data[i] = 0x18
+		i++
+		i = encodeVarintRaftInternal(data, i, uint64(m.Ttl))
+	}
+	if m.Txn != nil {
+		data[i] = 0x22
+		i++
+		i = encodeVarintRaftInternal(data, i, uint64(m.Txn.Size()))
+		n11, err := m.Txn.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n11
+	}
 	return i, nil
 }
 
@@ -249,6 +338,39 @@ func (m *InternalRaftRequest) Size() (n int) {
 		l = m.AuthEnable.Size()
 		n += 1 + l + sovRaftInternal(uint64(l))
 	}
+	if m.IdempotentTxn != nil {
+		l = m.IdempotentTxn.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.FencingToken != 0 {
+		n += 1 + sovRaftInternal(uint64(m.FencingToken))
+	}
+	if m.FencingKey != nil {
+		l = len(m.FencingKey)
+		if l > 0 {
+			n += 1 + l + sovRaftInternal(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *IdempotentTxnRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Id)
+	if l > 0 {
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.Time != 0 {
+		n += 1 + sovRaftInternal(uint64(m.Time))
+	}
+	if m.Ttl != 0 {
+		n += 1 + sovRaftInternal(uint64(m.Ttl))
+	}
+	if m.Txn != nil {
+		l = m.Txn.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
 	return n
 }
 
@@ -616,6 +738,239 @@ func (m *InternalRaftRequest) Unmarshal(data []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IdempotentTxn", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.IdempotentTxn == nil {
+				m.IdempotentTxn = &IdempotentTxnRequest{}
+			}
+			if err := m.IdempotentTxn.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FencingToken", wireType)
+			}
+			m.FencingToken = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.FencingToken |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FencingKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FencingKey = append(m.FencingKey[:0], data[iNdEx:postIndex]...)
+			if m.FencingKey == nil {
+				m.FencingKey = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRaftInternal(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IdempotentTxnRequest) Unmarshal(data []byte) error {
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRaftInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IdempotentTxnRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IdempotentTxnRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Id = string(data[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Time", wireType)
+			}
+			m.Time = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Time |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ttl", wireType)
+			}
+			m.Ttl = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Ttl |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Txn", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Txn == nil {
+				m.Txn = &TxnRequest{}
+			}
+			if err := m.Txn.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRaftInternal(data[iNdEx:])
@@ -0,0 +1,73 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"bytes"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	dstorage "github.com/coreos/etcd/storage"
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+type fencingTokenKeyType struct{}
+
+// fencingToken is the key of a lock or election holder and the create
+// revision the holder put it at.
+type fencingToken struct {
+	key   []byte
+	token int64
+}
+
+// WithFencingToken returns a context whose writes fail with
+// ErrStaleFencingToken unless key exists with create revision token, that
+// is, unless the holder that put key still holds it.
+func WithFencingToken(ctx context.Context, key []byte, token int64) context.Context {
+	return context.WithValue(ctx, fencingTokenKeyType{}, fencingToken{key, token})
+}
+
+func fencingTokenFromContext(ctx context.Context) (key []byte, token int64) {
+	ft, _ := ctx.Value(fencingTokenKeyType{}).(fencingToken)
+	return ft.key, ft.token
+}
+
+// checkFencingToken returns a result failed with ErrStaleFencingToken if r
+// is a write whose fencing key is gone or was put again since its token
+// was taken, or nil if r may be applied. Only the fencing key is looked at,
+// so the writes of other clients never fence the holder.
+func checkFencingToken(kv dstorage.KV, r *pb.InternalRaftRequest) *applyResult {
+	if r.FencingToken == 0 {
+		return nil
+	}
+	// typed nil responses, as the callers assert the response type
+	var resp proto.Message
+	switch {
+	case r.Put != nil:
+		resp = (*pb.PutResponse)(nil)
+	case r.DeleteRange != nil:
+		resp = (*pb.DeleteRangeResponse)(nil)
+	case r.Txn != nil, r.IdempotentTxn != nil:
+		resp = (*pb.TxnResponse)(nil)
+	default:
+		return nil
+	}
+
+	kvs, _, err := kv.Range(r.FencingKey, nil, 1, 0)
+	if err != nil || len(kvs) == 0 || !bytes.Equal(kvs[0].Key, r.FencingKey) || kvs[0].CreateRevision != r.FencingToken {
+		return &applyResult{resp: resp, err: ErrStaleFencingToken}
+	}
+	return nil
+}
@@ -61,6 +61,17 @@ const (
 	privateDirMode = 0700
 
 	DefaultSnapCount = 10000
+	// MinSnapshotCount is the lowest snapshot count etcd accepts without
+	// --unsafe-allow-low-snapshot-count; below it, snapshots under load
+	// slow down writes considerably.
+	MinSnapshotCount = 100
+
+	// DefaultSnapshotCatchUpEntries is the number of entries for a slow
+	// follower to catch-up after compacting the raft storage entries.
+	// We expect the follower has a millisecond level latency with the leader.
+	// The max throughput is around 10K. Keep a 5K entries is enough for helping
+	// follower to catch up.
+	DefaultSnapshotCatchUpEntries = 5000
 
 	StoreClusterPrefix = "/0"
 	StoreKeysPrefix    = "/1"
@@ -161,6 +172,9 @@ type EtcdServer struct {
 
 	cfg       *ServerConfig
 	snapCount uint64
+	// snapshotCatchUpEntries is the number of raft entries kept after a
+	// snapshot; a follower further behind is sent the snapshot.
+	snapshotCatchUpEntries uint64
 
 	w          wait.Wait
 	stop       chan struct{}
@@ -336,6 +350,8 @@ func NewServer(cfg *ServerConfig) (*EtcdServer, error) {
 		return nil, fmt.Errorf("unsupported bootstrap config")
 	}
 
+	w.SetSyncInterval(cfg.WALFsyncInterval)
+
 	if terr := fileutil.TouchDirAll(cfg.MemberDir()); terr != nil {
 		return nil, fmt.Errorf("cannot access member directory: %v", terr)
 	}
@@ -368,13 +384,20 @@ func NewServer(cfg *ServerConfig) (*EtcdServer, error) {
 		reqIDGen:      idutil.NewGenerator(uint16(id), time.Now()),
 		forceVersionC: make(chan struct{}),
 		msgSnapC:      make(chan raftpb.Message, maxInFlightMsgSnap),
+
+		snapshotCatchUpEntries: cfg.SnapshotCatchUpEntries,
 	}
 
 	if cfg.V3demo {
 		srv.be = backend.NewDefaultBackend(path.Join(cfg.SnapDir(), databaseFilename))
 		srv.lessor = lease.NewLessor(srv.be)
-		srv.kv = dstorage.New(srv.be, srv.lessor, &srv.consistIndex)
+		scfg := dstorage.StoreConfig{
+			CompactionBatchLimit:    cfg.CompactionBatchLimit,
+			CompactionSleepInterval: cfg.CompactionSleepInterval,
+		}
+		srv.kv = dstorage.New(srv.be, srv.lessor, &srv.consistIndex, scfg)
 		srv.authStore = auth.NewAuthStore(srv.be)
+		createTxnDedupBucket(srv.be)
 		if h := cfg.AutoCompactionRetention; h != 0 {
 			srv.compactor = compactor.NewPeriodic(h, srv.kv, srv)
 			srv.compactor.Run()
@@ -433,6 +456,9 @@ func (s *EtcdServer) start() {
 		plog.Infof("set snapshot count to default %d", DefaultSnapCount)
 		s.snapCount = DefaultSnapCount
 	}
+	if s.snapshotCatchUpEntries == 0 {
+		s.snapshotCatchUpEntries = DefaultSnapshotCatchUpEntries
+	}
 	s.w = wait.New()
 	s.done = make(chan struct{})
 	s.stop = make(chan struct{})
@@ -625,6 +651,8 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, apply *apply) {
 		if s.authStore != nil {
 			s.authStore.Recover(newbe)
 		}
+
+		createTxnDedupBucket(newbe)
 	}
 	if err := s.store.Recovery(apply.snapshot.Data); err != nil {
 		plog.Panicf("recovery store error: %v", err)
@@ -697,6 +725,51 @@ func (s *EtcdServer) Stop() {
 	<-s.done
 }
 
+// MoveLeader transfers the leadership from lead to transferee. It returns
+// once the transferee is the leader, or ErrTimeoutLeaderTransfer once ctx
+// is done.
+func (s *EtcdServer) MoveLeader(ctx context.Context, lead, transferee uint64) error {
+	now := time.Now()
+	interval := time.Duration(s.cfg.TickMs) * time.Millisecond
+
+	plog.Infof("%s starts leadership transfer from %s to %s", s.ID(), types.ID(lead), types.ID(transferee))
+	s.r.TransferLeadership(ctx, lead, transferee)
+	for s.Lead() != transferee {
+		select {
+		case <-ctx.Done():
+			return ErrTimeoutLeaderTransfer
+		case <-s.done:
+			return ErrStopped
+		case <-time.After(interval):
+		}
+	}
+	plog.Infof("%s finished leadership transfer from %s to %s (took %v)", s.ID(), types.ID(lead), types.ID(transferee), time.Since(now))
+	return nil
+}
+
+// TransferLeadership moves the leadership to a random follower the member
+// is connected to, if the member is the leader, waiting at most timeout
+// for the follower to take over.
+func (s *EtcdServer) TransferLeadership(timeout time.Duration) error {
+	if s.Leader() != s.ID() {
+		return nil
+	}
+	var ids []types.ID
+	for _, m := range s.cluster.Members() {
+		if m.ID != s.ID() && !s.r.transport.ActiveSince(m.ID).IsZero() {
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return ErrNoFollowerToTransfer
+	}
+	transferee := ids[rand.Intn(len(ids))]
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.MoveLeader(ctx, uint64(s.ID()), uint64(transferee))
+}
+
 func (s *EtcdServer) stopWithDelay(d time.Duration, err error) {
 	select {
 	case <-time.After(d):
@@ -850,6 +923,13 @@ func (s *EtcdServer) Leader() types.ID { return types.ID(s.Lead()) }
 
 func (s *EtcdServer) IsPprofEnabled() bool { return s.cfg.EnablePprof }
 
+func (s *EtcdServer) IsProxyRedirectEnabled() bool { return s.cfg.ProxyRedirect }
+
+func (s *EtcdServer) MaxConcurrentStreams() uint32 { return s.cfg.MaxConcurrentStreams }
+
+// GRPCAdditionalHeaders returns the headers added to every gRPC response.
+func (s *EtcdServer) GRPCAdditionalHeaders() map[string]string { return s.cfg.GRPCAdditionalHeaders }
+
 // configure sends a configuration change through consensus and
 // then waits for it to be applied to the server. It
 // will block until the change is performed or there is an error.
@@ -1196,8 +1276,8 @@ func (s *EtcdServer) snapshot(snapi uint64, confState raftpb.ConfState) {
 
 		// keep some in memory log entries for slow followers.
 		compacti := uint64(1)
-		if snapi > numberOfCatchUpEntries {
-			compacti = snapi - numberOfCatchUpEntries
+		if snapi > s.snapshotCatchUpEntries {
+			compacti = snapi - s.snapshotCatchUpEntries
 		}
 		err = s.r.raftStorage.Compact(compacti)
 		if err != nil {
@@ -855,6 +855,41 @@ func TestSnapshot(t *testing.T) {
 	}
 }
 
+// snapshot should keep snapshotCatchUpEntries entries in the raft log
+func TestSnapshotCatchUpEntries(t *testing.T) {
+	s := raft.NewMemoryStorage()
+	ents := make([]raftpb.Entry, 100)
+	for i := range ents {
+		ents[i] = raftpb.Entry{Index: uint64(i + 1)}
+	}
+	s.Append(ents)
+	p := mockstorage.NewStorageRecorderStream("")
+	srv := &EtcdServer{
+		cfg:                    &ServerConfig{},
+		snapshotCatchUpEntries: 20,
+		r: raftNode{
+			Node:        newNodeNop(),
+			raftStorage: s,
+			storage:     p,
+		},
+		store: mockstore.NewRecorder(),
+	}
+	srv.snapshot(90, raftpb.ConfState{Nodes: []uint64{1}})
+	if _, err := p.Wait(1); err != nil {
+		t.Fatal(err)
+	}
+
+	wfirst := uint64(90 - 20 + 1)
+	for i := 0; i < 100; i++ {
+		if first, _ := s.FirstIndex(); first == wfirst {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	first, _ := s.FirstIndex()
+	t.Errorf("first index = %d, want %d", first, wfirst)
+}
+
 // Applied > SnapCount should trigger a SaveSnap event
 func TestTriggerSnap(t *testing.T) {
 	snapc := 10
@@ -940,7 +975,7 @@ func TestConcurrentApplyAndSnapshotV3(t *testing.T) {
 	defer func() {
 		os.RemoveAll(tmpPath)
 	}()
-	s.kv = dstorage.New(be, &lease.FakeLessor{}, &s.consistIndex)
+	s.kv = dstorage.New(be, &lease.FakeLessor{}, &s.consistIndex, dstorage.StoreConfig{})
 	s.be = be
 
 	s.start()
@@ -1424,6 +1459,8 @@ func (n *nodeRecorder) ReportUnreachable(id uint64) {}
 
 func (n *nodeRecorder) ReportSnapshot(id uint64, status raft.SnapshotStatus) {}
 
+func (n *nodeRecorder) TransferLeadership(ctx context.Context, lead, transferee uint64) {}
+
 func (n *nodeRecorder) Compact(index uint64, nodes []uint64, d []byte) {
 	n.Record(testutil.Action{Name: "Compact"})
 }
@@ -73,7 +73,7 @@ func (st *storage) SaveSnap(snap raftpb.Snapshot) error {
 	return nil
 }
 
-func readWAL(waldir string, snap walpb.Snapshot) (w *wal.WAL, id, cid types.ID, st raftpb.HardState, ents []raftpb.Entry) {
+func readWAL(waldir string, snap walpb.Snapshot, key []byte) (w *wal.WAL, id, cid types.ID, st raftpb.HardState, ents []raftpb.Entry) {
 	var (
 		err       error
 		wmetadata []byte
@@ -81,7 +81,7 @@ func readWAL(waldir string, snap walpb.Snapshot) (w *wal.WAL, id, cid types.ID,
 
 	repaired := false
 	for {
-		if w, err = wal.Open(waldir, snap); err != nil {
+		if w, err = wal.OpenWithKey(waldir, snap, key); err != nil {
 			plog.Fatalf("open wal error: %v", err)
 		}
 		if wmetadata, st, ents, err = w.ReadAll(); err != nil {
@@ -90,7 +90,7 @@ func readWAL(waldir string, snap walpb.Snapshot) (w *wal.WAL, id, cid types.ID,
 			if repaired || err != io.ErrUnexpectedEOF {
 				plog.Fatalf("read wal error (%v) and cannot be repaired", err)
 			}
-			if !wal.Repair(waldir) {
+			if !wal.RepairWithKey(waldir, key) {
 				plog.Fatalf("WAL error (%v) cannot be repaired", err)
 			} else {
 				plog.Infof("repaired WAL error (%v)", err)
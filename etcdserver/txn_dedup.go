@@ -0,0 +1,114 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"encoding/binary"
+	"time"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/lease"
+	dstorage "github.com/coreos/etcd/storage"
+	"github.com/coreos/etcd/storage/backend"
+)
+
+const (
+	// DefaultTxnDedupTTL is how long the result of an idempotent txn is
+	// kept when the server is not configured with a TxnDedupTTL.
+	DefaultTxnDedupTTL = 5 * time.Minute
+)
+
+var (
+	// txnDedupBucketName maps a txn id to its expiry time and response.
+	txnDedupBucketName = []byte("txnDedup")
+	// txnDedupExpiryBucketName indexes the txn ids by expiry time, keyed
+	// by the big-endian expiry time followed by the id.
+	txnDedupExpiryBucketName = []byte("txnDedupExpiry")
+)
+
+func createTxnDedupBucket(be backend.Backend) {
+	tx := be.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket(txnDedupBucketName)
+	tx.UnsafeCreateBucket(txnDedupExpiryBucketName)
+	tx.Unlock()
+	be.ForceCommit()
+}
+
+// applyIdempotentTxn applies the txn unless a txn with the same id has been
+// applied and not yet expired, in which case the saved response is returned.
+// Expiry is computed from the proposal time in the request, so every member
+// makes the same decision.
+func applyIdempotentTxn(be backend.Backend, kv dstorage.KV, le lease.Lessor, r *pb.IdempotentTxnRequest) (*pb.TxnResponse, error) {
+	id := []byte(r.Id)
+	tx := be.BatchTx()
+	tx.Lock()
+	_, vs := tx.UnsafeRange(txnDedupBucketName, id, nil, 0)
+	tx.Unlock()
+	if len(vs) != 0 {
+		if expire, resp := decodeTxnDedupEntry(vs[0]); r.Time < expire {
+			return resp, nil
+		}
+	}
+
+	resp, err := applyTxn(kv, le, r.Txn)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Lock()
+	defer tx.Unlock()
+	expireTxnDedupEntries(tx, r.Time)
+	expire := r.Time + r.Ttl
+	tx.UnsafePut(txnDedupBucketName, id, encodeTxnDedupEntry(expire, resp))
+	tx.UnsafePut(txnDedupExpiryBucketName, txnDedupExpiryKey(expire, id), []byte{})
+	return resp, nil
+}
+
+// expireTxnDedupEntries deletes the entries that expire at or before now,
+// walking the expiry index from its oldest entry.
+func expireTxnDedupEntries(tx backend.BatchTx, now int64) {
+	keys, _ := tx.UnsafeRange(txnDedupExpiryBucketName, txnDedupExpiryKey(0, nil), txnDedupExpiryKey(now+1, nil), 0)
+	for _, k := range keys {
+		tx.UnsafeDelete(txnDedupBucketName, k[8:])
+		tx.UnsafeDelete(txnDedupExpiryBucketName, k)
+	}
+}
+
+// txnDedupExpiryKey returns the expiry index key of id expiring at expire.
+func txnDedupExpiryKey(expire int64, id []byte) []byte {
+	b := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(b, uint64(expire))
+	return append(b, id...)
+}
+
+// encodeTxnDedupEntry encodes the expiry time followed by the txn response.
+func encodeTxnDedupEntry(expire int64, resp *pb.TxnResponse) []byte {
+	data, err := resp.Marshal()
+	if err != nil {
+		plog.Panicf("marshal txn response should never fail (%v)", err)
+	}
+	b := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(b, uint64(expire))
+	return append(b, data...)
+}
+
+func decodeTxnDedupEntry(b []byte) (int64, *pb.TxnResponse) {
+	resp := &pb.TxnResponse{}
+	if err := resp.Unmarshal(b[8:]); err != nil {
+		plog.Panicf("unmarshal txn response should never fail (%v)", err)
+	}
+	return int64(binary.BigEndian.Uint64(b[:8])), resp
+}
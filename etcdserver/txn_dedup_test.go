@@ -0,0 +1,89 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/lease"
+	dstorage "github.com/coreos/etcd/storage"
+	"github.com/coreos/etcd/storage/backend"
+)
+
+func TestApplyIdempotentTxnExpiry(t *testing.T) {
+	be, tmpPath := backend.NewDefaultTmpBackend()
+	createTxnDedupBucket(be)
+	le := &lease.FakeLessor{}
+	kv := dstorage.NewStore(be, le)
+	defer func() {
+		kv.Close()
+		be.Close()
+		os.Remove(tmpPath)
+	}()
+
+	apply := func(id string, now int64) *pb.TxnResponse {
+		txn := &pb.TxnRequest{Success: []*pb.RequestUnion{{
+			Request: &pb.RequestUnion_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("foo"), Value: []byte(id)}},
+		}}}
+		resp, err := applyIdempotentTxn(be, kv, le, &pb.IdempotentTxnRequest{Id: id, Time: now, Ttl: 10, Txn: txn})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	ids := func() (ids, expiry []string) {
+		tx := be.BatchTx()
+		tx.Lock()
+		defer tx.Unlock()
+		ks, _ := tx.UnsafeRange(txnDedupBucketName, []byte{0}, []byte{0xff}, 0)
+		for _, k := range ks {
+			ids = append(ids, string(k))
+		}
+		ks, _ = tx.UnsafeRange(txnDedupExpiryBucketName, txnDedupExpiryKey(0, nil), txnDedupExpiryKey(1<<62, nil), 0)
+		for _, k := range ks {
+			expiry = append(expiry, string(k[8:]))
+		}
+		return ids, expiry
+	}
+
+	resp := apply("a", 0)
+	apply("b", 5)
+	if rresp := apply("a", 9); !reflect.DeepEqual(rresp, resp) {
+		t.Fatalf("resubmitted response = %+v, want %+v", rresp, resp)
+	}
+	if rev := kv.Rev(); rev != 3 {
+		t.Fatalf("rev = %d, want 3 for two applied txns", rev)
+	}
+
+	// a expires at 10, b at 15
+	apply("c", 12)
+	wids := []string{"b", "c"}
+	if gids, gexpiry := ids(); !reflect.DeepEqual(gids, wids) || !reflect.DeepEqual(gexpiry, wids) {
+		t.Fatalf("ids = %v, expiry index = %v, want %v", gids, gexpiry, wids)
+	}
+
+	// an expired id is applied again and indexed by its new expiry
+	apply("c", 22)
+	if rev := kv.Rev(); rev != 5 {
+		t.Fatalf("rev = %d, want 5 for the expired txn applied again", rev)
+	}
+	wids = []string{"c"}
+	if gids, gexpiry := ids(); !reflect.DeepEqual(gids, wids) || !reflect.DeepEqual(gexpiry, wids) {
+		t.Fatalf("ids = %v, expiry index = %v, want %v", gids, gexpiry, wids)
+	}
+}
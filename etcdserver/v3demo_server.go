@@ -42,6 +42,9 @@ type RaftKV interface {
 	Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse, error)
 	DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error)
 	Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse, error)
+	// IdempotentTxn applies the txn at most once for the given id; a txn
+	// resubmitted with the same id returns the response of the first apply.
+	IdempotentTxn(ctx context.Context, id string, r *pb.TxnRequest) (*pb.TxnResponse, error)
 	Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error)
 	Hash(ctx context.Context, r *pb.HashRequest) (*pb.HashResponse, error)
 }
@@ -59,6 +62,7 @@ type Lessor interface {
 
 type Authenticator interface {
 	AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*pb.AuthEnableResponse, error)
+	AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*pb.AuthStatusResponse, error)
 }
 
 func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
@@ -97,6 +101,19 @@ func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse
 	return result.resp.(*pb.TxnResponse), result.err
 }
 
+func (s *EtcdServer) IdempotentTxn(ctx context.Context, id string, r *pb.TxnRequest) (*pb.TxnResponse, error) {
+	ttl := s.cfg.TxnDedupTTL
+	if ttl == 0 {
+		ttl = DefaultTxnDedupTTL
+	}
+	ir := &pb.IdempotentTxnRequest{Id: id, Time: time.Now().UnixNano(), Ttl: int64(ttl), Txn: r}
+	result, err := s.processInternalRaftRequest(ctx, pb.InternalRaftRequest{IdempotentTxn: ir})
+	if err != nil {
+		return nil, err
+	}
+	return result.resp.(*pb.TxnResponse), result.err
+}
+
 func (s *EtcdServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.CompactionResponse, error) {
 	result, err := s.processInternalRaftRequest(ctx, pb.InternalRaftRequest{Compaction: r})
 	if err != nil {
@@ -114,6 +131,13 @@ func (s *EtcdServer) Compact(ctx context.Context, r *pb.CompactionRequest) (*pb.
 }
 
 func (s *EtcdServer) Hash(ctx context.Context, r *pb.HashRequest) (*pb.HashResponse, error) {
+	if r.Revision != 0 {
+		h, rev, compactRev, err := s.kv.HashByRev(r.Revision)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.HashResponse{Header: &pb.ResponseHeader{Revision: rev}, Hash: h, CompactRevision: compactRev}, nil
+	}
 	h, err := s.be.Hash()
 	if err != nil {
 		return nil, err
@@ -185,6 +209,11 @@ func (s *EtcdServer) AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*
 	return result.resp.(*pb.AuthEnableResponse), result.err
 }
 
+// AuthStatus reports the auth state applied by this member.
+func (s *EtcdServer) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*pb.AuthStatusResponse, error) {
+	return &pb.AuthStatusResponse{Enabled: s.AuthStore().IsAuthEnabled()}, nil
+}
+
 type applyResult struct {
 	resp proto.Message
 	err  error
@@ -192,6 +221,7 @@ type applyResult struct {
 
 func (s *EtcdServer) processInternalRaftRequest(ctx context.Context, r pb.InternalRaftRequest) (*applyResult, error) {
 	r.ID = s.reqIDGen.Next()
+	r.FencingKey, r.FencingToken = fencingTokenFromContext(ctx)
 
 	data, err := r.Marshal()
 	if err != nil {
@@ -222,6 +252,11 @@ func (s *EtcdServer) Watchable() dstorage.Watchable {
 	return s.getKV()
 }
 
+// KV returns the key-value store of the etcdserver.
+func (s *EtcdServer) KV() dstorage.ConsistentWatchableKV {
+	return s.getKV()
+}
+
 const (
 	// noTxn is an invalid txn ID.
 	// To apply with independent Range, Put, Delete, you can pass noTxn
@@ -233,6 +268,10 @@ func (s *EtcdServer) applyV3Request(r *pb.InternalRaftRequest) interface{} {
 	kv := s.getKV()
 	le := s.lessor
 
+	if ar := checkFencingToken(kv, r); ar != nil {
+		return ar
+	}
+
 	ar := &applyResult{}
 
 	switch {
@@ -244,6 +283,8 @@ func (s *EtcdServer) applyV3Request(r *pb.InternalRaftRequest) interface{} {
 		ar.resp, ar.err = applyDeleteRange(noTxn, kv, r.DeleteRange)
 	case r.Txn != nil:
 		ar.resp, ar.err = applyTxn(kv, le, r.Txn)
+	case r.IdempotentTxn != nil:
+		ar.resp, ar.err = applyIdempotentTxn(s.Backend(), kv, le, r.IdempotentTxn)
 	case r.Compaction != nil:
 		ar.resp, ar.err = applyCompaction(kv, r.Compaction)
 	case r.LeaseCreate != nil:
@@ -266,19 +307,45 @@ func applyPut(txnID int64, kv dstorage.KV, le lease.Lessor, p *pb.PutRequest) (*
 		rev int64
 		err error
 	)
+	val, leaseID := p.Value, lease.LeaseID(p.Lease)
+	if p.IgnoreValue || p.IgnoreLease || p.PrevKv {
+		var kvs []storagepb.KeyValue
+		if txnID != noTxn {
+			kvs, _, err = kv.TxnRange(txnID, p.Key, nil, 1, 0)
+		} else {
+			kvs, _, err = kv.Range(p.Key, nil, 1, 0)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(kvs) == 0 {
+			if p.IgnoreValue || p.IgnoreLease {
+				return nil, ErrKeyNotFound
+			}
+		} else {
+			if p.IgnoreValue {
+				val = kvs[0].Value
+			}
+			if p.IgnoreLease {
+				leaseID = lease.LeaseID(kvs[0].Lease)
+			}
+			if p.PrevKv {
+				resp.PrevKv = &kvs[0]
+			}
+		}
+	}
 	if txnID != noTxn {
-		rev, err = kv.TxnPut(txnID, p.Key, p.Value, lease.LeaseID(p.Lease))
+		rev, err = kv.TxnPut(txnID, p.Key, val, leaseID)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		leaseID := lease.LeaseID(p.Lease)
 		if leaseID != lease.NoLease {
 			if l := le.Lookup(leaseID); l == nil {
 				return nil, lease.ErrLeaseNotFound
 			}
 		}
-		rev = kv.Put(p.Key, p.Value, leaseID)
+		rev = kv.Put(p.Key, val, leaseID)
 	}
 	resp.Header.Revision = rev
 	return resp, nil
@@ -338,8 +405,8 @@ func applyRange(txnID int64, kv dstorage.KV, r *pb.RangeRequest) (*pb.RangeRespo
 	}
 
 	limit := r.Limit
-	if r.SortOrder != pb.RangeRequest_NONE {
-		// fetch everything; sort and truncate afterwards
+	if r.SortOrder != pb.RangeRequest_NONE || r.MinModRevision > 0 || r.CountOnly {
+		// fetch everything; filter, sort and truncate afterwards
 		limit = 0
 	}
 	if limit > 0 {
@@ -359,6 +426,22 @@ func applyRange(txnID int64, kv dstorage.KV, r *pb.RangeRequest) (*pb.RangeRespo
 		}
 	}
 
+	if r.MinModRevision > 0 {
+		fkvs := kvs[:0]
+		for _, kv := range kvs {
+			if kv.ModRevision >= r.MinModRevision {
+				fkvs = append(fkvs, kv)
+			}
+		}
+		kvs = fkvs
+	}
+
+	if r.CountOnly {
+		resp.Header.Revision = rev
+		resp.Count = int64(len(kvs))
+		return resp, nil
+	}
+
 	if r.SortOrder != pb.RangeRequest_NONE {
 		var sorter sort.Interface
 		switch {
@@ -388,6 +471,9 @@ func applyRange(txnID int64, kv dstorage.KV, r *pb.RangeRequest) (*pb.RangeRespo
 
 	resp.Header.Revision = rev
 	for i := range kvs {
+		if r.KeysOnly {
+			kvs[i].Value = nil
+		}
 		resp.Kvs = append(resp.Kvs, &kvs[i])
 	}
 	return resp, nil
@@ -459,6 +545,29 @@ func checkRequestRange(kv dstorage.KV, reqs []*pb.RequestUnion) error {
 	return nil
 }
 
+// checkRequestIgnore ensures every key put with IgnoreValue or IgnoreLease
+// exists.
+func checkRequestIgnore(kv dstorage.KV, reqs []*pb.RequestUnion) error {
+	for _, requ := range reqs {
+		tv, ok := requ.Request.(*pb.RequestUnion_RequestPut)
+		if !ok {
+			continue
+		}
+		preq := tv.RequestPut
+		if preq == nil || !(preq.IgnoreValue || preq.IgnoreLease) {
+			continue
+		}
+		kvs, _, err := kv.Range(preq.Key, nil, 1, 0)
+		if err != nil {
+			return err
+		}
+		if len(kvs) == 0 {
+			return ErrKeyNotFound
+		}
+	}
+	return nil
+}
+
 func applyTxn(kv dstorage.KV, le lease.Lessor, rt *pb.TxnRequest) (*pb.TxnResponse, error) {
 	var revision int64
 
@@ -482,6 +591,9 @@ func applyTxn(kv dstorage.KV, le lease.Lessor, rt *pb.TxnRequest) (*pb.TxnRespon
 	if err := checkRequestRange(kv, reqs); err != nil {
 		return nil, err
 	}
+	if err := checkRequestIgnore(kv, reqs); err != nil {
+		return nil, err
+	}
 
 	// When executing the operations of txn, we need to hold the txn lock.
 	// So the reader will not see any intermediate results.
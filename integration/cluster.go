@@ -33,6 +33,7 @@ import (
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/coreos/etcd/client"
 	"github.com/coreos/etcd/clientv3"
@@ -74,6 +75,13 @@ type ClusterConfig struct {
 	DiscoveryURL string
 	UseV3        bool
 	UseGRPC      bool
+	// MaxConcurrentStreams limits the gRPC streams per client connection.
+	MaxConcurrentStreams uint32
+	// GRPCAdditionalHeaders are added to every gRPC response.
+	GRPCAdditionalHeaders map[string]string
+	// ServerVersion is the version the members report in Status; empty
+	// means the current version.
+	ServerVersion string
 }
 
 type cluster struct {
@@ -200,6 +208,9 @@ func (c *cluster) mustNewMember(t *testing.T) *member {
 	m := mustNewMember(t, name, c.cfg.PeerTLS, c.cfg.ClientTLS)
 	m.DiscoveryURL = c.cfg.DiscoveryURL
 	m.V3demo = c.cfg.UseV3
+	m.MaxConcurrentStreams = c.cfg.MaxConcurrentStreams
+	m.GRPCAdditionalHeaders = c.cfg.GRPCAdditionalHeaders
+	m.serverVersion = c.cfg.ServerVersion
 	if c.cfg.UseGRPC {
 		if err := m.listenGRPC(); err != nil {
 			t.Fatal(err)
@@ -415,6 +426,8 @@ type member struct {
 
 	grpcServer *grpc.Server
 	grpcAddr   string
+	// serverVersion overrides the version reported in Status if not empty
+	serverVersion string
 }
 
 // mustNewMember return an inited member with the given name. If peerTLS is
@@ -514,6 +527,7 @@ func NewClientV3(m *member) (*clientv3.Client, error) {
 func (m *member) Clone(t *testing.T) *member {
 	mm := &member{}
 	mm.ServerConfig = m.ServerConfig
+	mm.serverVersion = m.serverVersion
 
 	var err error
 	clientURLStrs := m.ClientURLs.StringSlice()
@@ -595,7 +609,11 @@ func (m *member) Launch() error {
 				return err
 			}
 		}
-		m.grpcServer = v3rpc.Server(m.s, tlscfg)
+		if m.serverVersion == "" {
+			m.grpcServer = v3rpc.Server(m.s, tlscfg, nil)
+		} else {
+			m.grpcServer = newVersionGRPCServer(m.s, tlscfg, m.serverVersion)
+		}
 		go m.grpcServer.Serve(m.grpcListener)
 	}
 	return nil
@@ -621,6 +639,9 @@ func (m *member) WaitOK(t *testing.T) {
 
 func (m *member) URL() string { return m.ClientURLs[0].String() }
 
+// GRPCAddr returns the endpoint of the member's grpc server.
+func (m *member) GRPCAddr() string { return m.grpcAddr }
+
 func (m *member) Pause() {
 	m.raftHandler.Pause()
 	m.s.PauseSending()
@@ -754,6 +775,37 @@ func (c *ClusterV3) Client(i int) *clientv3.Client {
 	return c.clients[i]
 }
 
+// newVersionGRPCServer serves the v3 API of s like v3rpc.Server, except
+// that Status reports the given version.
+func newVersionGRPCServer(s *etcdserver.EtcdServer, tlscfg *tls.Config, version string) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlscfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlscfg)))
+	}
+	gs := grpc.NewServer(opts...)
+	pb.RegisterKVServer(gs, v3rpc.NewKVServer(s))
+	pb.RegisterWatchServer(gs, v3rpc.NewWatchServer(s))
+	pb.RegisterLeaseServer(gs, v3rpc.NewLeaseServer(s))
+	pb.RegisterClusterServer(gs, v3rpc.NewClusterServer(s))
+	pb.RegisterAuthServer(gs, v3rpc.NewAuthServer(s))
+	pb.RegisterMaintenanceServer(gs, &versionMaintenanceServer{v3rpc.NewMaintenanceServer(s), version})
+	pb.RegisterHealthServer(gs, v3rpc.NewHealthServer(s))
+	return gs
+}
+
+type versionMaintenanceServer struct {
+	pb.MaintenanceServer
+	version string
+}
+
+func (ms *versionMaintenanceServer) Status(ctx context.Context, r *pb.StatusRequest) (*pb.StatusResponse, error) {
+	resp, err := ms.MaintenanceServer.Status(ctx, r)
+	if resp != nil {
+		resp.Version = ms.version
+	}
+	return resp, err
+}
+
 type grpcAPI struct {
 	// Cluster is the cluster API for the client's connection.
 	Cluster pb.ClusterClient
@@ -763,6 +815,8 @@ type grpcAPI struct {
 	Lease pb.LeaseClient
 	// Watch is the watch API for the client's connection.
 	Watch pb.WatchClient
+	// Health is the health API for the client's connection.
+	Health pb.HealthClient
 }
 
 func toGRPC(c *clientv3.Client) grpcAPI {
@@ -771,5 +825,6 @@ func toGRPC(c *clientv3.Client) grpcAPI {
 		pb.NewKVClient(c.ActiveConnection()),
 		pb.NewLeaseClient(c.ActiveConnection()),
 		pb.NewWatchClient(c.ActiveConnection()),
+		pb.NewHealthClient(c.ActiveConnection()),
 	}
 }
@@ -18,12 +18,19 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/coreos/etcd/client"
 	"github.com/coreos/etcd/pkg/testutil"
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"golang.org/x/net/context"
 )
@@ -255,6 +262,75 @@ func TestIssue2746(t *testing.T) {
 	clusterMustProgress(t, c.Members)
 }
 
+// TestSnapshotCatchUpEntries ensures a follower that falls behind by more
+// than SnapshotCatchUpEntries is sent a snapshot, and one that falls behind
+// by fewer catches up from the log.
+func TestSnapshotCatchUpEntries(t *testing.T) {
+	defer testutil.AfterTest(t)
+	tests := []struct {
+		catchUp uint64
+		wsnap   bool
+	}{
+		{100, false},
+		{5, true},
+	}
+	for i, tt := range tests {
+		func() {
+			c := NewCluster(t, 3)
+			for _, m := range c.Members {
+				m.SnapCount = 10
+				m.SnapshotCatchUpEntries = tt.catchUp
+			}
+			c.Launch(t)
+			defer c.Terminate(t)
+
+			slow := c.Members[2]
+			slow.Stop(t)
+			// enough entries for the others to snapshot and compact past slow
+			for j := 0; j < 30; j++ {
+				clusterMustProgress(t, c.Members[:2])
+			}
+			n := sentSnapshots(t, slow.s.ID())
+
+			if err := slow.Restart(t); err != nil {
+				t.Fatal(err)
+			}
+			clusterMustProgress(t, c.Members)
+
+			// the sender records a snapshot once the follower acknowledged it
+			var sent bool
+			for j := 0; j < 10 && !sent; j++ {
+				if sent = sentSnapshots(t, slow.s.ID()) > n; !sent {
+					time.Sleep(100 * time.Millisecond)
+				}
+			}
+			if sent != tt.wsnap {
+				t.Errorf("#%d: snapshot sent = %v, want %v", i, sent, tt.wsnap)
+			}
+		}()
+	}
+}
+
+// sentSnapshots returns the number of snapshots sent to the member id, as
+// recorded by the rafthttp metrics.
+func sentSnapshots(t *testing.T, id types.ID) int {
+	w := httptest.NewRecorder()
+	prometheus.Handler().ServeHTTP(w, &http.Request{Method: "GET", URL: &url.URL{Path: "/metrics"}, Header: http.Header{}})
+	var n int
+	for _, l := range strings.Split(w.Body.String(), "\n") {
+		if !strings.HasPrefix(l, "etcd_rafthttp_message_sent_latency_seconds_count{") ||
+			!strings.Contains(l, `msgType="MsgSnap"`) || !strings.Contains(l, fmt.Sprintf(`remoteID="%s"`, id)) {
+			continue
+		}
+		v, err := strconv.Atoi(l[strings.LastIndex(l, " ")+1:])
+		if err != nil {
+			t.Fatalf("bad metric %q (%v)", l, err)
+		}
+		n += v
+	}
+	return n
+}
+
 // Ensure etcd will not panic when removing a just started member.
 func TestIssue2904(t *testing.T) {
 	defer testutil.AfterTest(t)
@@ -337,6 +413,44 @@ func TestIssue3699(t *testing.T) {
 	}
 }
 
+// TestTransferLeadership ensures a leader hands off leadership to a
+// follower before it stops, and that the cluster keeps making progress
+// without it.
+func TestTransferLeadership(t *testing.T) {
+	defer testutil.AfterTest(t)
+	c := NewCluster(t, 3)
+	c.Launch(t)
+	defer c.Terminate(t)
+
+	oldLeadIdx := c.waitLeader(t, c.Members)
+	oldLead := c.Members[oldLeadIdx]
+
+	// followers have no leadership to transfer
+	follower := c.Members[(oldLeadIdx+1)%len(c.Members)]
+	if err := follower.s.TransferLeadership(time.Second); err != nil {
+		t.Fatalf("unexpected error on follower transfer (%v)", err)
+	}
+
+	if err := oldLead.s.TransferLeadership(5 * time.Second); err != nil {
+		t.Fatalf("unexpected error on leader transfer (%v)", err)
+	}
+	if oldLead.s.Leader() == oldLead.s.ID() {
+		t.Fatalf("leader is still %s after transfer", oldLead.s.ID())
+	}
+
+	if newLeadIdx := c.waitLeader(t, c.Members); newLeadIdx == oldLeadIdx {
+		t.Fatalf("leader index = %d, want a different leader", newLeadIdx)
+	}
+	oldLead.Stop(t)
+	<-oldLead.s.StopNotify()
+	c.waitLeader(t, c.Members)
+	clusterMustProgress(t, append(c.Members[:oldLeadIdx:oldLeadIdx], c.Members[oldLeadIdx+1:]...))
+	if err := oldLead.Restart(t); err != nil {
+		t.Fatal(err)
+	}
+	c.waitLeader(t, c.Members)
+}
+
 // clusterMustProgress ensures that cluster can make progress. It creates
 // a random key first, and check the new key could be got from all client urls
 // of the cluster.
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
 	"golang.org/x/net/context"
 )
@@ -146,3 +147,116 @@ func TestElectionFailover(t *testing.T) {
 	// leader must ack election (otherwise, Campaign may see closed conn)
 	<-electedc
 }
+
+// TestElectionObserveCurrentLeader tests that Observe first returns the
+// leader elected before it was called, even once the revision of its
+// election has been compacted, and then follows its proclamations.
+func TestElectionObserveCurrentLeader(t *testing.T) {
+	clus := NewClusterV3(t, &ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	defer dropSessionLease(clus)
+
+	e := concurrency.NewElection(clus.clients[0], "test-election")
+	if err := e.Campaign(context.TODO(), "foo"); err != nil {
+		t.Fatalf("failed volunteer (%v)", err)
+	}
+
+	// compact past the revision after the put of the leader key, so a
+	// watch from it fails
+	var presp *clientv3.PutResponse
+	var err error
+	for i := 0; i < 2; i++ {
+		if presp, err = clus.clients[1].Put(context.TODO(), "other", "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = clus.clients[1].Compact(context.TODO(), presp.Header.Revision); err != nil {
+		t.Fatal(err)
+	}
+	// reads at the compacted revision fail; move past it
+	if _, err = clus.clients[1].Put(context.TODO(), "other", "v"); err != nil {
+		t.Fatal(err)
+	}
+
+	cctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	och := concurrency.NewElection(clus.clients[2], "test-election").Observe(cctx)
+
+	select {
+	case resp, ok := <-och:
+		if !ok {
+			t.Fatalf("observe channel closed, want current leader")
+		}
+		if s := string(resp.Kvs[0].Value); s != "foo" {
+			t.Fatalf("wrong election result. got %s, wanted foo", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for current leader")
+	}
+
+	if err = e.Proclaim(context.TODO(), "bar"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case resp, ok := <-och:
+		if !ok {
+			t.Fatalf("observe channel closed, want proclaimed value")
+		}
+		if s := string(resp.Kvs[0].Value); s != "bar" {
+			t.Fatalf("wrong proclaimed value. got %s, wanted bar", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for proclaimed value")
+	}
+}
+
+// TestLeaderCampaign tests that only one LeaderCampaign call returns at a
+// time for the same prefix.
+func TestLeaderCampaign(t *testing.T) {
+	clus := NewClusterV3(t, &ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+	defer dropSessionLease(clus)
+
+	electedc := make(chan *concurrency.Election, 2)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			s, err := concurrency.NewSession(clus.clients[i])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			e, err := concurrency.LeaderCampaign(context.TODO(), s, "test-election", fmt.Sprintf("v%d", i))
+			if err != nil {
+				t.Errorf("failed campaign (%v)", err)
+				return
+			}
+			electedc <- e
+		}(i)
+	}
+
+	first := <-electedc
+	select {
+	case <-electedc:
+		t.Fatalf("both campaigns won")
+	case <-time.After(time.Second):
+	}
+
+	// a campaign that cannot be won in time gives up
+	s, err := concurrency.NewSession(clus.clients[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = concurrency.LeaderCampaign(context.TODO(), s, "test-election", "v2", concurrency.WithCampaignTimeout(100*time.Millisecond))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := first.Resign(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-electedc:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("second campaign did not win after resign")
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/coreos/etcd/pkg/testutil"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 )
 
 // TestV3PutOverwrite puts a key with the v3 api to a random cluster member,
@@ -74,6 +76,39 @@ func TestV3PutOverwrite(t *testing.T) {
 	}
 }
 
+// TestV3AdditionalHeaders ensures the configured headers are sent in the
+// response metadata of unary and streaming calls.
+func TestV3AdditionalHeaders(t *testing.T) {
+	defer testutil.AfterTest(t)
+	clus := NewClusterV3(t, &ClusterConfig{
+		Size:                  3,
+		GRPCAdditionalHeaders: map[string]string{"x-etcd-deployment-id": "blue"},
+	})
+	defer clus.Terminate(t)
+
+	var md metadata.MD
+	kvc := toGRPC(clus.RandClient()).KV
+	if _, err := kvc.Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")}, grpc.Header(&md)); err != nil {
+		t.Fatal(err)
+	}
+	if v := md["x-etcd-deployment-id"]; len(v) != 1 || v[0] != "blue" {
+		t.Errorf("unary header = %v, want [blue]", v)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wStream, err := toGRPC(clus.RandClient()).Watch.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, err = wStream.Header(); err != nil {
+		t.Fatal(err)
+	}
+	if v := md["x-etcd-deployment-id"]; len(v) != 1 || v[0] != "blue" {
+		t.Errorf("stream header = %v, want [blue]", v)
+	}
+}
+
 func TestV3TxnTooManyOps(t *testing.T) {
 	defer testutil.AfterTest(t)
 	clus := NewClusterV3(t, &ClusterConfig{Size: 3})
@@ -455,6 +490,74 @@ func TestV3Hash(t *testing.T) {
 	}
 }
 
+// TestV3HashRevision ensures members report the same key-value hash for the
+// same revision and that a corrupted member reports a different one.
+func TestV3HashRevision(t *testing.T) {
+	defer testutil.AfterTest(t)
+	clus := NewClusterV3(t, &ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	kvc := toGRPC(clus.RandClient()).KV
+	var rev int64
+	for i := 0; i < 3; i++ {
+		preq := &pb.PutRequest{Key: []byte(fmt.Sprintf("foo%d", i)), Value: []byte("bar")}
+		resp, err := kvc.Put(context.Background(), preq)
+		if err != nil {
+			t.Fatalf("couldn't put key (%v)", err)
+		}
+		rev = resp.Header.Revision
+	}
+	// a later write must not change the hash at rev
+	if _, err := kvc.Put(context.Background(), &pb.PutRequest{Key: []byte("foo"), Value: []byte("baz")}); err != nil {
+		t.Fatalf("couldn't put key (%v)", err)
+	}
+
+	hashes := func() []uint32 {
+		hs := make([]uint32, len(clus.Members))
+		for i := range clus.Members {
+			req := &pb.HashRequest{Revision: rev}
+			var resp *pb.HashResponse
+			var err error
+			for j := 0; j < 10; j++ {
+				// wait for the member to apply rev
+				if resp, err = toGRPC(clus.Client(i)).KV.Hash(context.Background(), req); err != rpctypes.ErrFutureRev {
+					break
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			if err != nil {
+				t.Fatalf("couldn't hash member %d (%v)", i, err)
+			}
+			hs[i] = resp.Hash
+		}
+		return hs
+	}
+
+	hs := hashes()
+	for i := range hs {
+		if hs[i] != hs[0] {
+			t.Fatalf("hash of member %d = %d, want %d", i, hs[i], hs[0])
+		}
+	}
+
+	// overwrite the value of the first key on one member
+	be := clus.Members[0].s.Backend()
+	tx := be.BatchTx()
+	tx.Lock()
+	keys, _ := tx.UnsafeRange([]byte("key"), []byte{0}, []byte{0xff}, 1)
+	tx.UnsafePut([]byte("key"), keys[0], []byte("corrupted"))
+	tx.Unlock()
+	be.ForceCommit()
+
+	hs = hashes()
+	if hs[0] == hs[1] {
+		t.Errorf("corrupted member reports hash %d of healthy member", hs[0])
+	}
+	if hs[1] != hs[2] {
+		t.Errorf("healthy members report hashes %d and %d", hs[1], hs[2])
+	}
+}
+
 func TestV3RangeRequest(t *testing.T) {
 	defer testutil.AfterTest(t)
 	tests := []struct {
@@ -587,6 +690,24 @@ func TestV3RangeRequest(t *testing.T) {
 			},
 			[]bool{true, true, true, true, false},
 		},
+		// min mod revision; a is put at revisions 2 and 5, b at 3, c at 4
+		{
+			[]string{"a", "b", "c", "a"},
+			[]pb.RangeRequest{
+				{Key: []byte("a"), RangeEnd: []byte("z"), MinModRevision: 4},
+				{Key: []byte("a"), RangeEnd: []byte("z"), MinModRevision: 4, KeysOnly: true},
+				{Key: []byte("a"), RangeEnd: []byte("z"), MinModRevision: 4, Limit: 1},
+				{Key: []byte("a"), RangeEnd: []byte("z"), MinModRevision: 6},
+			},
+
+			[][]string{
+				{"a", "c"},
+				{"a", "c"},
+				{"a"},
+				{},
+			},
+			[]bool{false, false, true, false},
+		},
 	}
 
 	for i, tt := range tests {
@@ -615,6 +736,9 @@ func TestV3RangeRequest(t *testing.T) {
 				if respKey != wKey {
 					t.Errorf("#%d.%d: key[%d]. got = %v, want = %v, ", i, j, k, respKey, wKey)
 				}
+				if req.KeysOnly && len(resp.Kvs[k].Value) != 0 {
+					t.Errorf("#%d.%d: key[%d] has value %q with keys only", i, j, k, resp.Kvs[k].Value)
+				}
 			}
 			if resp.More != tt.wmores[j] {
 				t.Errorf("#%d.%d: bad more. got = %v, want = %v, ", i, j, resp.More, tt.wmores[j])
@@ -722,3 +846,68 @@ func TestTLSGRPCAcceptSecureAll(t *testing.T) {
 		t.Fatalf("unexpected error on put over tls (%v)", err)
 	}
 }
+
+// TestV3MaxConcurrentStreams ensures a client connection cannot open more
+// gRPC streams than the server allows until one of them finishes.
+func TestV3MaxConcurrentStreams(t *testing.T) {
+	defer testutil.AfterTest(t)
+	clus := NewClusterV3(t, &ClusterConfig{Size: 3, MaxConcurrentStreams: 10})
+	defer clus.Terminate(t)
+
+	// a connection of its own, since a client keeps streams open
+	c := clus.Client(0)
+	conn, err := c.Dial(c.Endpoints()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// the response carries the server's stream limit to the connection
+	if _, err = pb.NewKVClient(conn).Range(context.TODO(), &pb.RangeRequest{Key: []byte("foo")}); err != nil {
+		t.Fatal(err)
+	}
+	wAPI := pb.NewWatchClient(conn)
+
+	newWatch := func(ctx context.Context) error {
+		wStream, err := wAPI.Watch(ctx)
+		if err != nil {
+			return err
+		}
+		req := &pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{
+			CreateRequest: &pb.WatchCreateRequest{Key: []byte("foo")}}}
+		if err = wStream.Send(req); err != nil {
+			return err
+		}
+		_, err = wStream.Recv()
+		return err
+	}
+
+	cancels := make([]context.CancelFunc, 10)
+	for i := range cancels {
+		var ctx context.Context
+		ctx, cancels[i] = context.WithCancel(context.Background())
+		if err := newWatch(ctx); err != nil {
+			t.Fatalf("#%d: watch error (%v)", i, err)
+		}
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	// the 11th stream waits for a free slot
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	err = newWatch(ctx)
+	cancel()
+	if grpc.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, codes.DeadlineExceeded)
+	}
+
+	// closing a stream frees a slot
+	cancels[0]()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = newWatch(ctx); err != nil {
+		t.Fatalf("watch error after freeing a stream (%v)", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/testutil"
+	"golang.org/x/net/context"
+)
+
+// TestV3ReadinessProbe ensures a member is ready while it can serve
+// linearizable reads and not ready once it loses quorum.
+func TestV3ReadinessProbe(t *testing.T) {
+	defer testutil.AfterTest(t)
+	clus := NewClusterV3(t, &ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	hc := toGRPC(clus.Client(0)).Health
+	resp, err := hc.ReadinessProbe(context.TODO(), &pb.ReadinessRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Ready {
+		t.Fatalf("member not ready (%s)", resp.Reason)
+	}
+
+	// partition member 0 from the rest of the cluster
+	clus.Members[0].Pause()
+	defer clus.Members[0].Resume()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		resp, err = hc.ReadinessProbe(context.TODO(), &pb.ReadinessRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Ready {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("member still ready without quorum")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if resp.Reason == "" {
+		t.Errorf("expected a reason for not being ready")
+	}
+}
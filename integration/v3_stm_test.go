@@ -109,6 +109,52 @@ func TestSTMPutNewKey(t *testing.T) {
 	}
 }
 
+// TestSTMPutIgnoreLease confirms a STM put with WithIgnoreLease does not
+// attach a lease to the key.
+func TestSTMPutIgnoreLease(t *testing.T) {
+	clus := NewClusterV3(t, &ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	etcdc := clus.RandClient()
+	lresp, err := etcdc.Create(context.TODO(), 10)
+	if err != nil {
+		t.Fatalf("failed to create lease (%v)", err)
+	}
+	applyf := func(stm concurrency.STM) error {
+		stm.Put("foo", "bar", v3.WithLease(v3.LeaseID(lresp.ID)), v3.WithIgnoreLease())
+		return nil
+	}
+	if _, err := concurrency.NewSTMRepeatable(context.TODO(), etcdc, applyf); err != nil {
+		t.Fatalf("error on stm txn (%v)", err)
+	}
+
+	resp, err := etcdc.Get(context.TODO(), "foo")
+	if err != nil {
+		t.Fatalf("error fetching key (%v)", err)
+	}
+	if resp.Kvs[0].Lease != 0 {
+		t.Fatalf("bad lease. got %d, expected no lease", resp.Kvs[0].Lease)
+	}
+
+	// a put without lease options detaches the lease of a leased key
+	if _, err = etcdc.Put(context.TODO(), "foo", "bar", v3.WithLease(v3.LeaseID(lresp.ID))); err != nil {
+		t.Fatalf("could not put key (%v)", err)
+	}
+	applyf = func(stm concurrency.STM) error {
+		stm.Put("foo", "baz")
+		return nil
+	}
+	if _, err = concurrency.NewSTMRepeatable(context.TODO(), etcdc, applyf); err != nil {
+		t.Fatalf("error on stm txn (%v)", err)
+	}
+	if resp, err = etcdc.Get(context.TODO(), "foo"); err != nil {
+		t.Fatalf("error fetching key (%v)", err)
+	}
+	if resp.Kvs[0].Lease != 0 {
+		t.Fatalf("bad lease. got %d, expected no lease", resp.Kvs[0].Lease)
+	}
+}
+
 // TestSTMAbort tests that an aborted txn does not modify any keys.
 func TestSTMAbort(t *testing.T) {
 	clus := NewClusterV3(t, &ClusterConfig{Size: 1})
@@ -6,6 +6,7 @@ package testutil
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"regexp"
@@ -96,6 +97,23 @@ func AfterTest(t *testing.T) {
 	t.Errorf("Test appears to have leaked %s:\n%s", bad, stacks)
 }
 
+// WaitForGoroutines closes c, e.g. a clientv3.Client, and fails the test
+// if Close has not returned within timeout because the goroutines c waits
+// on are still running. A leaked goroutine then fails the test with the
+// running goroutine stacks instead of hanging it.
+func WaitForGoroutines(t *testing.T, c io.Closer, timeout time.Duration) {
+	donec := make(chan struct{})
+	go func() {
+		c.Close()
+		close(donec)
+	}()
+	select {
+	case <-donec:
+	case <-time.After(timeout):
+		t.Fatalf("goroutines still running %v after close:\n%s", timeout, strings.Join(interestingGoroutines(), "\n\n"))
+	}
+}
+
 func interestingGoroutines() (gs []string) {
 	buf := make([]byte, 2<<20)
 	buf = buf[:runtime.Stack(buf, true)]
@@ -18,10 +18,12 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -30,9 +32,14 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/coreos/pkg/capnslog"
 )
 
+var plog = capnslog.NewPackageLogger("github.com/coreos/etcd/pkg", "transport")
+
 func NewListener(addr string, scheme string, tlscfg *tls.Config) (net.Listener, error) {
 	nettype := "tcp"
 	if scheme == "unix" {
@@ -61,6 +68,9 @@ func NewTransport(info TLSInfo, dialtimeoutd time.Duration) (*http.Transport, er
 	if err != nil {
 		return nil, err
 	}
+	if w := info.CertExpiry(); w != nil {
+		plog.Warning(w.Warn())
+	}
 
 	t := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -77,6 +87,31 @@ func NewTransport(info TLSInfo, dialtimeoutd time.Duration) (*http.Transport, er
 	return t, nil
 }
 
+// certExpiryWarnPeriod is how long before a certificate expires
+// CertExpiry starts returning a CertExpiryWarning.
+const certExpiryWarnPeriod = 30 * 24 * time.Hour
+
+// CertExpiryWarning is returned by CertExpiry when the certificate expires
+// soon. It is not fatal; callers should log it and carry on.
+type CertExpiryWarning struct {
+	CertFile string
+	NotAfter time.Time
+}
+
+func (w *CertExpiryWarning) Error() string { return w.Warn() }
+
+// Warn returns the warning message.
+func (w *CertExpiryWarning) Warn() string {
+	return fmt.Sprintf("certificate %s expires at %v (in %v)", w.CertFile, w.NotAfter, w.NotAfter.Sub(time.Now()))
+}
+
+const (
+	// CertKeyTypeRSA is an RSA-2048 key for a self-signed certificate.
+	CertKeyTypeRSA = "RSA"
+	// CertKeyTypeECDSA is an ECDSA P-256 key for a self-signed certificate.
+	CertKeyTypeECDSA = "ECDSA"
+)
+
 type TLSInfo struct {
 	CertFile       string
 	KeyFile        string
@@ -84,8 +119,20 @@ type TLSInfo struct {
 	TrustedCAFile  string
 	ClientCertAuth bool
 
+	// CertKeyType is the key type of a self-signed certificate, either
+	// CertKeyTypeRSA or CertKeyTypeECDSA. It is set by SelfCert.
+	CertKeyType string
+
+	// CipherSuites restricts the TLS 1.0-1.2 cipher suites; nil allows the
+	// crypto/tls defaults. TLS 1.3 cipher suites are not configurable.
+	CipherSuites []uint16
+
 	selfCert bool
 
+	// reloader holds the certificate of the configs generated once
+	// EnableReload was called; it is shared by the copies of the TLSInfo.
+	reloader *certReloader
+
 	// parseFunc exists to simplify testing. Typically, parseFunc
 	// should be left nil. In that case, tls.X509KeyPair will be used.
 	parseFunc func([]byte, []byte) (tls.Certificate, error)
@@ -99,7 +146,19 @@ func (info TLSInfo) Empty() bool {
 	return info.CertFile == "" && info.KeyFile == ""
 }
 
+// SelfCert generates a self-signed certificate with an ECDSA key for the
+// given hosts in dirpath, or reuses the one already there.
 func SelfCert(dirpath string, hosts []string) (info TLSInfo, err error) {
+	return SelfCertWithKeyType(dirpath, hosts, CertKeyTypeECDSA)
+}
+
+// SelfCertWithKeyType is SelfCert with a key of the given type,
+// CertKeyTypeRSA or CertKeyTypeECDSA. A certificate already in dirpath is
+// reused whatever its key type; info.CertKeyType reports the type it has.
+func SelfCertWithKeyType(dirpath string, hosts []string, keyType string) (info TLSInfo, err error) {
+	if keyType != CertKeyTypeRSA && keyType != CertKeyTypeECDSA {
+		return info, fmt.Errorf("unknown certificate key type %q", keyType)
+	}
 	if err = os.MkdirAll(dirpath, 0700); err != nil {
 		return
 	}
@@ -112,6 +171,7 @@ func SelfCert(dirpath string, hosts []string) (info TLSInfo, err error) {
 		info.CertFile = certPath
 		info.KeyFile = keyPath
 		info.selfCert = true
+		info.CertKeyType, err = certKeyType(certPath)
 		return
 	}
 
@@ -140,12 +200,33 @@ func SelfCert(dirpath string, hosts []string) (info TLSInfo, err error) {
 		}
 	}
 
-	priv, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
-	if err != nil {
-		return
+	var (
+		priv     interface{}
+		pub      interface{}
+		keyBlock *pem.Block
+	)
+	switch keyType {
+	case CertKeyTypeRSA:
+		var k *rsa.PrivateKey
+		if k, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			return
+		}
+		priv, pub = k, &k.PublicKey
+		keyBlock = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case CertKeyTypeECDSA:
+		var k *ecdsa.PrivateKey
+		if k, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return
+		}
+		var b []byte
+		if b, err = x509.MarshalECPrivateKey(k); err != nil {
+			return
+		}
+		priv, pub = k, &k.PublicKey
+		keyBlock = &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
 	}
 
-	derBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &priv.PublicKey, priv)
+	derBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, pub, priv)
 	if err != nil {
 		return
 	}
@@ -157,21 +238,107 @@ func SelfCert(dirpath string, hosts []string) (info TLSInfo, err error) {
 	pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
 	certOut.Close()
 
-	b, err := x509.MarshalECPrivateKey(priv)
-	if err != nil {
-		return
-	}
 	keyOut, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return
 	}
-	pem.Encode(keyOut, &pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+	pem.Encode(keyOut, keyBlock)
 	keyOut.Close()
 
-	return SelfCert(dirpath, hosts)
+	return SelfCertWithKeyType(dirpath, hosts, keyType)
 }
 
-func (info TLSInfo) baseConfig() (*tls.Config, error) {
+// certKeyType returns the key type of the PEM encoded certificate at path.
+func certKeyType(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return "", fmt.Errorf("no PEM data in %s", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		return CertKeyTypeRSA, nil
+	case x509.ECDSA:
+		return CertKeyTypeECDSA, nil
+	}
+	return "", fmt.Errorf("unsupported key algorithm %v in %s", cert.PublicKeyAlgorithm, path)
+}
+
+// certReloader holds the certificate that Reload swaps for every config it
+// was given to.
+type certReloader struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func (r *certReloader) get() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+func (r *certReloader) set(cert *tls.Certificate) {
+	r.mu.Lock()
+	r.cert = cert
+	r.mu.Unlock()
+}
+
+// EnableReload makes the configs generated by ServerConfig and ClientConfig
+// from info, and from copies of it taken afterwards, use the certificate
+// last given to Reload on each handshake. It loads the current certificate.
+func (info *TLSInfo) EnableReload() error {
+	if info.reloader != nil {
+		return nil
+	}
+	cert, err := info.loadCert()
+	if err != nil {
+		return err
+	}
+	info.reloader = &certReloader{cert: cert}
+	return nil
+}
+
+// Reload validates the certificate and key of newInfo and, if they are
+// valid now, swaps them in for the current ones of the configs generated
+// from info. New handshakes use the new certificate; established
+// connections are kept. Only CertFile and KeyFile are taken from newInfo.
+// EnableReload must have been called on info before its configs were
+// generated.
+func (info *TLSInfo) Reload(newInfo TLSInfo) error {
+	if info.reloader == nil {
+		return errors.New("transport: reload is not enabled on the TLSInfo")
+	}
+	if newInfo.parseFunc == nil {
+		newInfo.parseFunc = info.parseFunc
+	}
+	cert, err := newInfo.loadCert()
+	if err != nil {
+		return err
+	}
+	if len(cert.Certificate) == 0 {
+		return fmt.Errorf("no certificate in %s", newInfo.CertFile)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	if now := time.Now(); now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate %s is only valid from %v to %v", newInfo.CertFile, leaf.NotBefore, leaf.NotAfter)
+	}
+	info.reloader.set(cert)
+	info.CertFile, info.KeyFile = newInfo.CertFile, newInfo.KeyFile
+	return nil
+}
+
+// loadCert reads and parses the certificate and key of info.
+func (info TLSInfo) loadCert() (*tls.Certificate, error) {
 	if info.KeyFile == "" || info.CertFile == "" {
 		return nil, fmt.Errorf("KeyFile and CertFile must both be present[key: %v, cert: %v]", info.KeyFile, info.CertFile)
 	}
@@ -195,11 +362,32 @@ func (info TLSInfo) baseConfig() (*tls.Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	return &tlsCert, nil
+}
 
+func (info TLSInfo) baseConfig() (*tls.Config, error) {
 	cfg := &tls.Config{
-		Certificates: []tls.Certificate{tlsCert},
 		MinVersion:   tls.VersionTLS10,
+		CipherSuites: info.CipherSuites,
 	}
+	if r := info.reloader; r != nil {
+		if info.KeyFile == "" || info.CertFile == "" {
+			return nil, fmt.Errorf("KeyFile and CertFile must both be present[key: %v, cert: %v]", info.KeyFile, info.CertFile)
+		}
+		cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.get(), nil
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.get(), nil
+		}
+		return cfg, nil
+	}
+
+	tlsCert, err := info.loadCert()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Certificates = []tls.Certificate{*tlsCert}
 	return cfg, nil
 }
 
@@ -267,6 +455,34 @@ func (info TLSInfo) ClientConfig() (*tls.Config, error) {
 	return cfg, nil
 }
 
+// CertExpiry returns a CertExpiryWarning if the certificate of info expires
+// within 30 days, or nil if it does not or info has no certificate.
+func (info TLSInfo) CertExpiry() *CertExpiryWarning {
+	if info.Empty() {
+		return nil
+	}
+	var tlsCert *tls.Certificate
+	if info.reloader != nil {
+		tlsCert = info.reloader.get()
+	} else {
+		var err error
+		if tlsCert, err = info.loadCert(); err != nil {
+			return nil
+		}
+	}
+	if tlsCert == nil || len(tlsCert.Certificate) == 0 {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	if time.Now().Add(certExpiryWarnPeriod).After(cert.NotAfter) {
+		return &CertExpiryWarning{CertFile: info.CertFile, NotAfter: cert.NotAfter}
+	}
+	return nil
+}
+
 // newCertPool creates x509 certPool with provided CA files.
 func newCertPool(CAFiles []string) (*x509.CertPool, error) {
 	certPool := x509.NewCertPool()
@@ -15,11 +15,22 @@
 package transport
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"os"
+	"path"
 	"testing"
 	"time"
 )
@@ -79,6 +90,90 @@ func testNewListenerTLSInfoAccept(t *testing.T, tlsInfo TLSInfo) {
 	}
 }
 
+func TestSelfCertKeyType(t *testing.T) {
+	tests := []struct {
+		keyType string
+		walg    x509.PublicKeyAlgorithm
+	}{
+		{CertKeyTypeRSA, x509.RSA},
+		{CertKeyTypeECDSA, x509.ECDSA},
+	}
+	for i, tt := range tests {
+		tmpdir, err := ioutil.TempDir(os.TempDir(), "tlsdir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmpdir)
+
+		tlsinfo, err := SelfCertWithKeyType(tmpdir, []string{"127.0.0.1"}, tt.keyType)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if tlsinfo.CertKeyType != tt.keyType {
+			t.Errorf("#%d: key type = %q, want %q", i, tlsinfo.CertKeyType, tt.keyType)
+		}
+		b, err := ioutil.ReadFile(tlsinfo.CertFile)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		block, _ := pem.Decode(b)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if cert.PublicKeyAlgorithm != tt.walg {
+			t.Errorf("#%d: key algorithm = %v, want %v", i, cert.PublicKeyAlgorithm, tt.walg)
+		}
+
+		// a TLS handshake with the certificate succeeds
+		tlscfg, err := tlsinfo.ServerConfig()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		ln, err := NewListener("127.0.0.1:0", "https", tlscfg)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		errc := make(chan error, 1)
+		go func() {
+			conn, err := ln.Accept()
+			if err == nil {
+				err = conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+			errc <- err
+		}()
+		roots := x509.NewCertPool()
+		roots.AddCert(cert)
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{RootCAs: roots})
+		if err != nil {
+			t.Errorf("#%d: dial error (%v)", i, err)
+		} else {
+			conn.Close()
+		}
+		if err = <-errc; err != nil {
+			t.Errorf("#%d: handshake error (%v)", i, err)
+		}
+		ln.Close()
+
+		// an existing certificate is reused with its own key type
+		other := CertKeyTypeRSA
+		if tt.keyType == CertKeyTypeRSA {
+			other = CertKeyTypeECDSA
+		}
+		if tlsinfo, err = SelfCertWithKeyType(tmpdir, []string{"127.0.0.1"}, other); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if tlsinfo.CertKeyType != tt.keyType {
+			t.Errorf("#%d: reused key type = %q, want %q", i, tlsinfo.CertKeyType, tt.keyType)
+		}
+	}
+
+	if _, err := SelfCertWithKeyType(os.TempDir(), nil, "DSA"); err == nil {
+		t.Errorf("expected error for unknown key type")
+	}
+}
+
 func TestNewListenerTLSEmptyInfo(t *testing.T) {
 	_, err := NewListener("127.0.0.1:0", "https", nil)
 	if err == nil {
@@ -250,6 +345,158 @@ func TestTLSInfoConfigFuncs(t *testing.T) {
 	}
 }
 
+func TestTLSInfoCertExpiry(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "certexpiry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		validity time.Duration
+		wwarn    bool
+	}{
+		{20 * 24 * time.Hour, true},
+		{365 * 24 * time.Hour, false},
+	}
+	for i, tt := range tests {
+		info, err := createCert(path.Join(dir, fmt.Sprint(i)), tt.validity)
+		if err != nil {
+			t.Fatalf("#%d: unable to create cert: %v", i, err)
+		}
+		// a soon expiring certificate is still usable
+		if _, err = info.ClientConfig(); err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		w := info.CertExpiry()
+		if (w != nil) != tt.wwarn {
+			t.Errorf("#%d: warning = %v, want warning %v", i, w, tt.wwarn)
+		}
+	}
+}
+
+// createCert writes a self-signed cert valid for the given duration and its
+// key into dir.
+func createCert(dir string, validity time.Duration) (TLSInfo, error) {
+	info := TLSInfo{CertFile: path.Join(dir, "cert.pem"), KeyFile: path.Join(dir, "key.pem")}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return info, err
+	}
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return info, err
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"etcd"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		return info, err
+	}
+	b, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return info, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+	if err = ioutil.WriteFile(info.CertFile, certPEM, 0600); err != nil {
+		return info, err
+	}
+	return info, ioutil.WriteFile(info.KeyFile, keyPEM, 0600)
+}
+
+// TestTLSInfoReload ensures new connections are served the reloaded
+// certificate while the established ones are kept.
+func TestTLSInfoReload(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "certreload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var infos []TLSInfo
+	for i, validity := range []time.Duration{time.Hour, time.Hour, -time.Hour} {
+		info, err := createCert(path.Join(dir, fmt.Sprint(i)), validity)
+		if err != nil {
+			t.Fatalf("#%d: unable to create cert: %v", i, err)
+		}
+		infos = append(infos, info)
+	}
+	certA, certB, expired := infos[0], infos[1], infos[2]
+
+	if err = certA.Reload(certB); err == nil {
+		t.Fatalf("expected error on reload before EnableReload")
+	}
+	info := certA
+	if err = info.EnableReload(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := info.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := NewListener("127.0.0.1:0", "https", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+
+	dial := func(want TLSInfo) *tls.Conn {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wcert, err := tls.LoadX509KeyPair(want.CertFile, want.KeyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(conn.ConnectionState().PeerCertificates[0].Raw, wcert.Certificate[0]) {
+			t.Fatalf("got certificate of %s, want %s", info.CertFile, want.CertFile)
+		}
+		return conn
+	}
+
+	connA := dial(certA)
+	defer connA.Close()
+	if err = info.Reload(certB); err != nil {
+		t.Fatal(err)
+	}
+	connB := dial(certB)
+	connB.Close()
+
+	// invalid certificates are refused and the current one is kept
+	if err = info.Reload(expired); err == nil {
+		t.Errorf("expected error on reload of an expired certificate")
+	}
+	if err = info.Reload(TLSInfo{CertFile: path.Join(dir, "missing"), KeyFile: certB.KeyFile}); err == nil {
+		t.Errorf("expected error on reload of a missing certificate")
+	}
+	connB = dial(certB)
+	connB.Close()
+
+	// the connection established before the reload still works
+	if _, err = connA.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 4)
+	if _, err = io.ReadFull(connA, b); err != nil || string(b) != "ping" {
+		t.Fatalf("got %q (%v), want ping", b, err)
+	}
+}
+
 func TestNewListenerUnixSocket(t *testing.T) {
 	l, err := NewListener("testsocket", "unix", nil)
 	if err != nil {
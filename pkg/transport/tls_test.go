@@ -0,0 +1,110 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGetCipherSuites(t *testing.T) {
+	tests := []struct {
+		s    string
+		wids []uint16
+		werr bool
+	}{
+		{"", nil, false},
+		{"TLS_RSA_WITH_AES_128_CBC_SHA", []uint16{tls.TLS_RSA_WITH_AES_128_CBC_SHA}, false},
+		{
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			[]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+			false,
+		},
+		{"TLS_RSA_WITH_AES_128_CBC_SHA,", nil, true},
+		{"TLS_BOGUS", nil, true},
+	}
+	for i, tt := range tests {
+		ids, err := GetCipherSuites(tt.s)
+		if (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+		}
+		if !reflect.DeepEqual(ids, tt.wids) {
+			t.Errorf("#%d: ids = %v, want %v", i, ids, tt.wids)
+		}
+	}
+}
+
+func TestCipherSuitesHandshake(t *testing.T) {
+	tmpdir, err := ioutil.TempDir(os.TempDir(), "tlsdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	tlsinfo, err := SelfCertWithKeyType(tmpdir, []string{"127.0.0.1"}, CertKeyTypeRSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsinfo.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	tlscfg, err := tlsinfo.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := NewListener("127.0.0.1:0", "https", tlscfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	b, err := ioutil.ReadFile(tlsinfo.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(b)
+
+	tests := []struct {
+		cs uint16
+		ok bool
+	}{
+		{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, true},
+		{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, false},
+	}
+	for i, tt := range tests {
+		// TLS 1.3 ignores the configured cipher suites
+		ccfg := &tls.Config{RootCAs: roots, MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tt.cs}}
+		conn, err := tls.Dial("tcp", ln.Addr().String(), ccfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("#%d: dial error = %v, want success %v", i, err, tt.ok)
+		}
+		if err == nil {
+			conn.Close()
+		}
+	}
+}
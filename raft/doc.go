@@ -289,5 +289,15 @@ stale log entries:
 	indicating 'MsgApp' is lost. When follower's progress state is replicate,
 	the leader sets it back to probe.
 
+	'MsgTransferLeader' asks the leader to hand its leadership to the node in
+	the message's From field. The leader stops accepting proposals, catches
+	the transferee up with 'sendAppend' if it is behind, and then sends it
+	'MsgTimeoutNow'. If the transferee does not take over within an election
+	timeout, the leader aborts the transfer. A follower forwards the message
+	to its leader.
+
+	'MsgTimeoutNow' tells the transferee to start an election right away
+	instead of waiting for its election timeout.
+
 */
 package raft
@@ -157,6 +157,10 @@ type Node interface {
 	ReportUnreachable(id uint64)
 	// ReportSnapshot reports the status of the sent snapshot.
 	ReportSnapshot(id uint64, status SnapshotStatus)
+	// TransferLeadership attempts to transfer leadership from lead to the
+	// given transferee. The leader stops accepting proposals until the
+	// transferee takes over or an election timeout passes.
+	TransferLeadership(ctx context.Context, lead, transferee uint64)
 	// Stop performs any necessary termination of the Node.
 	Stop()
 }
@@ -469,6 +473,15 @@ func (n *node) ReportSnapshot(id uint64, status SnapshotStatus) {
 	}
 }
 
+func (n *node) TransferLeadership(ctx context.Context, lead, transferee uint64) {
+	select {
+	// from is the transferee, so the leader can tell whom to transfer to
+	case n.recvc <- pb.Message{Type: pb.MsgTransferLeader, From: transferee, To: lead}:
+	case <-n.done:
+	case <-ctx.Done():
+	}
+}
+
 func newReady(r *raft, prevSoftSt *SoftState, prevHardSt pb.HardState) Ready {
 	rd := Ready{
 		Entries:          r.raftLog.unstableEntries(),
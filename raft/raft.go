@@ -158,6 +158,9 @@ type raft struct {
 
 	// the leader id
 	lead uint64
+	// leadTransferee is the id of the leader transfer target when it is
+	// not None.
+	leadTransferee uint64
 
 	// New configuration is ignored if there exists unapplied configuration.
 	pendingConf bool
@@ -393,6 +396,8 @@ func (r *raft) reset(term uint64) {
 	r.electionElapsed = 0
 	r.heartbeatElapsed = 0
 
+	r.abortLeaderTransfer()
+
 	r.votes = make(map[uint64]bool)
 	for id := range r.prs {
 		r.prs[id] = &Progress{Next: r.raftLog.lastIndex() + 1, ins: newInflights(r.maxInflight)}
@@ -438,6 +443,11 @@ func (r *raft) tickHeartbeat() {
 		if r.checkQuorum {
 			r.Step(pb.Message{From: r.id, Type: pb.MsgCheckQuorum})
 		}
+		// the transferee did not take over within an election timeout;
+		// keep leading and accept proposals again.
+		if r.state == StateLeader && r.leadTransferee != None {
+			r.abortLeaderTransfer()
+		}
 	}
 
 	if r.state != StateLeader {
@@ -590,6 +600,10 @@ func stepLeader(r *raft, m pb.Message) {
 			// drop any new proposals.
 			return
 		}
+		if r.leadTransferee != None {
+			r.logger.Debugf("%x [term %d] transfer leadership to %x is in progress; dropping proposal", r.id, r.Term, r.leadTransferee)
+			return
+		}
 		for i, e := range m.Entries {
 			if e.Type == pb.EntryConfChange {
 				if r.pendingConf {
@@ -648,6 +662,11 @@ func stepLeader(r *raft, m pb.Message) {
 					// an update before, send it now.
 					r.sendAppend(m.From)
 				}
+				// the transferee caught up with the log; let it campaign
+				if m.From == r.leadTransferee && pr.Match == r.raftLog.lastIndex() {
+					r.logger.Infof("%x sent MsgTimeoutNow to %x after received MsgAppResp", r.id, m.From)
+					r.sendTimeoutNow(m.From)
+				}
 			}
 		}
 	case pb.MsgHeartbeatResp:
@@ -683,6 +702,31 @@ func stepLeader(r *raft, m pb.Message) {
 			pr.becomeProbe()
 		}
 		r.logger.Debugf("%x failed to send message to %x because it is unreachable [%s]", r.id, m.From, pr)
+	case pb.MsgTransferLeader:
+		// m.From is the transferee
+		if r.leadTransferee != None {
+			if r.leadTransferee == m.From {
+				r.logger.Infof("%x [term %d] transfer leadership to %x is in progress, ignores request to same node %x",
+					r.id, r.Term, r.leadTransferee, m.From)
+				return
+			}
+			r.logger.Infof("%x [term %d] abort previous transferring leadership to %x", r.id, r.Term, r.leadTransferee)
+			r.abortLeaderTransfer()
+		}
+		if m.From == r.id {
+			r.logger.Debugf("%x is already leader. Ignored transferring leadership to self", r.id)
+			return
+		}
+		r.logger.Infof("%x [term %d] starts to transfer leadership to %x", r.id, r.Term, m.From)
+		// the transfer must finish within an election timeout
+		r.electionElapsed = 0
+		r.leadTransferee = m.From
+		if pr.Match == r.raftLog.lastIndex() {
+			r.sendTimeoutNow(m.From)
+			r.logger.Infof("%x sends MsgTimeoutNow to %x immediately as %x already has up-to-date log", r.id, m.From, m.From)
+		} else {
+			r.sendAppend(m.From)
+		}
 	}
 }
 
@@ -691,6 +735,9 @@ func stepCandidate(r *raft, m pb.Message) {
 	case pb.MsgProp:
 		r.logger.Infof("%x no leader at term %d; dropping proposal", r.id, r.Term)
 		return
+	case pb.MsgTransferLeader:
+		r.logger.Infof("%x no leader at term %d; dropping leader transfer msg", r.id, r.Term)
+		return
 	case pb.MsgApp:
 		r.becomeFollower(r.Term, m.From)
 		r.handleAppendEntries(m)
@@ -749,6 +796,20 @@ func stepFollower(r *raft, m pb.Message) {
 				r.id, r.raftLog.lastTerm(), r.raftLog.lastIndex(), r.Vote, m.From, m.LogTerm, m.Index, r.Term)
 			r.send(pb.Message{To: m.From, Type: pb.MsgVoteResp, Reject: true})
 		}
+	case pb.MsgTransferLeader:
+		if r.lead == None {
+			r.logger.Infof("%x no leader at term %d; dropping leader transfer msg", r.id, r.Term)
+			return
+		}
+		m.To = r.lead
+		r.send(m)
+	case pb.MsgTimeoutNow:
+		if !r.promotable() {
+			r.logger.Infof("%x received MsgTimeoutNow from %x but is not promotable", r.id, m.From)
+			return
+		}
+		r.logger.Infof("%x [term %d] received MsgTimeoutNow from %x and starts an election to get leadership.", r.id, r.Term, m.From)
+		r.campaign()
 	}
 }
 
@@ -846,6 +907,12 @@ func (r *raft) removeNode(id uint64) {
 
 func (r *raft) resetPendingConf() { r.pendingConf = false }
 
+func (r *raft) sendTimeoutNow(to uint64) {
+	r.send(pb.Message{To: to, Type: pb.MsgTimeoutNow})
+}
+
+func (r *raft) abortLeaderTransfer() { r.leadTransferee = None }
+
 func (r *raft) setProgress(id, match, next uint64) {
 	r.prs[id] = &Progress{Next: next, Match: match, ins: newInflights(r.maxInflight)}
 }
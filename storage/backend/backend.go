@@ -43,12 +43,15 @@ var (
 
 type Backend interface {
 	BatchTx() BatchTx
+	ReadTx() ReadTx
 	Snapshot() Snapshot
 	Hash() (uint32, error)
 	// Size returns the current size of the backend.
 	Size() int64
 	Defrag() error
 	ForceCommit()
+	// Commits returns the number of commits of the batch tx so far.
+	Commits() int64
 	Close() error
 }
 
@@ -76,6 +79,7 @@ type backend struct {
 	batchInterval time.Duration
 	batchLimit    int
 	batchTx       *batchTx
+	readTx        *readTx
 
 	stopc chan struct{}
 	donec chan struct{}
@@ -104,6 +108,7 @@ func newBackend(path string, d time.Duration, limit int) *backend {
 		stopc: make(chan struct{}),
 		donec: make(chan struct{}),
 	}
+	b.readTx = &readTx{backend: b}
 	b.batchTx = newBatchTx(b)
 	go b.run()
 	return b
@@ -116,6 +121,12 @@ func (b *backend) BatchTx() BatchTx {
 	return b.batchTx
 }
 
+// ReadTx returns the read tx shared by concurrent readers. It only sees
+// writes of the batch tx that were committed.
+func (b *backend) ReadTx() ReadTx {
+	return b.readTx
+}
+
 // ForceCommit forces the current batching tx to commit.
 func (b *backend) ForceCommit() {
 	b.batchTx.Commit()
@@ -136,27 +147,21 @@ func (b *backend) Snapshot() Snapshot {
 func (b *backend) Hash() (uint32, error) {
 	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
 
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	err := b.db.View(func(tx *bolt.Tx) error {
-		c := tx.Cursor()
-		for next, _ := c.First(); next != nil; next, _ = c.Next() {
-			b := tx.Bucket(next)
-			if b == nil {
-				return fmt.Errorf("cannot get hash of bucket %s", string(next))
-			}
-			h.Write(next)
-			b.ForEach(func(k, v []byte) error {
-				h.Write(k)
-				h.Write(v)
-				return nil
-			})
+	b.readTx.RLock()
+	defer b.readTx.RUnlock()
+	tx := b.readTx.tx
+	c := tx.Cursor()
+	for next, _ := c.First(); next != nil; next, _ = c.Next() {
+		b := tx.Bucket(next)
+		if b == nil {
+			return 0, fmt.Errorf("cannot get hash of bucket %s", string(next))
 		}
-		return nil
-	})
-
-	if err != nil {
-		return 0, err
+		h.Write(next)
+		b.ForEach(func(k, v []byte) error {
+			h.Write(k)
+			h.Write(v)
+			return nil
+		})
 	}
 
 	return h.Sum32(), nil
@@ -197,12 +202,15 @@ func (b *backend) Defrag() error {
 	// close previous ongoing tx.
 	b.batchTx.Lock()
 	defer b.batchTx.Unlock()
+	// keep readers from beginning a tx on the database being replaced.
+	b.readTx.mu.Lock()
+	defer b.readTx.mu.Unlock()
 
 	// lock database after lock tx to avoid deadlock.
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.batchTx.commit(true)
+	b.batchTx.unsafeCommit(true)
 	b.batchTx.tx = nil
 
 	tmpdb, err := bolt.Open(b.db.Path()+".tmp", 0600, boltOpenOptions)
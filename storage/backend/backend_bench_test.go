@@ -16,6 +16,7 @@ package backend
 
 import (
 	"crypto/rand"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -48,3 +49,42 @@ func BenchmarkBackendPut(b *testing.B) {
 		batchTx.Unlock()
 	}
 }
+
+func benchmarkBackendConcurrentRead(b *testing.B, shared bool) {
+	backend, tmpPath := NewDefaultTmpBackend()
+	defer cleanup(backend, tmpPath)
+
+	tx := backend.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket([]byte("test"))
+	for i := 0; i < 1000; i++ {
+		tx.UnsafePut([]byte("test"), []byte(fmt.Sprintf("foo_%04d", i)), []byte("bar"))
+	}
+	tx.Unlock()
+	backend.ForceCommit()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if shared {
+				rt := backend.ReadTx()
+				rt.RLock()
+				rt.UnsafeRange([]byte("test"), []byte("foo_0500"), nil, 0)
+				rt.RUnlock()
+				continue
+			}
+			btx, err := backend.db.Begin(false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			unsafeRange(btx, []byte("test"), []byte("foo_0500"), nil, 0)
+			btx.Rollback()
+		}
+	})
+}
+
+// BenchmarkBackendConcurrentReadTx reads through the shared read tx.
+func BenchmarkBackendConcurrentReadTx(b *testing.B) { benchmarkBackendConcurrentRead(b, true) }
+
+// BenchmarkBackendConcurrentBoltTx begins a bolt read tx per read.
+func BenchmarkBackendConcurrentBoltTx(b *testing.B) { benchmarkBackendConcurrentRead(b, false) }
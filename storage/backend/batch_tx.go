@@ -84,7 +84,11 @@ func (t *batchTx) unsafePut(bucketName []byte, key []byte, value []byte, seq boo
 
 // UnsafeRange must be called holding the lock on the tx.
 func (t *batchTx) UnsafeRange(bucketName []byte, key, endKey []byte, limit int64) (keys [][]byte, vs [][]byte) {
-	bucket := t.tx.Bucket(bucketName)
+	return unsafeRange(t.tx, bucketName, key, endKey, limit)
+}
+
+func unsafeRange(tx *bolt.Tx, bucketName []byte, key, endKey []byte, limit int64) (keys [][]byte, vs [][]byte) {
+	bucket := tx.Bucket(bucketName)
 	if bucket == nil {
 		log.Fatalf("storage: bucket %s does not exist", string(bucketName))
 	}
@@ -144,7 +148,18 @@ func (t *batchTx) Unlock() {
 	t.Mutex.Unlock()
 }
 
+// commit also resets the shared read tx, so readers only ever see the
+// latest committed state.
 func (t *batchTx) commit(stop bool) {
+	t.backend.readTx.mu.Lock()
+	defer t.backend.readTx.mu.Unlock()
+	t.unsafeCommit(stop)
+}
+
+// unsafeCommit must be called holding the write lock on the read tx.
+func (t *batchTx) unsafeCommit(stop bool) {
+	t.backend.readTx.reset()
+
 	var err error
 	// commit the last tx
 	if t.tx != nil {
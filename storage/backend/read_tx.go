@@ -0,0 +1,84 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"log"
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+// ReadTx reads the committed state of the backend. Writes batched in the
+// BatchTx are not visible until they are committed.
+type ReadTx interface {
+	RLock()
+	RUnlock()
+	UnsafeRange(bucketName []byte, key, endKey []byte, limit int64) (keys [][]byte, vals [][]byte)
+}
+
+// readTx is a bolt read transaction shared by all concurrent readers. It is
+// opened on first use and rolled back whenever the batch tx commits, so
+// readers that run between two commits do not each begin a bolt tx.
+type readTx struct {
+	// mu is held for reading while the tx is in use and for writing
+	// while the tx is replaced.
+	mu      sync.RWMutex
+	tx      *bolt.Tx
+	backend *backend
+}
+
+// RLock locks the tx for reading, beginning a new one if the last was reset.
+func (rt *readTx) RLock() {
+	rt.mu.RLock()
+	for rt.tx == nil {
+		rt.mu.RUnlock()
+		rt.mu.Lock()
+		if rt.tx == nil {
+			rt.begin()
+		}
+		rt.mu.Unlock()
+		rt.mu.RLock()
+	}
+}
+
+func (rt *readTx) RUnlock() { rt.mu.RUnlock() }
+
+// UnsafeRange must be called holding the read lock on the tx.
+func (rt *readTx) UnsafeRange(bucketName []byte, key, endKey []byte, limit int64) (keys [][]byte, vs [][]byte) {
+	return unsafeRange(rt.tx, bucketName, key, endKey, limit)
+}
+
+// begin must be called holding the write lock on the tx.
+func (rt *readTx) begin() {
+	rt.backend.mu.RLock()
+	defer rt.backend.mu.RUnlock()
+	tx, err := rt.backend.db.Begin(false)
+	if err != nil {
+		log.Fatalf("storage: cannot begin read tx (%s)", err)
+	}
+	rt.tx = tx
+}
+
+// reset must be called holding the write lock on the tx.
+func (rt *readTx) reset() {
+	if rt.tx == nil {
+		return
+	}
+	if err := rt.tx.Rollback(); err != nil {
+		log.Fatalf("storage: cannot rollback read tx (%s)", err)
+	}
+	rt.tx = nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadTxSeesCommitted(t *testing.T) {
+	b, tmpPath := NewTmpBackend(time.Hour, 10000)
+	defer cleanup(b, tmpPath)
+
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket([]byte("test"))
+	tx.UnsafePut([]byte("test"), []byte("foo"), []byte("bar"))
+	tx.Unlock()
+
+	rt := b.ReadTx()
+	rt.RLock()
+	// the bucket is not committed yet, so the read tx cannot see it
+	empty := rt.(*readTx).tx.Bucket([]byte("test")) == nil
+	rt.RUnlock()
+	if !empty {
+		t.Fatalf("read tx sees uncommitted bucket")
+	}
+
+	b.ForceCommit()
+	rt.RLock()
+	_, vs := rt.UnsafeRange([]byte("test"), []byte("foo"), nil, 0)
+	rt.RUnlock()
+	if len(vs) != 1 || string(vs[0]) != "bar" {
+		t.Fatalf("vals = %q, want [bar]", vs)
+	}
+}
+
+func TestReadTxShared(t *testing.T) {
+	b, tmpPath := NewTmpBackend(time.Hour, 10000)
+	defer cleanup(b, tmpPath)
+
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket([]byte("test"))
+	tx.Unlock()
+	b.ForceCommit()
+
+	rt := b.ReadTx()
+	rt.RLock()
+	otx := b.readTx.tx
+	rt.RUnlock()
+
+	// concurrent readers reuse the same bolt tx while nothing commits
+	var wg sync.WaitGroup
+	errc := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rt.RLock()
+			defer rt.RUnlock()
+			if b.readTx.tx != otx {
+				errc <- fmt.Errorf("read tx was replaced without a commit")
+			}
+			rt.UnsafeRange([]byte("test"), []byte("foo"), nil, 0)
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Fatal(err)
+	}
+
+	// a commit resets the tx
+	tx.Lock()
+	tx.UnsafePut([]byte("test"), []byte("foo"), []byte("bar"))
+	tx.Unlock()
+	b.ForceCommit()
+	rt.RLock()
+	ntx := b.readTx.tx
+	_, vs := rt.UnsafeRange([]byte("test"), []byte("foo"), nil, 0)
+	rt.RUnlock()
+	if ntx == otx {
+		t.Errorf("read tx was not replaced after commit")
+	}
+	if len(vs) != 1 || string(vs[0]) != "bar" {
+		t.Errorf("vals = %q, want [bar]", vs)
+	}
+}
+
+func TestReadTxDefrag(t *testing.T) {
+	b, tmpPath := NewTmpBackend(time.Hour, 10000)
+	defer cleanup(b, tmpPath)
+
+	tx := b.BatchTx()
+	tx.Lock()
+	tx.UnsafeCreateBucket([]byte("test"))
+	tx.UnsafePut([]byte("test"), []byte("foo"), []byte("bar"))
+	tx.Unlock()
+	b.ForceCommit()
+
+	rt := b.ReadTx()
+	rt.RLock()
+	rt.UnsafeRange([]byte("test"), []byte("foo"), nil, 0)
+	rt.RUnlock()
+
+	if err := b.Defrag(); err != nil {
+		t.Fatal(err)
+	}
+
+	rt.RLock()
+	_, vs := rt.UnsafeRange([]byte("test"), []byte("foo"), nil, 0)
+	rt.RUnlock()
+	if len(vs) != 1 || string(vs[0]) != "bar" {
+		t.Errorf("vals = %q, want [bar]", vs)
+	}
+}
@@ -47,15 +47,15 @@ type consistentWatchableStore struct {
 	skip bool // indicate whether or not to skip an operation
 }
 
-func New(b backend.Backend, le lease.Lessor, ig ConsistentIndexGetter) ConsistentWatchableKV {
-	return newConsistentWatchableStore(b, le, ig)
+func New(b backend.Backend, le lease.Lessor, ig ConsistentIndexGetter, cfg StoreConfig) ConsistentWatchableKV {
+	return newConsistentWatchableStore(b, le, ig, cfg)
 }
 
 // newConsistentWatchableStore creates a new consistentWatchableStore with the give
 // backend.
-func newConsistentWatchableStore(b backend.Backend, le lease.Lessor, ig ConsistentIndexGetter) *consistentWatchableStore {
+func newConsistentWatchableStore(b backend.Backend, le lease.Lessor, ig ConsistentIndexGetter, cfg StoreConfig) *consistentWatchableStore {
 	return &consistentWatchableStore{
-		watchableStore: newWatchableStore(b, le),
+		watchableStore: newWatchableStoreWithConfig(b, le, cfg),
 		ig:             ig,
 	}
 }
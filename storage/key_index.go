@@ -195,6 +195,44 @@ func (ki *keyIndex) compact(atRev int64, available map[revision]struct{}) {
 		log.Panicf("store.keyindex: unexpected compact on empty keyIndex %s", string(ki.key))
 	}
 
+	i, n := ki.doCompact(atRev, available)
+	g := &ki.generations[i]
+	if !g.isEmpty() {
+		// remove the previous contents.
+		if n != -1 {
+			g.revs = g.revs[n:]
+		}
+		// remove any tombstone
+		if len(g.revs) == 1 && i != len(ki.generations)-1 {
+			delete(available, g.revs[0])
+			i++
+		}
+	}
+	// remove the previous generations.
+	ki.generations = ki.generations[i:]
+	return
+}
+
+// keep adds the revision that compact at atRev would keep to available,
+// without compacting the keyIndex.
+func (ki *keyIndex) keep(atRev int64, available map[revision]struct{}) {
+	if ki.isEmpty() {
+		return
+	}
+
+	i, n := ki.doCompact(atRev, available)
+	g := &ki.generations[i]
+	// remove any tombstone
+	if !g.isEmpty() && n != -1 && n == len(g.revs)-1 && i != len(ki.generations)-1 {
+		delete(available, g.revs[n])
+	}
+}
+
+// doCompact adds the last revision at or before atRev of the first
+// generation that is not removed by a compaction at atRev to available.
+// It returns the index of that generation and of the revision in it, or
+// -1 if all of its revisions are after atRev.
+func (ki *keyIndex) doCompact(atRev int64, available map[revision]struct{}) (int, int) {
 	// walk until reaching the first revision that has an revision smaller or equal to
 	// the atRev.
 	// add it to the available map
@@ -216,21 +254,11 @@ func (ki *keyIndex) compact(atRev int64, available map[revision]struct{}) {
 		g = &ki.generations[i]
 	}
 
+	n := -1
 	if !g.isEmpty() {
-		n := g.walk(f)
-		// remove the previous contents.
-		if n != -1 {
-			g.revs = g.revs[n:]
-		}
-		// remove any tombstone
-		if len(g.revs) == 1 && i != len(ki.generations)-1 {
-			delete(available, g.revs[0])
-			i++
-		}
+		n = g.walk(f)
 	}
-	// remove the previous generations.
-	ki.generations = ki.generations[i:]
-	return
+	return i, n
 }
 
 func (ki *keyIndex) isEmpty() bool {
@@ -472,6 +472,14 @@ func TestKeyIndexCompact(t *testing.T) {
 	// Once Compaction
 	for i, tt := range tests {
 		ki := newTestKeyIndex()
+		kam := make(map[revision]struct{})
+		ki.keep(tt.compact, kam)
+		if !reflect.DeepEqual(kam, tt.wam) {
+			t.Errorf("#%d: kept = %+v, want %+v", i, kam, tt.wam)
+		}
+		if wki := newTestKeyIndex(); !reflect.DeepEqual(ki, wki) {
+			t.Errorf("#%d: keep changed ki = %+v, want %+v", i, ki, wki)
+		}
 		am := make(map[revision]struct{})
 		ki.compact(tt.compact, am)
 		if !reflect.DeepEqual(ki, tt.wki) {
@@ -18,6 +18,7 @@ import (
 	"github.com/coreos/etcd/lease"
 	"github.com/coreos/etcd/storage/backend"
 	"github.com/coreos/etcd/storage/storagepb"
+	"golang.org/x/net/context"
 )
 
 type KV interface {
@@ -73,6 +74,13 @@ type KV interface {
 	// This method is designed for consistency checking purpose.
 	Hash() (uint32, error)
 
+	// HashByRev returns the hash of the key-value pairs written at or before
+	// rev that the last compaction keeps, leaving out the revisions it may not
+	// have removed yet. It also returns the current revision and the main
+	// revision of the last compaction. If rev <= 0, the current revision is
+	// hashed.
+	HashByRev(rev int64) (hash uint32, currentRev int64, compactRev int64, err error)
+
 	// Commit commits txns into the underlying backend.
 	Commit()
 
@@ -92,6 +100,13 @@ type Watchable interface {
 	// NewWatchStream returns a WatchStream that can be used to
 	// watch events happened or happening on the KV.
 	NewWatchStream() WatchStream
+
+	// WatchRevRange replays the events of all keys from startRev up to, but
+	// not including, endRev in revision order, waiting for revisions that
+	// have not happened yet. The returned chan is closed once endRev is
+	// reached or ctx is done. If startRev has been compacted, a single
+	// response with CompactRevision set is sent before the chan is closed.
+	WatchRevRange(ctx context.Context, startRev, endRev int64) <-chan WatchResponse
 }
 
 // ConsistentWatchableKV is a WatchableKV that understands the consistency
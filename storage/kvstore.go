@@ -16,6 +16,7 @@ package storage
 
 import (
 	"errors"
+	"hash/crc32"
 	"log"
 	"math"
 	"math/rand"
@@ -49,7 +50,27 @@ var (
 	ErrCanceled      = errors.New("storage: watcher is canceled")
 )
 
+const (
+	// DefaultCompactionBatchLimit is the number of keys deleted per
+	// compaction step.
+	DefaultCompactionBatchLimit = 1000
+	// DefaultCompactionSleepInterval is the pause between compaction steps.
+	DefaultCompactionSleepInterval = 10 * time.Millisecond
+)
+
+// StoreConfig paces compaction, which deletes the compacted history in
+// steps so other requests can use the backend in between. Zero fields take
+// the defaults.
+type StoreConfig struct {
+	// CompactionBatchLimit is the number of keys deleted per step.
+	CompactionBatchLimit int
+	// CompactionSleepInterval is the pause between steps.
+	CompactionSleepInterval time.Duration
+}
+
 type store struct {
+	cfg StoreConfig
+
 	mu sync.Mutex // guards the following
 
 	b       backend.Backend
@@ -63,6 +84,10 @@ type store struct {
 
 	tx    backend.BatchTx
 	txnID int64 // tracks the current txnID to verify txn operations
+	// writeCommits is the backend commit count when the keys were last
+	// written; once the backend has committed since, reads can go through
+	// the shared read tx.
+	writeCommits int64
 
 	changes   []storagepb.KeyValue
 	fifoSched schedule.Scheduler
@@ -73,7 +98,19 @@ type store struct {
 // NewStore returns a new store. It is useful to create a store inside
 // storage pkg. It should only be used for testing externally.
 func NewStore(b backend.Backend, le lease.Lessor) *store {
+	return newStore(b, le, StoreConfig{})
+}
+
+func newStore(b backend.Backend, le lease.Lessor, cfg StoreConfig) *store {
+	if cfg.CompactionBatchLimit == 0 {
+		cfg.CompactionBatchLimit = DefaultCompactionBatchLimit
+	}
+	if cfg.CompactionSleepInterval == 0 {
+		cfg.CompactionSleepInterval = DefaultCompactionSleepInterval
+	}
 	s := &store{
+		cfg: cfg,
+
 		b:       b,
 		kvindex: newTreeIndex(),
 
@@ -81,6 +118,7 @@ func NewStore(b backend.Backend, le lease.Lessor) *store {
 
 		currentRev:     revision{main: 1},
 		compactMainRev: -1,
+		writeCommits:   -1,
 
 		fifoSched: schedule.NewFIFOScheduler(),
 
@@ -131,9 +169,20 @@ func (s *store) Put(key, value []byte, lease lease.LeaseID) int64 {
 }
 
 func (s *store) Range(key, end []byte, limit, rangeRev int64) (kvs []storagepb.KeyValue, rev int64, err error) {
-	id := s.TxnBegin()
-	kvs, rev, err = s.rangeKeys(key, end, limit, rangeRev)
-	s.txnEnd(id)
+	s.mu.Lock()
+	if s.committed() {
+		rt := s.b.ReadTx()
+		rt.RLock()
+		kvs, rev, err = s.rangeKeys(rt, key, end, limit, rangeRev)
+		rt.RUnlock()
+		s.mu.Unlock()
+	} else {
+		// only the batch tx sees the writes not yet committed
+		s.mu.Unlock()
+		id := s.TxnBegin()
+		kvs, rev, err = s.rangeKeys(s.tx, key, end, limit, rangeRev)
+		s.txnEnd(id)
+	}
 
 	rangeCounter.Inc()
 
@@ -177,6 +226,9 @@ func (s *store) txnEnd(txnID int64) error {
 		return ErrTxnIDMismatch
 	}
 
+	if s.currentRev.sub != 0 {
+		s.writeCommits = s.b.Commits()
+	}
 	s.tx.Unlock()
 	if s.currentRev.sub != 0 {
 		s.currentRev.main += 1
@@ -192,7 +244,7 @@ func (s *store) TxnRange(txnID int64, key, end []byte, limit, rangeRev int64) (k
 	if txnID != s.txnID {
 		return nil, 0, ErrTxnIDMismatch
 	}
-	return s.rangeKeys(key, end, limit, rangeRev)
+	return s.rangeKeys(s.tx, key, end, limit, rangeRev)
 }
 
 func (s *store) TxnPut(txnID int64, key, value []byte, lease lease.LeaseID) (rev int64, err error) {
@@ -264,6 +316,52 @@ func (s *store) Hash() (uint32, error) {
 	return s.b.Hash()
 }
 
+func (s *store) HashByRev(rev int64) (uint32, int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rev <= 0 {
+		rev = s.currentRev.main
+	}
+	if rev > s.currentRev.main {
+		return 0, 0, 0, ErrFutureRev
+	}
+	if rev <= s.compactMainRev {
+		return 0, 0, 0, ErrCompacted
+	}
+
+	// the revisions kept by the last compaction are hashed, but not the
+	// ones it may not have removed yet
+	keep := s.kvindex.Keep(s.compactMainRev)
+
+	lower, upper := newRevBytes(), newRevBytes()
+	revToBytes(revision{main: rev + 1}, upper)
+
+	var keys, vals [][]byte
+	if s.committed() {
+		rt := s.b.ReadTx()
+		rt.RLock()
+		keys, vals = rt.UnsafeRange(keyBucketName, lower, upper, 0)
+		rt.RUnlock()
+	} else {
+		tx := s.b.BatchTx()
+		tx.Lock()
+		keys, vals = tx.UnsafeRange(keyBucketName, lower, upper, 0)
+		tx.Unlock()
+	}
+
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	for i := range keys {
+		kr := bytesToRev(keys[i])
+		if _, ok := keep[kr]; !ok && kr.main <= s.compactMainRev {
+			continue
+		}
+		h.Write(keys[i])
+		h.Write(vals[i])
+	}
+	return h.Sum32(), s.currentRev.main, s.compactMainRev, nil
+}
+
 func (s *store) Commit() { s.b.ForceCommit() }
 
 func (s *store) Restore(b backend.Backend) error {
@@ -279,6 +377,7 @@ func (s *store) Restore(b backend.Backend) error {
 	s.compactMainRev = -1
 	s.tx = b.BatchTx()
 	s.txnID = -1
+	s.writeCommits = -1
 	s.fifoSched = schedule.NewFIFOScheduler()
 	s.stopc = make(chan struct{})
 
@@ -370,8 +469,17 @@ func (a *store) Equal(b *store) bool {
 	return a.kvindex.Equal(b.kvindex)
 }
 
+// committed reports whether the backend has committed all writes to
+// the keys. It must be called holding s.mu.
+func (s *store) committed() bool { return s.b.Commits() > s.writeCommits }
+
+// rangeTx is the part of a backend tx that reads the keys.
+type rangeTx interface {
+	UnsafeRange(bucketName []byte, key, endKey []byte, limit int64) (keys [][]byte, vals [][]byte)
+}
+
 // range is a keyword in Go, add Keys suffix.
-func (s *store) rangeKeys(key, end []byte, limit, rangeRev int64) (kvs []storagepb.KeyValue, curRev int64, err error) {
+func (s *store) rangeKeys(tx rangeTx, key, end []byte, limit, rangeRev int64) (kvs []storagepb.KeyValue, curRev int64, err error) {
 	curRev = int64(s.currentRev.main)
 	if s.currentRev.sub > 0 {
 		curRev += 1
@@ -398,7 +506,7 @@ func (s *store) rangeKeys(key, end []byte, limit, rangeRev int64) (kvs []storage
 	for _, revpair := range revpairs {
 		start, end := revBytesRange(revpair)
 
-		_, vs := s.tx.UnsafeRange(keyBucketName, start, end, 0)
+		_, vs := tx.UnsafeRange(keyBucketName, start, end, 0)
 		if len(vs) != 1 {
 			log.Fatalf("storage: range cannot find rev (%d,%d)", revpair.main, revpair.sub)
 		}
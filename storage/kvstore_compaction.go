@@ -26,7 +26,7 @@ func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struc
 	end := make([]byte, 8)
 	binary.BigEndian.PutUint64(end, uint64(compactMainRev+1))
 
-	batchsize := int64(10000)
+	batchsize := int64(s.cfg.CompactionBatchLimit)
 	last := make([]byte, 8+1+8)
 	for {
 		var rev revision
@@ -57,7 +57,7 @@ func (s *store) scheduleCompaction(compactMainRev int64, keep map[revision]struc
 		dbCompactionPauseDurations.Observe(float64(time.Now().Sub(start) / time.Millisecond))
 
 		select {
-		case <-time.After(100 * time.Millisecond):
+		case <-time.After(s.cfg.CompactionSleepInterval):
 		case <-s.stopc:
 			return
 		}
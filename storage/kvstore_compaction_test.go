@@ -17,6 +17,7 @@ package storage
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/coreos/etcd/lease"
 	"github.com/coreos/etcd/storage/backend"
@@ -93,3 +94,49 @@ func TestScheduleCompaction(t *testing.T) {
 		cleanup(s, b, tmpPath)
 	}
 }
+
+// TestScheduleCompactionThrottle ensures compaction deletes the history in
+// steps of CompactionBatchLimit keys, pausing CompactionSleepInterval
+// between them.
+func TestScheduleCompactionThrottle(t *testing.T) {
+	const nrevs = 100
+	sleep := 10 * time.Millisecond
+
+	tests := []struct {
+		limit int
+
+		// wpauses is the number of pauses between steps
+		wpauses int
+	}{
+		{nrevs + 1, 0},
+		{10, nrevs / 10},
+	}
+	for i, tt := range tests {
+		b, tmpPath := backend.NewDefaultTmpBackend()
+		s := newStore(b, &lease.FakeLessor{}, StoreConfig{CompactionBatchLimit: tt.limit, CompactionSleepInterval: sleep})
+		tx := s.b.BatchTx()
+
+		tx.Lock()
+		ibytes := newRevBytes()
+		for rev := int64(1); rev <= nrevs; rev++ {
+			revToBytes(revision{main: rev}, ibytes)
+			tx.UnsafePut(keyBucketName, ibytes, []byte("bar"))
+		}
+		tx.Unlock()
+
+		start := time.Now()
+		s.scheduleCompaction(nrevs, nil)
+		took := time.Since(start)
+
+		if min := time.Duration(tt.wpauses) * sleep; took < min {
+			t.Errorf("#%d: compaction took %v, want at least %v", i, took, min)
+		}
+		tx.Lock()
+		if keys, _ := tx.UnsafeRange(keyBucketName, newRevBytes(), []byte{0xff}, 0); len(keys) != 0 {
+			t.Errorf("#%d: %d keys left after compaction, want 0", i, len(keys))
+		}
+		tx.Unlock()
+
+		cleanup(s, b, tmpPath)
+	}
+}
@@ -206,7 +206,7 @@ func TestStoreRange(t *testing.T) {
 		b.tx.rangeRespc <- tt.r
 		fi.indexRangeRespc <- tt.idxr
 
-		kvs, rev, err := s.rangeKeys([]byte("foo"), []byte("goo"), 1, 0)
+		kvs, rev, err := s.rangeKeys(s.tx, []byte("foo"), []byte("goo"), 1, 0)
 		if err != nil {
 			t.Errorf("#%d: err = %v, want nil", i, err)
 		}
@@ -346,7 +346,7 @@ func TestStoreCompact(t *testing.T) {
 	binary.BigEndian.PutUint64(end, uint64(4))
 	wact := []testutil.Action{
 		{"put", []interface{}{metaBucketName, scheduledCompactKeyName, newTestRevBytes(revision{3, 0})}},
-		{"range", []interface{}{keyBucketName, make([]byte, 17), end, int64(10000)}},
+		{"range", []interface{}{keyBucketName, make([]byte, 17), end, int64(DefaultCompactionBatchLimit)}},
 		{"delete", []interface{}{keyBucketName, key2}},
 		{"put", []interface{}{metaBucketName, finishedCompactKeyName, newTestRevBytes(revision{3, 0})}},
 	}
@@ -416,6 +416,58 @@ func TestStoreRestore(t *testing.T) {
 	}
 }
 
+// TestHashByRevKeptRevisions ensures that the revisions kept by a
+// compaction are hashed.
+func TestHashByRevKeptRevisions(t *testing.T) {
+	var ss []*store
+	for i := 0; i < 2; i++ {
+		b, tmpPath := backend.NewDefaultTmpBackend()
+		s := NewStore(b, &lease.FakeLessor{})
+		defer cleanup(s, b, tmpPath)
+
+		s.Put([]byte("foo"), []byte("bar0"), lease.NoLease)
+		s.Put([]byte("foo"), []byte("bar1"), lease.NoLease)
+		s.Put([]byte("bar"), []byte("bar"), lease.NoLease)
+		s.Put([]byte("foo"), []byte("bar2"), lease.NoLease)
+		if err := s.Compact(4); err != nil {
+			t.Fatal(err)
+		}
+		ss = append(ss, s)
+	}
+
+	h0, _, compactRev, err := ss[0].HashByRev(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compactRev != 4 {
+		t.Fatalf("compactRev = %d, want 4", compactRev)
+	}
+	h1, _, _, err := ss[1].HashByRev(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h0 != h1 {
+		t.Fatalf("hash = %d, want %d", h1, h0)
+	}
+
+	// corrupt foo at revision 3, which the compaction at 4 keeps
+	rbytes := newRevBytes()
+	revToBytes(revision{main: 3}, rbytes)
+	tx := ss[1].b.BatchTx()
+	tx.Lock()
+	tx.UnsafePut(keyBucketName, rbytes, []byte("corrupted"))
+	tx.Unlock()
+	// the store reads revisions it did not write itself once committed
+	ss[1].b.ForceCommit()
+
+	if h1, _, _, err = ss[1].HashByRev(0); err != nil {
+		t.Fatal(err)
+	}
+	if h0 == h1 {
+		t.Errorf("hash = %d after corrupting a kept revision, want a different hash", h1)
+	}
+}
+
 func TestRestoreContinueUnfinishedCompaction(t *testing.T) {
 	b, tmpPath := backend.NewDefaultTmpBackend()
 	s0 := NewStore(b, &lease.FakeLessor{})
@@ -518,6 +570,36 @@ func TestTxnBlockBackendForceCommit(t *testing.T) {
 	}
 }
 
+// TestRangeCommittedUsesReadTx ensures a range of committed keys does not
+// wait on the batch tx, while a range of uncommitted keys still sees them.
+func TestRangeCommittedUsesReadTx(t *testing.T) {
+	b, tmpPath := backend.NewDefaultTmpBackend()
+	s := NewStore(b, &lease.FakeLessor{})
+	defer cleanup(s, b, tmpPath)
+
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	if kvs, _, err := s.Range([]byte("foo"), nil, 0, 0); err != nil || len(kvs) != 1 {
+		t.Fatalf("range before commit = %+v, %v, want foo", kvs, err)
+	}
+	s.b.ForceCommit()
+
+	tx := s.b.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+	done := make(chan struct{})
+	go func() {
+		if kvs, _, err := s.Range([]byte("foo"), nil, 0, 0); err != nil || len(kvs) != 1 {
+			t.Errorf("range after commit = %+v, %v, want foo", kvs, err)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		testutil.FatalStack(t, "range of committed keys blocked on the batch tx")
+	}
+}
+
 // TODO: test attach key to lessor
 
 func newTestRevBytes(rev revision) []byte {
@@ -547,6 +629,7 @@ func newFakeStore() *store {
 		indexCompactRespc:     make(chan map[revision]struct{}, 1),
 	}
 	return &store{
+		cfg:            StoreConfig{CompactionBatchLimit: DefaultCompactionBatchLimit, CompactionSleepInterval: DefaultCompactionSleepInterval},
 		b:              b,
 		le:             &lease.FakeLessor{},
 		kvindex:        fi,
@@ -586,16 +669,20 @@ func (b *fakeBatchTx) UnsafeDelete(bucketName []byte, key []byte) {
 }
 func (b *fakeBatchTx) Commit()        {}
 func (b *fakeBatchTx) CommitAndStop() {}
+func (b *fakeBatchTx) RLock()         {}
+func (b *fakeBatchTx) RUnlock()       {}
 
 type fakeBackend struct {
 	tx *fakeBatchTx
 }
 
 func (b *fakeBackend) BatchTx() backend.BatchTx   { return b.tx }
+func (b *fakeBackend) ReadTx() backend.ReadTx     { return b.tx }
 func (b *fakeBackend) Hash() (uint32, error)      { return 0, nil }
 func (b *fakeBackend) Size() int64                { return 0 }
 func (b *fakeBackend) Snapshot() backend.Snapshot { return nil }
 func (b *fakeBackend) ForceCommit()               {}
+func (b *fakeBackend) Commits() int64             { return 0 }
 func (b *fakeBackend) Defrag() error              { return nil }
 func (b *fakeBackend) Close() error               { return nil }
 
@@ -652,6 +739,10 @@ func (i *fakeIndex) Compact(rev int64) map[revision]struct{} {
 	i.Recorder.Record(testutil.Action{Name: "compact", Params: []interface{}{rev}})
 	return <-i.indexCompactRespc
 }
+func (i *fakeIndex) Keep(rev int64) map[revision]struct{} {
+	i.Recorder.Record(testutil.Action{Name: "keep", Params: []interface{}{rev}})
+	return <-i.indexCompactRespc
+}
 func (i *fakeIndex) Equal(b index) bool { return false }
 
 func createBytesSlice(bytesN, sliceN int) [][]byte {
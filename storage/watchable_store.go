@@ -22,6 +22,7 @@ import (
 	"github.com/coreos/etcd/lease"
 	"github.com/coreos/etcd/storage/backend"
 	"github.com/coreos/etcd/storage/storagepb"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -50,6 +51,10 @@ type watchableStore struct {
 	// The key of the map is the key that the watcher watches on.
 	synced watcherGroup
 
+	// revc is closed, and then cleared, once the store reaches a new
+	// revision. It is created by the first revision watcher waiting on it.
+	revc chan struct{}
+
 	stopc chan struct{}
 	wg    sync.WaitGroup
 }
@@ -59,8 +64,12 @@ type watchableStore struct {
 type cancelFunc func()
 
 func newWatchableStore(b backend.Backend, le lease.Lessor) *watchableStore {
+	return newWatchableStoreWithConfig(b, le, StoreConfig{})
+}
+
+func newWatchableStoreWithConfig(b backend.Backend, le lease.Lessor, cfg StoreConfig) *watchableStore {
 	s := &watchableStore{
-		store:    NewStore(b, le),
+		store:    newStore(b, le, cfg),
 		unsynced: newWatcherGroup(),
 		synced:   newWatcherGroup(),
 		stopc:    make(chan struct{}),
@@ -157,6 +166,14 @@ func (s *watchableStore) TxnEnd(txnID int64) error {
 	return nil
 }
 
+func (s *watchableStore) Restore(b backend.Backend) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.store.Restore(b)
+	s.notifyRev()
+	return err
+}
+
 func (s *watchableStore) Close() error {
 	close(s.stopc)
 	s.wg.Wait()
@@ -303,7 +320,78 @@ func (s *watchableStore) syncWatchers() {
 	slowWatcherGauge.Set(float64(s.unsynced.size()))
 }
 
-// kvsToEvents gets all events for the watchers from all key-value pairs
+func (s *watchableStore) WatchRevRange(ctx context.Context, startRev, endRev int64) <-chan WatchResponse {
+	ch := make(chan WatchResponse, chanBufLen)
+	go func() {
+		defer close(ch)
+		for startRev < endRev {
+			// take the channel before reading, so a revision reached in
+			// between still wakes up the wait below
+			revc := s.revNotify()
+			wr, next := s.revRange(startRev, endRev)
+			if next == startRev && wr.CompactRevision == 0 {
+				// wait for the store to reach startRev
+				select {
+				case <-revc:
+					continue
+				case <-ctx.Done():
+					return
+				case <-s.stopc:
+					return
+				}
+			}
+			select {
+			case ch <- wr:
+			case <-ctx.Done():
+				return
+			case <-s.stopc:
+				return
+			}
+			if wr.CompactRevision != 0 {
+				return
+			}
+			startRev = next
+		}
+	}()
+	return ch
+}
+
+// revRange reads the events of at most watchBatchMaxRevs revisions in
+// [startRev, endRev) that the store has already reached. It returns the
+// response with those events and the revision to continue from.
+func (s *watchableStore) revRange(startRev, endRev int64) (WatchResponse, int64) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	curRev := s.store.currentRev.main
+	if startRev <= s.store.compactMainRev {
+		return WatchResponse{Revision: curRev, CompactRevision: s.store.compactMainRev}, startRev
+	}
+	if endRev > curRev+1 {
+		endRev = curRev + 1
+	}
+	if maxRev := startRev + int64(watchBatchMaxRevs); endRev > maxRev {
+		endRev = maxRev
+	}
+	if endRev <= startRev {
+		return WatchResponse{}, startRev
+	}
+
+	minBytes, maxBytes := newRevBytes(), newRevBytes()
+	revToBytes(revision{main: startRev}, minBytes)
+	revToBytes(revision{main: endRev}, maxBytes)
+
+	tx := s.store.b.BatchTx()
+	tx.Lock()
+	revs, vs := tx.UnsafeRange(keyBucketName, minBytes, maxBytes, 0)
+	evs := kvsToEvents(nil, revs, vs)
+	tx.Unlock()
+
+	return WatchResponse{Events: evs, Revision: curRev}, endRev
+}
+
+// kvsToEvents gets all events for the watchers from all key-value pairs.
+// A nil watcherGroup gets the events of all keys.
 func kvsToEvents(wg *watcherGroup, revs, vals [][]byte) (evs []storagepb.Event) {
 	for i, v := range vals {
 		var kv storagepb.KeyValue
@@ -311,7 +399,7 @@ func kvsToEvents(wg *watcherGroup, revs, vals [][]byte) (evs []storagepb.Event)
 			log.Panicf("storage: cannot unmarshal event: %v", err)
 		}
 
-		if !wg.contains(string(kv.Key)) {
+		if wg != nil && !wg.contains(string(kv.Key)) {
 			continue
 		}
 
@@ -328,7 +416,28 @@ func kvsToEvents(wg *watcherGroup, revs, vals [][]byte) (evs []storagepb.Event)
 
 // notify notifies the fact that given event at the given rev just happened to
 // watchers that watch on the key of the event.
+// revNotify returns a channel that is closed once the store reaches a new
+// revision.
+func (s *watchableStore) revNotify() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.revc == nil {
+		s.revc = make(chan struct{})
+	}
+	return s.revc
+}
+
+// notifyRev wakes up the revision watchers waiting for a new revision. It
+// must be called holding s.mu.
+func (s *watchableStore) notifyRev() {
+	if s.revc != nil {
+		close(s.revc)
+		s.revc = nil
+	}
+}
+
 func (s *watchableStore) notify(rev int64, evs []storagepb.Event) {
+	s.notifyRev()
 	for w, eb := range newWatcherBatch(&s.synced, evs) {
 		if eb.revs != 1 {
 			panic("unexpected multiple revisions in notification")
@@ -16,6 +16,7 @@ package storage
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"reflect"
 	"testing"
@@ -24,6 +25,7 @@ import (
 	"github.com/coreos/etcd/lease"
 	"github.com/coreos/etcd/storage/backend"
 	"github.com/coreos/etcd/storage/storagepb"
+	"golang.org/x/net/context"
 )
 
 func TestWatch(t *testing.T) {
@@ -294,6 +296,90 @@ func TestWatchFutureRev(t *testing.T) {
 	}
 }
 
+func TestWatchRevRange(t *testing.T) {
+	b, tmpPath := backend.NewDefaultTmpBackend()
+	s := newWatchableStore(b, &lease.FakeLessor{})
+
+	defer func() {
+		s.store.Close()
+		os.Remove(tmpPath)
+	}()
+
+	for i := 0; i < 100; i++ {
+		s.Put([]byte(fmt.Sprintf("foo%d", i)), []byte("bar"), lease.NoLease)
+	}
+
+	var evs []storagepb.Event
+	for resp := range s.WatchRevRange(context.TODO(), 20, 50) {
+		if resp.CompactRevision != 0 {
+			t.Fatalf("compact rev = %d, want 0", resp.CompactRevision)
+		}
+		evs = append(evs, resp.Events...)
+	}
+	if len(evs) != 30 {
+		t.Fatalf("len(evs) = %d, want 30", len(evs))
+	}
+	for i, ev := range evs {
+		if wrev := int64(20 + i); ev.Kv.ModRevision != wrev {
+			t.Errorf("#%d: mod rev = %d, want %d", i, ev.Kv.ModRevision, wrev)
+		}
+	}
+}
+
+func TestWatchRevRangeFutureRev(t *testing.T) {
+	b, tmpPath := backend.NewDefaultTmpBackend()
+	s := newWatchableStore(b, &lease.FakeLessor{})
+
+	defer func() {
+		s.store.Close()
+		os.Remove(tmpPath)
+	}()
+
+	ch := s.WatchRevRange(context.TODO(), 2, 5)
+	for i := 0; i < 5; i++ {
+		s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	}
+
+	var evs []storagepb.Event
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case resp, ok := <-ch:
+			evs, done = append(evs, resp.Events...), !ok
+		case <-timeout:
+			t.Fatalf("failed to receive all events in 1 second (got %d)", len(evs))
+		}
+	}
+	if len(evs) != 3 {
+		t.Fatalf("len(evs) = %d, want 3", len(evs))
+	}
+}
+
+func TestWatchRevRangeCompacted(t *testing.T) {
+	b, tmpPath := backend.NewDefaultTmpBackend()
+	s := newWatchableStore(b, &lease.FakeLessor{})
+
+	defer func() {
+		s.store.Close()
+		os.Remove(tmpPath)
+	}()
+
+	for i := 0; i < 10; i++ {
+		s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	}
+	if err := s.Compact(5); err != nil {
+		t.Fatalf("failed to compact kv (%v)", err)
+	}
+
+	var resps []WatchResponse
+	for resp := range s.WatchRevRange(context.TODO(), 3, 8) {
+		resps = append(resps, resp)
+	}
+	if len(resps) != 1 || resps[0].CompactRevision != 5 {
+		t.Fatalf("resps = %+v, want a single response with compact rev 5", resps)
+	}
+}
+
 // TestWatchBatchUnsynced tests batching on unsynced watchers
 func TestWatchBatchUnsynced(t *testing.T) {
 	b, tmpPath := backend.NewDefaultTmpBackend()
@@ -38,6 +38,9 @@ func mustCreateConn() *clientv3.Client {
 			fmt.Fprintf(os.Stderr, "bad tls config: %v\n", err)
 			os.Exit(1)
 		}
+		if w := tls.CertExpiry(); w != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w.Warn())
+		}
 		cfg.TLS = cfgtls
 	}
 
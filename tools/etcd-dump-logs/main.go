@@ -34,6 +34,7 @@ func main() {
 	from := flag.String("data-dir", "", "")
 	snapfile := flag.String("start-snap", "", "The base name of snapshot file to start dumping")
 	index := flag.Uint64("start-index", 0, "The index to start dumping")
+	keyfile := flag.String("wal-encryption-key-file", "", "Path to the file the wal encryption key is derived from, if the wal is encrypted")
 	flag.Parse()
 	if *from == "" {
 		log.Fatal("Must provide -data-dir flag.")
@@ -75,7 +76,14 @@ func main() {
 		fmt.Println("Start dupmping log entries from snapshot.")
 	}
 
-	w, err := wal.Open(walDir(*from), walsnap)
+	var walKey []byte
+	if *keyfile != "" {
+		if walKey, err = wal.ReadEncryptionKeyFile(*keyfile); err != nil {
+			log.Fatalf("Failed reading WAL encryption key: %v", err)
+		}
+	}
+
+	w, err := wal.OpenWithKey(walDir(*from), walsnap, walKey)
 	if err != nil {
 		log.Fatalf("Failed opening WAL: %v", err)
 	}
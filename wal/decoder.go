@@ -16,6 +16,7 @@ package wal
 
 import (
 	"bufio"
+	"crypto/cipher"
 	"encoding/binary"
 	"hash"
 	"io"
@@ -34,6 +35,11 @@ type decoder struct {
 	// lastValidOff file offset following the last valid decoded record
 	lastValidOff int64
 	crc          hash.Hash32
+
+	// key is the secret the record key is derived from; aead decrypts
+	// encrypted records once a salt record derives it from key.
+	key  []byte
+	aead cipher.AEAD
 }
 
 func newDecoder(r ...io.Reader) *decoder {
@@ -98,6 +104,24 @@ func (d *decoder) decodeRecord(rec *walpb.Record) error {
 			return err
 		}
 	}
+
+	switch {
+	case rec.Type == saltType:
+		// records after the salt are sealed with the key derived from it
+		if len(d.key) != 0 {
+			if d.aead, err = newAEAD(d.key, rec.Data); err != nil {
+				return err
+			}
+		}
+	case rec.Encrypted != nil && *rec.Encrypted:
+		if d.aead == nil {
+			return ErrNoEncryptionKey
+		}
+		if rec.Data, err = open(d.aead, rec.Type, rec.Data); err != nil {
+			return err
+		}
+		rec.Encrypted = nil
+	}
 	// record decoded as valid; point last valid offset to end of record
 	d.lastValidOff += l + 8
 	return nil
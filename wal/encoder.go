@@ -16,6 +16,7 @@ package wal
 
 import (
 	"bufio"
+	"crypto/cipher"
 	"encoding/binary"
 	"hash"
 	"io"
@@ -32,6 +33,9 @@ type encoder struct {
 	crc       hash.Hash32
 	buf       []byte
 	uint64buf []byte
+
+	// aead encrypts record data if set.
+	aead cipher.AEAD
 }
 
 func newEncoder(w io.Writer, prevCrc uint32) *encoder {
@@ -48,14 +52,24 @@ func (e *encoder) encode(rec *walpb.Record) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	e.crc.Write(rec.Data)
-	rec.Crc = e.crc.Sum32()
 	var (
 		data []byte
 		err  error
 		n    int
 	)
 
+	if e.aead != nil && rec.Type != crcType && rec.Type != saltType {
+		// the crc covers the ciphertext, so it is checked before decryption
+		if data, err = seal(e.aead, rec.Type, rec.Data); err != nil {
+			return err
+		}
+		encrypted := true
+		rec = &walpb.Record{Type: rec.Type, Data: data, Encrypted: &encrypted}
+	}
+
+	e.crc.Write(rec.Data)
+	rec.Crc = e.crc.Sum32()
+
 	if rec.Size() > len(e.buf) {
 		data, err = rec.Marshal()
 		if err != nil {
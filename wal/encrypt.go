@@ -0,0 +1,118 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// saltSize is the size of the random salt recorded in every WAL file
+	// of an encrypted WAL.
+	saltSize = 16
+	// keyInfo binds the derived key to its use.
+	keyInfo = "etcd wal record encryption"
+)
+
+var (
+	ErrNoEncryptionKey = errors.New("wal: encrypted record found but no encryption key is given")
+	ErrDecrypt         = errors.New("wal: failed to decrypt record")
+)
+
+// ReadEncryptionKeyFile reads the WAL encryption secret from the file at
+// path. Surrounding whitespace is ignored, so the file may end with a
+// newline. The record key is derived from the secret and a per-WAL salt
+// with HKDF, which does not stretch the secret: it should be a long random
+// string, such as the output of "openssl rand -base64 32".
+func ReadEncryptionKeyFile(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, errors.New("wal: encryption key file is empty")
+	}
+	return b, nil
+}
+
+// newSalt returns a random salt for a newly encrypted WAL.
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// deriveKey derives a 256-bit AES key from secret and salt with
+// HKDF-SHA256 (RFC 5869). One block of expanded output is the whole key.
+func deriveKey(secret, salt []byte) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte(keyInfo))
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+// newAEAD returns the AES-256-GCM cipher for the key derived from secret
+// and salt.
+func newAEAD(secret, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(secret, salt))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data of a record of type typ with a random nonce, which is
+// prepended to the result. The type is authenticated with the data, so a
+// sealed record cannot be replayed as a record of another type.
+func seal(aead cipher.AEAD, typ int64, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, typeAD(typ)), nil
+}
+
+// open decrypts data of a record of type typ sealed by seal.
+func open(aead cipher.AEAD, typ int64, data []byte) ([]byte, error) {
+	n := aead.NonceSize()
+	if len(data) < n {
+		return nil, ErrDecrypt
+	}
+	b, err := aead.Open(nil, data[:n], data[n:], typeAD(typ))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return b, nil
+}
+
+func typeAD(typ int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(typ))
+	return b
+}
@@ -0,0 +1,265 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/coreos/etcd/raft/raftpb"
+	"github.com/coreos/etcd/wal/walpb"
+)
+
+func TestEncryptedWAL(t *testing.T) {
+	p, err := ioutil.TempDir(os.TempDir(), "waltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(p)
+
+	keyFile := path.Join(p, "key")
+	if err = ioutil.WriteFile(keyFile, []byte("passphrase\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ReadEncryptionKeyFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waldir := path.Join(p, "wal")
+
+	metadata := []byte("plaintext-metadata")
+	w, err := CreateWithKey(waldir, metadata, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := raftpb.HardState{Term: 1, Commit: 1}
+	ents := []raftpb.Entry{{Index: 1, Term: 1, Data: []byte("plaintext-entry")}}
+	if err = w.Save(state, ents); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	b, err := ioutil.ReadFile(path.Join(waldir, walName(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"plaintext-metadata", "plaintext-entry"} {
+		if bytes.Contains(b, []byte(s)) {
+			t.Errorf("found %q in encrypted wal", s)
+		}
+	}
+
+	// reading requires the key
+	if w, err = Open(waldir, walpb.Snapshot{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = w.ReadAll(); err != ErrNoEncryptionKey {
+		t.Errorf("err = %v, want %v", err, ErrNoEncryptionKey)
+	}
+	w.Close()
+
+	wrongKey := make([]byte, len(key))
+	if w, err = OpenWithKey(waldir, walpb.Snapshot{}, wrongKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = w.ReadAll(); err != ErrDecrypt {
+		t.Errorf("err = %v, want %v", err, ErrDecrypt)
+	}
+	w.Close()
+
+	if w, err = OpenWithKey(waldir, walpb.Snapshot{}, key); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	gmetadata, gstate, gents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gmetadata, metadata) {
+		t.Errorf("metadata = %s, want %s", gmetadata, metadata)
+	}
+	if !reflect.DeepEqual(gstate, state) {
+		t.Errorf("state = %+v, want %+v", gstate, state)
+	}
+	if !reflect.DeepEqual(gents, ents) {
+		t.Errorf("ents = %+v, want %+v", gents, ents)
+	}
+
+	// records appended after reading are encrypted as well
+	ents2 := []raftpb.Entry{{Index: 2, Term: 1, Data: []byte("plaintext-entry2")}}
+	if err = w.Save(raftpb.HardState{}, ents2); err != nil {
+		t.Fatal(err)
+	}
+	if b, err = ioutil.ReadFile(path.Join(waldir, walName(0, 0))); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("plaintext-entry2")) {
+		t.Errorf("found %q in encrypted wal", "plaintext-entry2")
+	}
+}
+
+func TestEncryptedWALSegments(t *testing.T) {
+	p, err := ioutil.TempDir(os.TempDir(), "waltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(p)
+
+	key := []byte("secret")
+	w, err := CreateWithKey(p, []byte("metadata"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := raftpb.HardState{Term: 1, Commit: 2}
+	if err = w.Save(state, []raftpb.Entry{{Index: 1, Term: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.cut(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.SaveSnapshot(walpb.Snapshot{Index: 1, Term: 1}); err != nil {
+		t.Fatal(err)
+	}
+	ents := []raftpb.Entry{{Index: 2, Term: 1, Data: []byte("entry")}}
+	if err = w.Save(state, ents); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	// reading from the second segment derives the key from its own salt
+	if w, err = OpenForReadWithKey(p, walpb.Snapshot{Index: 1, Term: 1}, key); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, gstate, gents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gstate, state) {
+		t.Errorf("state = %+v, want %+v", gstate, state)
+	}
+	if !reflect.DeepEqual(gents, ents) {
+		t.Errorf("ents = %+v, want %+v", gents, ents)
+	}
+}
+
+func TestStartEncryptingWAL(t *testing.T) {
+	p, err := ioutil.TempDir(os.TempDir(), "waltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(p)
+
+	w, err := Create(p, []byte("metadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ents := []raftpb.Entry{{Index: 1, Term: 1, Data: []byte("plaintext-entry")}}
+	if err = w.Save(raftpb.HardState{}, ents); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	key := []byte("secret")
+	if w, err = OpenWithKey(p, walpb.Snapshot{}, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = w.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	ents = append(ents, raftpb.Entry{Index: 2, Term: 1, Data: []byte("secret-entry")})
+	if err = w.Save(raftpb.HardState{}, ents[1:]); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	b, err := ioutil.ReadFile(path.Join(p, walName(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("secret-entry")) {
+		t.Errorf("found %q in encrypted wal", "secret-entry")
+	}
+
+	if w, err = OpenForRead(p, walpb.Snapshot{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = w.ReadAll(); err != ErrNoEncryptionKey {
+		t.Errorf("err = %v, want %v", err, ErrNoEncryptionKey)
+	}
+	w.Close()
+
+	if w, err = OpenWithKey(p, walpb.Snapshot{}, key); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, _, gents, err := w.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gents, ents) {
+		t.Errorf("ents = %+v, want %+v", gents, ents)
+	}
+}
+
+func TestEncryptedRecordType(t *testing.T) {
+	key, salt := []byte("secret"), []byte("salt")
+	aead, err := newAEAD(key, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := seal(aead, entryType, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := true
+	for i, tt := range []struct {
+		typ  int64
+		werr error
+	}{
+		{entryType, nil},
+		// a sealed entry does not decrypt as another record type
+		{stateType, ErrDecrypt},
+	} {
+		buf := new(bytes.Buffer)
+		e := newEncoder(buf, 0)
+		if err = e.encode(&walpb.Record{Type: saltType, Data: salt}); err != nil {
+			t.Fatal(err)
+		}
+		if err = e.encode(&walpb.Record{Type: tt.typ, Data: sealed, Encrypted: &encrypted}); err != nil {
+			t.Fatal(err)
+		}
+		if err = e.flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		d := newDecoder(buf)
+		d.key = key
+		rec := &walpb.Record{}
+		if err = d.decode(rec); err != nil {
+			t.Fatal(err)
+		}
+		if err = d.decode(rec); err != tt.werr {
+			t.Errorf("#%d: err = %v, want %v", i, err, tt.werr)
+		}
+		if tt.werr == nil && string(rec.Data) != "data" {
+			t.Errorf("#%d: data = %q, want %q", i, rec.Data, "data")
+		}
+	}
+}
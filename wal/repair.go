@@ -26,6 +26,11 @@ import (
 // Repair tries to repair ErrUnexpectedEOF in the
 // last wal file by truncating.
 func Repair(dirpath string) bool {
+	return RepairWithKey(dirpath, nil)
+}
+
+// RepairWithKey repairs an encrypted WAL like Repair.
+func RepairWithKey(dirpath string, key []byte) bool {
 	f, err := openLast(dirpath)
 	if err != nil {
 		return false
@@ -36,11 +41,13 @@ func Repair(dirpath string) bool {
 	rec := &walpb.Record{}
 
 	decoder := newDecoder(f)
+	decoder.key = key
 	for {
 		err := decoder.decode(rec)
 		switch err {
 		case nil:
-			n += 8 + rec.Size()
+			// records may be decrypted, so count their size on disk
+			n = int(decoder.lastOffset())
 			// update crc of the decoder when necessary
 			switch rec.Type {
 			case crcType:
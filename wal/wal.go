@@ -15,6 +15,7 @@
 package wal
 
 import (
+	"crypto/cipher"
 	"errors"
 	"fmt"
 	"hash/crc32"
@@ -40,6 +41,7 @@ const (
 	stateType
 	crcType
 	snapshotType
+	saltType
 
 	// the owner can make/remove files inside the directory
 	privateDirMode = 0700
@@ -81,11 +83,41 @@ type WAL struct {
 
 	locks []*fileutil.LockedFile // the locked files the WAL holds (the name is increasing)
 	fp    *filePipeline
+
+	key  []byte      // secret the record key is derived from; nil if not encrypted
+	salt []byte      // salt recorded at the head of each WAL file if encrypted
+	aead cipher.AEAD // encrypts record data; nil if not encrypted
+
+	syncInterval time.Duration // minimum time between fsyncs of Save; zero syncs every Save
+	lastSync     time.Time     // time of the last fsync
+	unsynced     bool          // records were saved after the last fsync
+	syncTimer    *time.Timer   // fsyncs the unsynced records once syncInterval passes
 }
 
 // Create creates a WAL ready for appending records. The given metadata is
 // recorded at the head of each WAL file, and can be retrieved with ReadAll.
 func Create(dirpath string, metadata []byte) (*WAL, error) {
+	return CreateWithKey(dirpath, metadata, nil)
+}
+
+// CreateWithKey creates a WAL like Create. If key is not empty, the data of
+// every record is encrypted with AES-256-GCM, using a key derived from key
+// and a random salt recorded at the head of each WAL file; the WAL must
+// then be opened with OpenWithKey and the same key.
+func CreateWithKey(dirpath string, metadata []byte, key []byte) (*WAL, error) {
+	var (
+		salt []byte
+		aead cipher.AEAD
+		err  error
+	)
+	if len(key) != 0 {
+		if salt, err = newSalt(); err != nil {
+			return nil, err
+		}
+		if aead, err = newAEAD(key, salt); err != nil {
+			return nil, err
+		}
+	}
 	if Exist(dirpath) {
 		return nil, os.ErrExist
 	}
@@ -109,13 +141,19 @@ func Create(dirpath string, metadata []byte) (*WAL, error) {
 	w := &WAL{
 		dir:      dirpath,
 		metadata: metadata,
-		encoder:  newEncoder(f, 0),
 		fp:       newFilePipeline(dirpath, segmentSizeBytes),
+		key:      key,
+		salt:     salt,
+		aead:     aead,
 	}
+	w.encoder = w.newEncoder(f, 0)
 	w.locks = append(w.locks, f)
 	if err := w.saveCrc(0); err != nil {
 		return nil, err
 	}
+	if err := w.saveSalt(); err != nil {
+		return nil, err
+	}
 	if err := w.encoder.encode(&walpb.Record{Type: metadataType, Data: metadata}); err != nil {
 		return nil, err
 	}
@@ -132,16 +170,30 @@ func Create(dirpath string, metadata []byte) (*WAL, error) {
 // the given snap. The WAL cannot be appended to before reading out all of its
 // previous records.
 func Open(dirpath string, snap walpb.Snapshot) (*WAL, error) {
-	return openAtIndex(dirpath, snap, true)
+	return openAtIndex(dirpath, snap, true, nil)
+}
+
+// OpenWithKey opens the WAL at the given snap like Open, decrypting records
+// encrypted by a WAL created with CreateWithKey. Records appended after
+// ReadAll are encrypted with key if it is not empty, which also starts
+// encrypting a WAL that was not encrypted before.
+func OpenWithKey(dirpath string, snap walpb.Snapshot, key []byte) (*WAL, error) {
+	return openAtIndex(dirpath, snap, true, key)
 }
 
 // OpenForRead only opens the wal files for read.
 // Write on a read only wal panics.
 func OpenForRead(dirpath string, snap walpb.Snapshot) (*WAL, error) {
-	return openAtIndex(dirpath, snap, false)
+	return openAtIndex(dirpath, snap, false, nil)
 }
 
-func openAtIndex(dirpath string, snap walpb.Snapshot, write bool) (*WAL, error) {
+// OpenForReadWithKey opens the wal files for read like OpenForRead,
+// decrypting records encrypted by a WAL created with CreateWithKey.
+func OpenForReadWithKey(dirpath string, snap walpb.Snapshot, key []byte) (*WAL, error) {
+	return openAtIndex(dirpath, snap, false, key)
+}
+
+func openAtIndex(dirpath string, snap walpb.Snapshot, write bool, key []byte) (*WAL, error) {
 	names, err := fileutil.ReadDir(dirpath)
 	if err != nil {
 		return nil, err
@@ -191,7 +243,9 @@ func openAtIndex(dirpath string, snap walpb.Snapshot, write bool) (*WAL, error)
 		decoder:   newDecoder(rs...),
 		readClose: closer,
 		locks:     ls,
+		key:       key,
 	}
+	w.decoder.key = key
 
 	if write {
 		// write reuses the file descriptors from read; don't close so
@@ -267,6 +321,8 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 				}
 				match = true
 			}
+		case saltType:
+			w.salt = rec.Data
 		default:
 			state.Reset()
 			return nil, state, nil, fmt.Errorf("unexpected block type %d", rec.Type)
@@ -310,7 +366,14 @@ func (w *WAL) ReadAll() (metadata []byte, state raftpb.HardState, ents []raftpb.
 	if w.tail() != nil {
 		// create encoder (chain crc with the decoder), enable appending
 		_, err = w.tail().Seek(w.decoder.lastOffset(), os.SEEK_SET)
-		w.encoder = newEncoder(w.tail(), w.decoder.lastCRC())
+		w.aead = w.decoder.aead
+		w.encoder = w.newEncoder(w.tail(), w.decoder.lastCRC())
+		if w.aead == nil && len(w.key) != 0 {
+			if serr := w.startEncryption(); serr != nil {
+				state.Reset()
+				return nil, state, nil, serr
+			}
+		}
 		lastIndexSaved.Set(float64(w.enti))
 	}
 	w.decoder = nil
@@ -345,10 +408,13 @@ func (w *WAL) cut() error {
 	// update writer and save the previous crc
 	w.locks = append(w.locks, newTail)
 	prevCrc := w.encoder.crc.Sum32()
-	w.encoder = newEncoder(w.tail(), prevCrc)
+	w.encoder = w.newEncoder(w.tail(), prevCrc)
 	if err = w.saveCrc(prevCrc); err != nil {
 		return err
 	}
+	if err = w.saveSalt(); err != nil {
+		return err
+	}
 	if err = w.encoder.encode(&walpb.Record{Type: metadataType, Data: w.metadata}); err != nil {
 		return err
 	}
@@ -380,12 +446,44 @@ func (w *WAL) cut() error {
 	w.locks[len(w.locks)-1] = newTail
 
 	prevCrc = w.encoder.crc.Sum32()
-	w.encoder = newEncoder(w.tail(), prevCrc)
+	w.encoder = w.newEncoder(w.tail(), prevCrc)
 
 	plog.Infof("segmented wal file %v is created", fpath)
 	return nil
 }
 
+// startEncryption encrypts the records appended to a WAL that was not
+// encrypted so far. The salt record marks where decryption starts.
+func (w *WAL) startEncryption() error {
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(w.key, salt)
+	if err != nil {
+		return err
+	}
+	w.salt, w.aead = salt, aead
+	w.encoder.aead = aead
+	return w.saveSalt()
+}
+
+// saveSalt records the salt of an encrypted WAL.
+func (w *WAL) saveSalt() error {
+	if w.salt == nil {
+		return nil
+	}
+	return w.encoder.encode(&walpb.Record{Type: saltType, Data: w.salt})
+}
+
+// newEncoder returns an encoder writing to f that encrypts records if the
+// WAL is encrypted.
+func (w *WAL) newEncoder(f io.Writer, prevCrc uint32) *encoder {
+	e := newEncoder(f, prevCrc)
+	e.aead = w.aead
+	return e
+}
+
 func (w *WAL) sync() error {
 	if w.encoder != nil {
 		if err := w.encoder.flush(); err != nil {
@@ -395,9 +493,46 @@ func (w *WAL) sync() error {
 	start := time.Now()
 	err := fileutil.Fdatasync(w.tail().File)
 	syncDurations.Observe(float64(time.Since(start)) / float64(time.Second))
+	if err == nil {
+		w.lastSync, w.unsynced = start, false
+	}
 	return err
 }
 
+// SetSyncInterval makes Save fsync at most once per d, so the entries of
+// the Saves made within d are flushed to disk together. The records of a
+// Save that skipped its fsync are synced by a later Save or, at the
+// latest, d after the last fsync; they may be lost if the machine crashes
+// in between. A Save that changes the term or vote of the HardState is
+// always fsynced at once; only Saves of entries are batched. A zero d, the
+// default, fsyncs every Save that needs it.
+func (w *WAL) SetSyncInterval(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncInterval = d
+}
+
+// deferSync records that the saved records still need an fsync, and
+// schedules it for when the sync interval has passed.
+func (w *WAL) deferSync() {
+	w.unsynced = true
+	if w.syncTimer == nil {
+		w.syncTimer = time.AfterFunc(w.syncInterval-time.Since(w.lastSync), w.syncDeferred)
+	}
+}
+
+func (w *WAL) syncDeferred() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncTimer = nil
+	if !w.unsynced || w.tail() == nil {
+		return
+	}
+	if err := w.sync(); err != nil {
+		plog.Errorf("failed to sync wal: %v", err)
+	}
+}
+
 // ReleaseLockTo releases the locks, which has smaller index than the given index
 // except the largest one among them.
 // For example, if WAL is holding lock 1,2,3,4,5,6, ReleaseLockTo(4) will release
@@ -450,6 +585,10 @@ func (w *WAL) Close() error {
 		w.fp.Close()
 		w.fp = nil
 	}
+	if w.syncTimer != nil {
+		w.syncTimer.Stop()
+		w.syncTimer = nil
+	}
 
 	if w.tail() != nil {
 		if err := w.sync(); err != nil {
@@ -499,6 +638,8 @@ func (w *WAL) Save(st raftpb.HardState, ents []raftpb.Entry) error {
 	}
 
 	mustSync := mustSync(st, w.state, len(ents))
+	// a vote must be on disk before it is sent; never delay it
+	stateChanged := !raft.IsEmptyHardState(st) && (st.Term != w.state.Term || st.Vote != w.state.Vote)
 
 	// TODO(xiangli): no more reference operator
 	for i := range ents {
@@ -515,10 +656,14 @@ func (w *WAL) Save(st raftpb.HardState, ents []raftpb.Entry) error {
 		return err
 	}
 	if curOff < segmentSizeBytes {
-		if mustSync {
-			return w.sync()
+		if !mustSync {
+			return nil
 		}
-		return nil
+		if !stateChanged && w.syncInterval > 0 && time.Since(w.lastSync) < w.syncInterval {
+			w.deferSync()
+			return nil
+		}
+		return w.sync()
 	}
 
 	// TODO: add a test for this code path when refactoring the tests
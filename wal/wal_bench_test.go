@@ -18,6 +18,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/coreos/etcd/raft/raftpb"
 )
@@ -66,3 +67,39 @@ func benchmarkWriteEntry(b *testing.B, size int, batch int) {
 		}
 	}
 }
+
+func BenchmarkSave100EntryWithoutSyncInterval(b *testing.B) { benchmarkSaveEntry(b, 100, 0) }
+func BenchmarkSave100EntrySyncInterval1ms(b *testing.B) {
+	benchmarkSaveEntry(b, 100, time.Millisecond)
+}
+func BenchmarkSave100EntrySyncInterval10ms(b *testing.B) {
+	benchmarkSaveEntry(b, 100, 10*time.Millisecond)
+}
+
+func benchmarkSaveEntry(b *testing.B, size int, interval time.Duration) {
+	p, err := ioutil.TempDir(os.TempDir(), "waltest")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(p)
+
+	w, err := Create(p, []byte("somedata"))
+	if err != nil {
+		b.Fatalf("err = %v, want nil", err)
+	}
+	defer w.Close()
+	w.SetSyncInterval(interval)
+	data := make([]byte, size)
+	for i := 0; i < len(data); i++ {
+		data[i] = byte(i)
+	}
+
+	b.ResetTimer()
+	b.SetBytes(int64((&raftpb.Entry{Data: data}).Size()))
+	for i := 0; i < b.N; i++ {
+		ents := []raftpb.Entry{{Index: uint64(i + 1), Data: data}}
+		if err := w.Save(raftpb.HardState{}, ents); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
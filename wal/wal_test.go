@@ -22,6 +22,7 @@ import (
 	"path"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/coreos/etcd/pkg/pbutil"
 	"github.com/coreos/etcd/raft/raftpb"
@@ -533,3 +534,60 @@ func TestReleaseLockTo(t *testing.T) {
 		t.Errorf("lockindex = %d, want %d", lockIndex, 10)
 	}
 }
+
+func TestSaveSyncInterval(t *testing.T) {
+	p, err := ioutil.TempDir(os.TempDir(), "waltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(p)
+
+	w, err := Create(p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.SetSyncInterval(100 * time.Millisecond)
+
+	if err = w.Save(raftpb.HardState{}, []raftpb.Entry{{Index: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	w.mu.Lock()
+	unsynced := w.unsynced
+	w.mu.Unlock()
+	// Create has just synced, so the save waits for the interval
+	if !unsynced {
+		t.Fatalf("unsynced = false, want true")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	w.mu.Lock()
+	unsynced = w.unsynced
+	w.mu.Unlock()
+	if unsynced {
+		t.Fatalf("unsynced = true after the sync interval, want false")
+	}
+
+	tests := []struct {
+		st        raftpb.HardState
+		wunsynced bool
+	}{
+		// a new term and vote are synced at once
+		{raftpb.HardState{Term: 1, Vote: 1, Commit: 1}, false},
+		// a commit index alone is batched with the entry
+		{raftpb.HardState{Term: 1, Vote: 1, Commit: 2}, true},
+		{raftpb.HardState{Term: 1, Vote: 2, Commit: 3}, false},
+		{raftpb.HardState{}, true},
+	}
+	for i, tt := range tests {
+		if err = w.Save(tt.st, []raftpb.Entry{{Index: uint64(i + 2), Term: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		w.mu.Lock()
+		unsynced = w.unsynced
+		w.mu.Unlock()
+		if unsynced != tt.wunsynced {
+			t.Errorf("#%d: unsynced = %v, want %v", i, unsynced, tt.wunsynced)
+		}
+	}
+}
@@ -30,9 +30,11 @@ var _ = fmt.Errorf
 var _ = math.Inf
 
 type Record struct {
-	Type             int64  `protobuf:"varint,1,opt,name=type" json:"type"`
-	Crc              uint32 `protobuf:"varint,2,opt,name=crc" json:"crc"`
-	Data             []byte `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	Type int64  `protobuf:"varint,1,opt,name=type" json:"type"`
+	Crc  uint32 `protobuf:"varint,2,opt,name=crc" json:"crc"`
+	Data []byte `protobuf:"bytes,3,opt,name=data" json:"data,omitempty"`
+	// encrypted is set when data is sealed with the WAL encryption key.
+	Encrypted        *bool  `protobuf:"varint,4,opt,name=encrypted" json:"encrypted,omitempty"`
 	XXX_unrecognized []byte `json:"-"`
 }
 
@@ -81,6 +83,16 @@ func (m *Record) MarshalTo(data []byte) (int, error) {
 		i = encodeVarintRecord(data, i, uint64(len(m.Data)))
 		i += copy(data[i:], m.Data)
 	}
+	if m.Encrypted != nil {
+		data[i] = 0x20
+		i++
+		if *m.Encrypted {
+			data[i] = 1
+		} else {
+			data[i] = 0
+		}
+		i++
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -150,6 +162,9 @@ func (m *Record) Size() (n int) {
 		l = len(m.Data)
 		n += 1 + l + sovRecord(uint64(l))
 	}
+	if m.Encrypted != nil {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -278,6 +293,27 @@ func (m *Record) Unmarshal(data []byte) error {
 				m.Data = []byte{}
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encrypted", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRecord
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Encrypted = &b
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRecord(data[iNdEx:])